@@ -0,0 +1,260 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/bench"
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+	"github.com/codefresh-io/go/venona/pkg/simulator"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/spf13/cobra"
+)
+
+type benchOptions struct {
+	workflows     int
+	batchSize     int
+	batchInterval time.Duration
+	tasks         []string
+	runtimes      int
+	concurrency   int
+	bufferSize    int
+	qps           float32
+	burst         int
+	latencies     []string
+	errorRates    []string
+	throttleRate  float64
+	seed          int64
+	output        string
+	verbose       bool
+}
+
+type benchResult struct {
+	*bench.Report
+	APIServer map[string]simulator.OperationStats `json:"apiServer"`
+}
+
+const (
+	benchOutputText = "text"
+	benchOutputJSON = "json"
+)
+
+var benchCmdOptions benchOptions
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load test the workflow queue against simulated runtimes",
+	Long: `Load test the workflow queue against simulated runtimes, in order to size --workflow-concurrency and --k8s-client-qps/burst.
+Synthetic workflows are enqueued in batches, and handled by runtimes whose API server is simulated in process.
+Latencies are given per verb (create, delete, patch or * for all others) as <verb>=<distribution>, where the distribution is one of
+<duration>, uniform:<min>-<max>, normal:<mean>,<stddev> or exponential:<mean>. Error rates are given as <verb>=<fraction>`,
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if benchCmdOptions.workflows <= 0 || benchCmdOptions.batchSize <= 0 || benchCmdOptions.runtimes <= 0 {
+			return errors.New("--workflows, --batch-size and --runtimes must be positive numbers")
+		}
+
+		if benchCmdOptions.concurrency <= 0 || benchCmdOptions.bufferSize <= 0 {
+			return errors.New("--workflow-concurrency and --workflow-buffer-size must be positive numbers")
+		}
+
+		if benchCmdOptions.qps <= 0 || benchCmdOptions.burst <= 0 {
+			return errors.New("--k8s-client-qps and --k8s-client-burst must be positive numbers")
+		}
+
+		if benchCmdOptions.output != benchOutputText && benchCmdOptions.output != benchOutputJSON {
+			return fmt.Errorf("--output must be %s or %s", benchOutputText, benchOutputJSON)
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runBench(cmd.Context(), benchCmdOptions, os.Stdout)
+	},
+}
+
+func runBench(ctx context.Context, options benchOptions, out io.Writer) error {
+	simOpts, err := simulatorOptions(options)
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]task.Type, 0, len(options.tasks))
+	for _, t := range options.tasks {
+		switch tt := task.Type(t); tt {
+		case task.TypeCreatePod, task.TypeCreatePVC, task.TypeDeletePod, task.TypeDeletePVC:
+			tasks = append(tasks, tt)
+		default:
+			return fmt.Errorf("unknown task type \"%s\"", t)
+		}
+	}
+
+	// the agent logs every workflow, only the report is printed unless asked for
+	logOpts := logger.Options{Level: "crit", Verbose: options.verbose}
+	log := logger.New(logOpts)
+	sim, err := simulator.New(simOpts)
+	if err != nil {
+		return err
+	}
+	defer sim.Close()
+
+	runtimes := map[string]runtime.Runtime{}
+	for i := range options.runtimes {
+		// every runtime has a client of its own, with its own rate limiter, like the runtimes of the agent
+		re, err := sim.Runtime(kubernetes.Options{
+			Logger: log.New("module", "k8s"),
+			QPS:    options.qps,
+			Burst:  options.burst,
+		})
+		if err != nil {
+			return err
+		}
+
+		runtimes[fmt.Sprintf("runtime-%d", i)] = re
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	report, err := bench.Run(ctx, &bench.Options{
+		Runtimes:      runtimes,
+		Logger:        log.New("module", "queue"),
+		Concurrency:   options.concurrency,
+		BufferSize:    options.bufferSize,
+		Workflows:     options.workflows,
+		BatchSize:     options.batchSize,
+		BatchInterval: options.batchInterval,
+		Tasks:         tasks,
+	})
+	if err != nil {
+		return err
+	}
+
+	result := benchResult{Report: report, APIServer: sim.Stats()}
+	if options.output == benchOutputJSON {
+		return json.NewEncoder(out).Encode(result)
+	}
+
+	return writeBenchResult(out, result)
+}
+
+func simulatorOptions(options benchOptions) (simulator.Options, error) {
+	opts := simulator.Options{
+		Operations:   map[string]simulator.Operation{},
+		ThrottleRate: options.throttleRate,
+		Seed:         options.seed,
+	}
+	for _, l := range options.latencies {
+		verb, value, ok := strings.Cut(l, "=")
+		if !ok {
+			return opts, fmt.Errorf("invalid latency \"%s\", expected verb=distribution", l)
+		}
+
+		d, err := simulator.ParseDistribution(value)
+		if err != nil {
+			return opts, err
+		}
+
+		op := opts.Operations[verb]
+		op.Latency = d
+		opts.Operations[verb] = op
+	}
+
+	for _, e := range options.errorRates {
+		verb, value, ok := strings.Cut(e, "=")
+		if !ok {
+			return opts, fmt.Errorf("invalid error rate \"%s\", expected verb=fraction", e)
+		}
+
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return opts, fmt.Errorf("invalid error rate \"%s\", expected a fraction between 0 and 1", e)
+		}
+
+		op := opts.Operations[verb]
+		op.ErrorRate = rate
+		opts.Operations[verb] = op
+	}
+
+	return opts, nil
+}
+
+func writeBenchResult(out io.Writer, result benchResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "workflows\t%d\n", result.Workflows)
+	fmt.Fprintf(w, "tasks\t%d (%d failed)\n", result.Tasks, result.FailedTasks)
+	fmt.Fprintf(w, "duration\t%s\n", result.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput\t%.2f workflows/s\n", result.Throughput)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "latency\tp50\tp90\tp95\tp99\tmax")
+	for _, l := range []struct {
+		name string
+		p    bench.Percentiles
+	}{{"workflow", result.WorkflowLatency}, {"task", result.TaskLatency}} {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", l.name, round(l.p.P50), round(l.p.P90), round(l.p.P95), round(l.p.P99), round(l.p.Max))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "api server\trequests\terrors\tthrottled")
+	verbs := make([]string, 0, len(result.APIServer))
+	for verb := range result.APIServer {
+		verbs = append(verbs, verb)
+	}
+	sort.Strings(verbs)
+	for _, verb := range verbs {
+		s := result.APIServer[verb]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", verb, s.Requests, s.Errors, s.Throttled)
+	}
+
+	return w.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchCmdOptions.workflows, "workflows", 1000, "How many workflows to run")
+	benchCmd.Flags().IntVar(&benchCmdOptions.batchSize, "batch-size", 100, "How many workflows to enqueue at once")
+	benchCmd.Flags().DurationVar(&benchCmdOptions.batchInterval, "batch-interval", time.Second, "The time between batches, like the task pulling interval")
+	benchCmd.Flags().StringSliceVar(&benchCmdOptions.tasks, "tasks", []string{string(task.TypeCreatePVC), string(task.TypeCreatePod)}, "The task types of every workflow, in order")
+	benchCmd.Flags().IntVar(&benchCmdOptions.runtimes, "runtimes", 1, "How many runtimes to spread the workflows between")
+	benchCmd.Flags().IntVar(&benchCmdOptions.concurrency, "workflow-concurrency", defaultWorkflowConcurrency, "How many workflow tasks to handle concurrently")
+	benchCmd.Flags().IntVar(&benchCmdOptions.bufferSize, "workflow-buffer-size", defaultWorkflowBufferSize, "The size of the workflow channel buffer")
+	benchCmd.Flags().Float32Var(&benchCmdOptions.qps, "k8s-client-qps", defaultK8sClientQPS, "the maximum QPS to the master from each runtime client")
+	benchCmd.Flags().IntVar(&benchCmdOptions.burst, "k8s-client-burst", defaultK8sClientBurst, "k8s client maximum burst for throttle")
+	benchCmd.Flags().StringArrayVar(&benchCmdOptions.latencies, "latency", []string{"*=uniform:10ms-50ms"}, "Latency of the API server per verb, can be repeated, e.g. create=exponential:30ms")
+	benchCmd.Flags().StringArrayVar(&benchCmdOptions.errorRates, "error-rate", nil, "Fraction of the requests of a verb that fail, can be repeated, e.g. create=0.01")
+	benchCmd.Flags().Float64Var(&benchCmdOptions.throttleRate, "throttle-rate", 0, "Fraction of the requests that the API server throttles with 429, the client retries them after a second")
+	benchCmd.Flags().Int64Var(&benchCmdOptions.seed, "seed", 0, "Seed of the simulation, for repeatable runs")
+	benchCmd.Flags().StringVar(&benchCmdOptions.output, "output", benchOutputText, "Report format: text or json")
+	benchCmd.Flags().BoolVar(&benchCmdOptions.verbose, "verbose", false, "Show the agent logs")
+
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,264 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/agent"
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/fakeplatform"
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/recorder"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakePlatformOptions struct {
+	port    string
+	agentID string
+	token   string
+	script  string
+	faults  []string
+	verbose bool
+}
+
+type replayOptions struct {
+	recording   string
+	speed       float64
+	wait        time.Duration
+	concurrency int
+	verbose     bool
+}
+
+const replayAgentID = "replay"
+
+var (
+	fakePlatformCmdOptions fakePlatformOptions
+	replayCmdOptions       replayOptions
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Tools for developing and testing the agent locally",
+}
+
+var fakePlatformCmd = &cobra.Command{
+	Use:   "fake-platform",
+	Short: "Serve a fake of the Codefresh agent API, run the agent with --codefresh-host pointing to it",
+	Long: `Serve a fake of the Codefresh agent API that hands out scripted workflows and records the reported statuses.
+More tasks can be added with POST /fake/tasks, and the reported statuses are listed in GET /fake/statuses.
+Faults are given as <endpoint>[,status=<code>][,latency=<duration>][,count=<n>], where the endpoint is one of tasks, statuses, release or status`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runFakePlatform(cmd.Context(), fakePlatformCmdOptions)
+	},
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay a recording of the agent (--record-file) against a fake platform and fake runtimes",
+	Long: `Replay a recording of the agent (--record-file) against a fake platform and fake runtimes.
+The recorded task batches are handed to an agent at their recorded times, divided by --speed, and the task statuses it reports
+are compared with the recorded ones. The runtimes are fake clusters, one per runtime of the recorded tasks`,
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if replayCmdOptions.recording == "" {
+			return errors.New("--recording is required")
+		}
+
+		if replayCmdOptions.concurrency <= 0 {
+			return errors.New("--workflow-concurrency must be a positive number")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runReplay(cmd.Context(), replayCmdOptions)
+	},
+}
+
+func runReplay(ctx context.Context, options replayOptions) error {
+	log := logger.New(logger.Options{Verbose: options.verbose})
+	records, err := recorder.LoadRecording(options.recording)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	platform := fakeplatform.New(fakeplatform.Options{
+		AgentID: replayAgentID,
+		Logger:  log.New("module", "fakeplatform"),
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           platform,
+		ReadHeaderTimeout: 60 * time.Second,
+	}
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Close()
+
+	a, err := agent.New(&agent.Options{
+		ID: replayAgentID,
+		Codefresh: codefresh.New(codefresh.Options{
+			Host:       fmt.Sprintf("http://%s", listener.Addr()),
+			AgentID:    replayAgentID,
+			HTTPClient: http.DefaultClient,
+		}),
+		Logger:                         log.New("module", "agent"),
+		Runtimes:                       replayRuntimes(records, log.New("module", "k8s")),
+		TaskPullingSecondsInterval:     100 * time.Millisecond,
+		StatusReportingSecondsInterval: time.Minute,
+		DrainTimeout:                   time.Second,
+		Concurrency:                    options.concurrency,
+		BufferSize:                     defaultWorkflowBufferSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+	defer func() { _ = a.Stop() }()
+
+	count := recorder.Replay(ctx, platform, records, options.speed)
+	log.Info("Replayed all task batches, waiting for the task statuses", "tasks", count)
+
+	// the agent is done once all the recorded statuses are reported, or when the wait is over
+	mismatches := recorder.Compare(records, platform.StatusesOf)
+	deadline := time.After(options.wait)
+	for len(mismatches) > 0 || platform.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			for _, m := range mismatches {
+				log.Warn("Task status differs from the recording", "task", m.TaskID, "recorded", m.Recorded, "replayed", m.Replayed)
+			}
+
+			return fmt.Errorf("%d tasks did not replay as recorded", len(mismatches))
+		case <-time.After(100 * time.Millisecond):
+			mismatches = recorder.Compare(records, platform.StatusesOf)
+		}
+	}
+
+	log.Info("Replayed the recording, all task statuses match", "tasks", count, "statuses", len(platform.Statuses()))
+	return nil
+}
+
+// replayRuntimes creates an empty fake cluster for every runtime of the recorded tasks
+func replayRuntimes(records []recorder.Record, log logger.Logger) map[string]runtime.Runtime {
+	runtimes := map[string]runtime.Runtime{}
+	for _, record := range records {
+		for _, t := range record.Tasks {
+			if _, ok := runtimes[t.Metadata.ReName]; ok || t.Metadata.ReName == "" {
+				continue
+			}
+
+			runtimes[t.Metadata.ReName] = runtime.New(runtime.Options{
+				Kubernetes: kubernetes.NewForClient(fake.NewClientset(), kubernetes.Options{Logger: log}),
+			})
+		}
+	}
+
+	return runtimes
+}
+
+func runFakePlatform(ctx context.Context, options fakePlatformOptions) error {
+	log := logger.New(logger.Options{Verbose: options.verbose})
+	platform := fakeplatform.New(fakeplatform.Options{
+		AgentID: options.agentID,
+		Token:   options.token,
+		Logger:  log,
+	})
+	for _, f := range options.faults {
+		endpoint, fault, err := fakeplatform.ParseFault(f)
+		if err != nil {
+			return err
+		}
+
+		if err := platform.InjectFault(endpoint, fault); err != nil {
+			return fmt.Errorf("invalid fault \"%s\": %w", f, err)
+		}
+	}
+
+	var script *fakeplatform.Script
+	if options.script != "" {
+		var err error
+		if script, err = fakeplatform.LoadScript(options.script); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%s", options.port),
+		Handler:           platform,
+		ReadHeaderTimeout: 60 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		log.Warn("Received shutdown request, stopping fake platform...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err.Error())
+		}
+	}()
+
+	if script != nil {
+		go platform.Play(ctx, script)
+	}
+
+	log.Info("Starting fake platform", "port", options.port, "agent", options.agentID)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	fakePlatformCmd.Flags().StringVar(&fakePlatformCmdOptions.port, "port", "8081", "The port to serve the fake platform on")
+	fakePlatformCmd.Flags().StringVar(&fakePlatformCmdOptions.agentID, "agent-id", "", "Only serve this agent id, any agent id is accepted when empty")
+	fakePlatformCmd.Flags().StringVar(&fakePlatformCmdOptions.token, "token", "", "Only accept requests with this token, any token is accepted when empty")
+	fakePlatformCmd.Flags().StringVar(&fakePlatformCmdOptions.script, "script", "", "Path of a YAML or JSON script of workflow batches to hand out")
+	fakePlatformCmd.Flags().StringArrayVar(&fakePlatformCmdOptions.faults, "fault", nil, "Inject a fault to an endpoint, can be repeated, e.g. tasks,status=503,latency=2s,count=3")
+	fakePlatformCmd.Flags().BoolVar(&fakePlatformCmdOptions.verbose, "verbose", false, "Show more logs")
+
+	replayCmd.Flags().StringVar(&replayCmdOptions.recording, "recording", "", "Path of the JSONL recording to replay")
+	replayCmd.Flags().Float64Var(&replayCmdOptions.speed, "speed", 1, "How much faster than recorded to replay the task batches, 0 replays all of them at once")
+	replayCmd.Flags().DurationVar(&replayCmdOptions.wait, "wait", 30*time.Second, "How long to wait for the task statuses after the last batch is replayed")
+	replayCmd.Flags().IntVar(&replayCmdOptions.concurrency, "workflow-concurrency", defaultWorkflowConcurrency, "How many workflow tasks to handle concurrently")
+	replayCmd.Flags().BoolVar(&replayCmdOptions.verbose, "verbose", false, "Show more logs")
+
+	devCmd.AddCommand(fakePlatformCmd, replayCmd)
+	rootCmd.AddCommand(devCmd)
+}
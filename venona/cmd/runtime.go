@@ -0,0 +1,340 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/lifecycle"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type runtimeOptions struct {
+	codefreshHost  string
+	codefreshToken string
+	kubeconfig     string
+	kubeContext    string
+	namespace      string
+	verbose        bool
+	agentName      string
+	runtimeName    string
+	clusterName    string
+	ownerName      string
+	secretName     string
+	dindSecretName string
+	agentToken     string
+	existingToken  string
+	configDir      string
+	agent          bool
+	dryRun         bool
+}
+
+const (
+	defaultRuntimeConfigDir = "/opt/codefresh"
+	defaultDindSecretName   = "codefresh-certs-server"
+)
+
+var (
+	runtimeCmdOptions runtimeOptions
+	// runtimeViper is separate from the global viper, the runtime commands read different env vars for the same flags as start
+	runtimeViper = viper.New()
+)
+
+var runtimeCmd = &cobra.Command{
+	Use:   "runtime",
+	Short: "Manage the agent and its runtime environments in Codefresh",
+}
+
+var runtimeInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the agent and its runtime environment, and store the agent token in a secret",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if runtimeCmdOptions.agentName == "" || runtimeCmdOptions.runtimeName == "" || runtimeCmdOptions.secretName == "" {
+			return errors.New("--agent-name, --runtime-name and --secret-name are required")
+		}
+
+		if runtimeCmdOptions.codefreshToken == "" && runtimeCmdOptions.agentToken == "" && runtimeCmdOptions.existingToken == "" {
+			return errors.New("--codefresh-token is required when there is no agent token")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if runtimeCmdOptions.codefreshToken == "" && runtimeCmdOptions.agentToken != "" {
+			log := logger.New(logger.Options{Verbose: runtimeCmdOptions.verbose})
+			log.Warn("runtime and agent are already initialized, skipping attach without a codefresh token")
+			return nil
+		}
+
+		l, err := buildLifecycle(runtimeCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		return l.Init(cmd.Context(), lifecycle.InitOptions{
+			AgentName:          runtimeCmdOptions.agentName,
+			RuntimeName:        runtimeCmdOptions.runtimeName,
+			ClusterName:        runtimeCmdOptions.clusterName,
+			OwnerName:          runtimeCmdOptions.ownerName,
+			SecretName:         runtimeCmdOptions.secretName,
+			AgentToken:         runtimeCmdOptions.agentToken,
+			ExistingAgentToken: runtimeCmdOptions.existingToken,
+			SkipAttach:         runtimeCmdOptions.codefreshToken == "",
+		})
+	},
+}
+
+var runtimeAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach the runtime environment to the agent",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if runtimeCmdOptions.agentName == "" || runtimeCmdOptions.runtimeName == "" {
+			return errors.New("--agent-name and --runtime-name are required")
+		}
+
+		return checkCodefreshToken(runtimeCmdOptions)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l, err := buildLifecycle(runtimeCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		return l.Attach(cmd.Context(), runtimeCmdOptions.agentName, runtimeCmdOptions.runtimeName)
+	},
+}
+
+var runtimePatchCmd = &cobra.Command{
+	Use:   "patch",
+	Short: "Update the runtime environments from the specs in the config directory",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		return checkCodefreshToken(runtimeCmdOptions)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l, err := buildLifecycle(runtimeCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		results, err := l.Patch(cmd.Context(), lifecycle.PatchOptions{
+			Dir:    runtimeCmdOptions.configDir,
+			Agent:  runtimeCmdOptions.agent,
+			DryRun: runtimeCmdOptions.dryRun,
+		})
+		printPatchResults(cmd.OutOrStdout(), results)
+		if err != nil {
+			return fmt.Errorf("failed patching runtime environments: %w", err)
+		}
+
+		return nil
+	},
+}
+
+var runtimeCleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove the runtime environment, the agent and their secrets",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		return checkCodefreshToken(runtimeCmdOptions)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l, err := buildLifecycle(runtimeCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		secrets := []string{}
+		for _, s := range []string{runtimeCmdOptions.secretName, runtimeCmdOptions.dindSecretName} {
+			if s != "" {
+				secrets = append(secrets, s)
+			}
+		}
+
+		return l.Cleanup(cmd.Context(), lifecycle.CleanupOptions{
+			AgentName:   runtimeCmdOptions.agentName,
+			RuntimeName: runtimeCmdOptions.runtimeName,
+			Agent:       runtimeCmdOptions.agent,
+			SecretNames: secrets,
+		})
+	},
+}
+
+func init() {
+	dieOnError(runtimeViper.BindEnv("codefresh-host", "API_HOST"))
+	dieOnError(runtimeViper.BindEnv("codefresh-token", "USER_CODEFRESH_TOKEN", "API_KEY", "API_TOKEN"))
+	dieOnError(runtimeViper.BindEnv("kube-context", "KUBE_CONTEXT_OVERRIDE"))
+	dieOnError(runtimeViper.BindEnv("namespace", "KUBE_NAMESPACE"))
+	dieOnError(runtimeViper.BindEnv("agent-name", "AGENT_NAME"))
+	dieOnError(runtimeViper.BindEnv("runtime-name", "RUNTIME_NAME"))
+	dieOnError(runtimeViper.BindEnv("cluster-name", "KUBE_CONTEXT"))
+	dieOnError(runtimeViper.BindEnv("owner-name", "OWNER_NAME"))
+	dieOnError(runtimeViper.BindEnv("secret-name", "SECRET_NAME", "AGENT_SECRET_NAME"))
+	dieOnError(runtimeViper.BindEnv("dind-secret-name", "DIND_SECRET_NAME"))
+	dieOnError(runtimeViper.BindEnv("agent-token", "AGENT_CODEFRESH_TOKEN"))
+	dieOnError(runtimeViper.BindEnv("existing-agent-token", "EXISTING_AGENT_CODEFRESH_TOKEN"))
+	dieOnError(runtimeViper.BindEnv("config-dir", "RUNTIME_CONFIG_DIR"))
+	dieOnError(runtimeViper.BindEnv("agent", "AGENT"))
+	runtimeViper.SetDefault("codefresh-host", defaultCodefreshHost)
+	runtimeViper.SetDefault("config-dir", defaultRuntimeConfigDir)
+	runtimeViper.SetDefault("dind-secret-name", defaultDindSecretName)
+	runtimeViper.SetDefault("agent", true)
+
+	runtimeCmd.PersistentFlags().StringVar(&runtimeCmdOptions.codefreshHost, "codefresh-host", runtimeViper.GetString("codefresh-host"), "Codefresh API host [$API_HOST]")
+	runtimeCmd.PersistentFlags().StringVar(&runtimeCmdOptions.codefreshToken, "codefresh-token", runtimeViper.GetString("codefresh-token"), "Codefresh user API token [$USER_CODEFRESH_TOKEN]")
+	runtimeCmd.PersistentFlags().StringVar(&runtimeCmdOptions.kubeconfig, "kubeconfig", runtimeViper.GetString("kubeconfig"), "Path to a kubeconfig, by default $KUBECONFIG or ~/.kube/config are used, and the in-cluster config when neither exists")
+	runtimeCmd.PersistentFlags().StringVar(&runtimeCmdOptions.kubeContext, "kube-context", runtimeViper.GetString("kube-context"), "The kubeconfig context to use [$KUBE_CONTEXT_OVERRIDE]")
+	runtimeCmd.PersistentFlags().StringVar(&runtimeCmdOptions.namespace, "namespace", runtimeViper.GetString("namespace"), "The namespace to work in, by default the one of the kubeconfig context [$KUBE_NAMESPACE]")
+	runtimeCmd.PersistentFlags().BoolVar(&runtimeCmdOptions.verbose, "verbose", false, "Show more logs")
+
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.agentName, "agent-name", runtimeViper.GetString("agent-name"), "Name of the agent [$AGENT_NAME]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.runtimeName, "runtime-name", runtimeViper.GetString("runtime-name"), "Name of the runtime environment [$RUNTIME_NAME]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.clusterName, "cluster-name", runtimeViper.GetString("cluster-name"), "Name of the cluster in Codefresh [$KUBE_CONTEXT]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.ownerName, "owner-name", runtimeViper.GetString("owner-name"), "Name of the runner deployment that owns the agent secret [$OWNER_NAME]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.secretName, "secret-name", runtimeViper.GetString("secret-name"), "Name of the agent secret [$SECRET_NAME]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.agentToken, "agent-token", runtimeViper.GetString("agent-token"), "Token from an existing agent secret [$AGENT_CODEFRESH_TOKEN]")
+	runtimeInitCmd.Flags().StringVar(&runtimeCmdOptions.existingToken, "existing-agent-token", runtimeViper.GetString("existing-agent-token"), "Token of an agent that was created in advance [$EXISTING_AGENT_CODEFRESH_TOKEN]")
+
+	runtimeAttachCmd.Flags().StringVar(&runtimeCmdOptions.agentName, "agent-name", runtimeViper.GetString("agent-name"), "Name of the agent [$AGENT_NAME]")
+	runtimeAttachCmd.Flags().StringVar(&runtimeCmdOptions.runtimeName, "runtime-name", runtimeViper.GetString("runtime-name"), "Name of the runtime environment [$RUNTIME_NAME]")
+
+	runtimePatchCmd.Flags().StringVar(&runtimeCmdOptions.configDir, "config-dir", runtimeViper.GetString("config-dir"), "Path of the runtime environment specs [$RUNTIME_CONFIG_DIR]")
+	runtimePatchCmd.Flags().BoolVar(&runtimeCmdOptions.dryRun, "dry-run", false, "Only print the changes, without updating the runtime environments")
+	runtimePatchCmd.Flags().BoolVar(&runtimeCmdOptions.agent, "agent", runtimeViper.GetBool("agent"), "Patch the specs as agent runtime environments, otherwise as system ones [$AGENT]")
+
+	runtimeCleanupCmd.Flags().StringVar(&runtimeCmdOptions.agentName, "agent-name", runtimeViper.GetString("agent-name"), "Name of the agent [$AGENT_NAME]")
+	runtimeCleanupCmd.Flags().StringVar(&runtimeCmdOptions.runtimeName, "runtime-name", runtimeViper.GetString("runtime-name"), "Name of the runtime environment [$RUNTIME_NAME]")
+	runtimeCleanupCmd.Flags().StringVar(&runtimeCmdOptions.secretName, "secret-name", runtimeViper.GetString("secret-name"), "Name of the agent secret [$AGENT_SECRET_NAME]")
+	runtimeCleanupCmd.Flags().StringVar(&runtimeCmdOptions.dindSecretName, "dind-secret-name", runtimeViper.GetString("dind-secret-name"), "Name of the dind certificates secret [$DIND_SECRET_NAME]")
+	runtimeCleanupCmd.Flags().BoolVar(&runtimeCmdOptions.agent, "agent", runtimeViper.GetBool("agent"), "Whether the runtime environment belongs to an agent, otherwise it is a system one [$AGENT]")
+
+	for _, cmd := range []*cobra.Command{runtimeCmd, runtimeInitCmd, runtimeAttachCmd, runtimePatchCmd, runtimeCleanupCmd} {
+		flags := cmd.LocalFlags()
+		flags.VisitAll(func(f *pflag.Flag) {
+			if runtimeViper.IsSet(f.Name) && runtimeViper.GetString(f.Name) != "" {
+				dieOnError(flags.Set(f.Name, runtimeViper.GetString(f.Name)))
+			}
+		})
+	}
+
+	runtimeCmd.AddCommand(runtimeInitCmd, runtimeAttachCmd, runtimePatchCmd, runtimeCleanupCmd)
+	rootCmd.AddCommand(runtimeCmd)
+}
+
+func printPatchResults(w io.Writer, results []lifecycle.PatchResult) {
+	failed := 0
+	for _, res := range results {
+		fmt.Fprintf(w, "%s (%s): %s\n", res.File, res.Runtime, res.Status)
+		if res.Err != nil {
+			failed++
+			fmt.Fprintf(w, "  %s\n", res.Err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(res.Diff, "\n"), "\n") {
+			if line != "" {
+				// specs might hold registry credentials and such
+				fmt.Fprintf(w, "    %s\n", redact.String(line))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%d specs, %d failed\n", len(results), failed)
+}
+
+func checkCodefreshToken(options runtimeOptions) error {
+	if options.codefreshToken == "" {
+		return errors.New("--codefresh-token is required")
+	}
+
+	return nil
+}
+
+func buildLifecycle(options runtimeOptions) (*lifecycle.Lifecycle, error) {
+	redact.AddSecret(options.codefreshToken)
+	redact.AddSecret(options.agentToken)
+	redact.AddSecret(options.existingToken)
+	log := logger.New(logger.Options{
+		Verbose: options.verbose,
+	})
+
+	client, namespace, err := buildRuntimeKubeClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	httpHeaders := http.Header{}
+	httpHeaders.Add("User-Agent", fmt.Sprintf("cf-classic-runner/%s", version))
+	cf := codefresh.NewAdmin(codefresh.Options{
+		Host:       options.codefreshHost,
+		Token:      options.codefreshToken,
+		HTTPClient: &http.Client{},
+		Headers:    httpHeaders,
+	})
+
+	l, err := lifecycle.New(&lifecycle.Options{
+		Codefresh:  cf,
+		KubeClient: client,
+		Namespace:  namespace,
+		Logger:     log.New("module", "lifecycle"),
+	})
+	return l, err
+}
+
+// buildRuntimeKubeClient uses the kubeconfig when available, and the in-cluster config otherwise
+func buildRuntimeKubeClient(options runtimeOptions) (kubernetes.Interface, string, error) {
+	config, namespace, err := loadRuntimeKubeConfig(options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed creating kubernetes client: %w", err)
+	}
+
+	return client, namespace, nil
+}
+
+func loadRuntimeKubeConfig(options runtimeOptions) (*rest.Config, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = options.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: options.kubeContext,
+	})
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed loading kubernetes config: %w", err)
+	}
+
+	namespace := options.namespace
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, "", fmt.Errorf("failed getting namespace: %w", err)
+		}
+	}
+
+	return config, namespace, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/codefresh-io/go/venona/pkg/lifecycle"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+type addClusterOptions struct {
+	serviceAccount  string
+	host            string
+	output          string
+	outputFile      string
+	secretName      string
+	secretNamespace string
+}
+
+const (
+	outputConfig = "config"
+	outputSecret = "secret"
+
+	defaultRuntimeConfigSecretName = "runtime-configs"
+)
+
+var addClusterCmdOptions addClusterOptions
+
+var runtimeAddClusterCmd = &cobra.Command{
+	Use:   "add-cluster",
+	Short: "Create the service account, RBAC and token for running workflows in a remote cluster, and print its runtime config",
+	Long: `Create the service account, RBAC and token for running workflows in a remote cluster, and print its runtime config.
+The cluster is the one of --kube-context, and the workflows run in --namespace.
+The output is either the *.runtime.yaml file the runner loads from its config dir, or a Secret holding it`,
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if runtimeCmdOptions.runtimeName == "" {
+			return errors.New("--runtime-name is required")
+		}
+
+		switch addClusterCmdOptions.output {
+		case outputConfig, outputSecret:
+		default:
+			return fmt.Errorf("--output must be either \"%s\" or \"%s\"", outputConfig, outputSecret)
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		log := logger.New(logger.Options{
+			Verbose: runtimeCmdOptions.verbose,
+			// the runtime config might be written to stdout
+			Output: os.Stderr,
+		})
+		config, namespace, err := loadRuntimeKubeConfig(runtimeCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		if err := rest.LoadTLSFiles(config); err != nil {
+			return fmt.Errorf("failed loading the cluster certificate: %w", err)
+		}
+
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed creating kubernetes client: %w", err)
+		}
+
+		host := config.Host
+		if addClusterCmdOptions.host != "" {
+			host = addClusterCmdOptions.host
+		}
+
+		cnf, err := lifecycle.AddCluster(cmd.Context(), client, lifecycle.AddClusterOptions{
+			RuntimeName:        runtimeCmdOptions.runtimeName,
+			Namespace:          namespace,
+			Host:               host,
+			CAData:             config.CAData,
+			ServiceAccountName: addClusterCmdOptions.serviceAccount,
+			Logger:             log.New("module", "lifecycle"),
+		})
+		if err != nil {
+			return err
+		}
+
+		redact.AddSecret(cnf.Token)
+		var data []byte
+		if addClusterCmdOptions.output == outputSecret {
+			data, err = lifecycle.MarshalRuntimeConfigSecret(cnf, addClusterCmdOptions.secretName, addClusterCmdOptions.secretNamespace)
+		} else {
+			data, err = lifecycle.MarshalRuntimeConfig(cnf)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed marshalling runtime config: %w", err)
+		}
+
+		return writeOutput(addClusterCmdOptions.outputFile, data)
+	},
+}
+
+func init() {
+	dieOnError(runtimeViper.BindEnv("service-account", "SERVICE_ACCOUNT_NAME"))
+	dieOnError(runtimeViper.BindEnv("host", "CLUSTER_HOST"))
+	runtimeViper.SetDefault("service-account", lifecycle.DefaultServiceAccountName)
+
+	runtimeAddClusterCmd.Flags().StringVar(&runtimeCmdOptions.runtimeName, "runtime-name", runtimeViper.GetString("runtime-name"), "Name of the runtime environment [$RUNTIME_NAME]")
+	runtimeAddClusterCmd.Flags().StringVar(&addClusterCmdOptions.serviceAccount, "service-account", runtimeViper.GetString("service-account"), "Name of the service account to create [$SERVICE_ACCOUNT_NAME]")
+	runtimeAddClusterCmd.Flags().StringVar(&addClusterCmdOptions.host, "host", runtimeViper.GetString("host"), "The cluster API server URL as the runner reaches it, by default the one from the kubeconfig [$CLUSTER_HOST]")
+	runtimeAddClusterCmd.Flags().StringVarP(&addClusterCmdOptions.output, "output", "o", outputConfig, "Output format: config (a *.runtime.yaml file) or secret (a Secret manifest holding it)")
+	runtimeAddClusterCmd.Flags().StringVar(&addClusterCmdOptions.outputFile, "output-file", "", "Write the output to a file instead of stdout")
+	runtimeAddClusterCmd.Flags().StringVar(&addClusterCmdOptions.secretName, "secret-name", defaultRuntimeConfigSecretName, "Name of the Secret, with --output secret")
+	runtimeAddClusterCmd.Flags().StringVar(&addClusterCmdOptions.secretNamespace, "secret-namespace", "", "Namespace of the Secret (the runner namespace), with --output secret")
+
+	runtimeAddClusterCmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "secret-name" {
+			// SECRET_NAME is the agent secret of the other runtime commands
+			return
+		}
+
+		if runtimeViper.IsSet(f.Name) && runtimeViper.GetString(f.Name) != "" {
+			dieOnError(runtimeAddClusterCmd.Flags().Set(f.Name, runtimeViper.GetString(f.Name)))
+		}
+	})
+
+	runtimeCmd.AddCommand(runtimeAddClusterCmd)
+}
+
+func writeOutput(file string, data []byte) error {
+	if file == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	// the output holds a token, keep it private
+	return os.WriteFile(file, data, 0600)
+}
@@ -25,53 +25,112 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/codefresh-io/go/venona/pkg/admission"
 	"github.com/codefresh-io/go/venona/pkg/agent"
 	"github.com/codefresh-io/go/venona/pkg/codefresh"
 	"github.com/codefresh-io/go/venona/pkg/config"
+	"github.com/codefresh-io/go/venona/pkg/dryrun"
 	"github.com/codefresh-io/go/venona/pkg/kubernetes"
 	"github.com/codefresh-io/go/venona/pkg/logger"
 	"github.com/codefresh-io/go/venona/pkg/metrics"
 	"github.com/codefresh-io/go/venona/pkg/monitoring"
 	"github.com/codefresh-io/go/venona/pkg/monitoring/newrelic"
+	"github.com/codefresh-io/go/venona/pkg/monitoring/opentelemetry"
+	"github.com/codefresh-io/go/venona/pkg/mutation"
+	"github.com/codefresh-io/go/venona/pkg/operator"
+	"github.com/codefresh-io/go/venona/pkg/pvcpool"
+	"github.com/codefresh-io/go/venona/pkg/queue"
+	"github.com/codefresh-io/go/venona/pkg/recorder"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/registry"
 	"github.com/codefresh-io/go/venona/pkg/runtime"
 	"github.com/codefresh-io/go/venona/pkg/server"
+	"github.com/codefresh-io/go/venona/pkg/taskfilter"
 
 	nr "github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 type startOptions struct {
 	codefreshToken                 string
 	codefreshHost                  string
 	verbose                        bool
+	logLevel                       string
+	logFormat                      string
+	logModuleLevels                string
+	logFile                        string
+	logFileMaxSize                 int
+	logFileMaxBackups              int
+	logSampleInitial               int
+	logSampleSecondsInterval       int64
+	redactPatterns                 []string
 	rejectTLSUnauthorized          bool
 	agentID                        string
 	taskPullingSecondsInterval     int64
 	statusReportingSecondsInterval int64
+	drainTimeoutSeconds            int64
 	concurrency                    int
 	bufferSize                     int
 	configDir                      string
 	serverPort                     string
 	newrelicLicenseKey             string
 	newrelicAppname                string
+	otelEnabled                    bool
+	otelExporterProtocol           string
+	otelExporterEndpoint           string
+	otelServiceName                string
+	otelSampleRatio                float64
 	inClusterRuntime               string
 	qps                            float32
 	burst                          int
 	forceDeletePvc                 bool
+	remoteRuntimeOperator          bool
+	remoteRuntimeNamespace         string
+	recordFile                     string
+	recordFileMaxSize              int
+	recordFileMaxBackups           int
+	dryRun                         bool
+	taskTTLs                       string
+	taskDedupCacheSize             int
+	workflowTimeout                time.Duration
+	taskTimeouts                   string
+	capacityWaitTimeout            time.Duration
+	mutationPolicy                 string
+	admissionPolicy                string
+	registryConfig                 string
+	pvcPoolConfig                  string
+	deleteWaitTimeout              time.Duration
+	deleteForceAfter               time.Duration
+	deleteRemoveFinalizersAfter    time.Duration
 }
 
 const (
 	defaultCodefreshHost           = "https://g.codefresh.io"
 	defaultTaskPullingInterval     = 3
 	defaultStatusReportingInterval = 10
+	defaultDrainTimeout            = 25
 	defaultWorkflowConcurrency     = 50
 	defaultWorkflowBufferSize      = 1000
 	defaultK8sClientQPS            = 50
 	defaultK8sClientBurst          = 100
 	defaultForceDeletePvc          = false
+	defaultTaskTTLs                = "CreatePod=1h,CreatePvc=1h"
+	defaultTaskDedupCacheSize      = 10000
+	defaultWorkflowTimeout         = 5 * time.Minute
+	defaultTaskTimeouts            = "CreatePod=1m,CreatePvc=1m,DeletePod=1m,DeletePvc=1m"
+	defaultCapacityWaitTimeout     = 4 * time.Minute
+	defaultLogLevel                = "info"
+	defaultLogFormat               = logger.FormatLogfmt
+	defaultLogFileMaxSize          = 100
+	defaultLogFileMaxBackups       = 3
+	defaultLogSampleInterval       = 1
+	defaultOtelExporterProtocol    = opentelemetry.ProtocolGRPC
+	defaultOtelSampleRatio         = 1
+	defaultMonitorShutdownTimeout  = 5 * time.Second
 )
 
 var (
@@ -83,6 +142,21 @@ var startCmd = &cobra.Command{
 	Use:  "start",
 	Long: "Start venona process",
 	PreRunE: func(_ *cobra.Command, _ []string) error {
+		logOpts, err := loggerOptions(startCmdOptions)
+		if err != nil {
+			return err
+		}
+
+		if err := logOpts.Validate(); err != nil {
+			return err
+		}
+
+		for _, p := range startCmdOptions.redactPatterns {
+			if err := redact.New().AddPattern(p); err != nil {
+				return err
+			}
+		}
+
 		if startCmdOptions.taskPullingSecondsInterval <= 0 {
 			return errors.New("--task-pulling-interval must be a positive number")
 		}
@@ -91,6 +165,10 @@ var startCmd = &cobra.Command{
 			return errors.New("--status-reporting-interval must be a positive number")
 		}
 
+		if startCmdOptions.drainTimeoutSeconds <= 0 {
+			return errors.New("--drain-timeout must be a positive number")
+		}
+
 		if startCmdOptions.concurrency <= 0 {
 			return errors.New("--workflow-concurrency must be a positive number")
 		}
@@ -99,6 +177,10 @@ var startCmd = &cobra.Command{
 			return errors.New("--workflow-buffer-size must be a positive number")
 		}
 
+		if startCmdOptions.otelSampleRatio <= 0 || startCmdOptions.otelSampleRatio > 1 {
+			return errors.New("--otel-sample-ratio must be a number between 0 and 1")
+		}
+
 		if startCmdOptions.qps <= 0 {
 			return errors.New("--k8s-client-qps must be a positive number")
 		}
@@ -107,6 +189,36 @@ var startCmd = &cobra.Command{
 			return errors.New("--k8s-client-burst must be a positive number")
 		}
 
+		if _, err := taskfilter.ParseTTLs(startCmdOptions.taskTTLs); err != nil {
+			return err
+		}
+
+		if startCmdOptions.taskDedupCacheSize <= 0 {
+			return errors.New("--task-dedup-cache-size must be a positive number")
+		}
+
+		if startCmdOptions.workflowTimeout < 0 {
+			return errors.New("--workflow-timeout must not be negative")
+		}
+
+		taskTimeouts, err := queue.ParseTaskTimeouts(startCmdOptions.taskTimeouts)
+		if err != nil {
+			return err
+		}
+
+		if startCmdOptions.capacityWaitTimeout < 0 {
+			return errors.New("--capacity-wait-timeout must not be negative")
+		}
+
+		if startCmdOptions.deleteWaitTimeout < 0 || startCmdOptions.deleteForceAfter < 0 || startCmdOptions.deleteRemoveFinalizersAfter < 0 {
+			return errors.New("--delete-wait-timeout, --delete-force-after and --delete-remove-finalizers-after must not be negative")
+		}
+
+		deletion := kubernetes.DeletionConfig{WaitTimeout: startCmdOptions.deleteWaitTimeout}
+		if err := deletion.CheckTaskTimeouts(taskTimeouts); err != nil {
+			return fmt.Errorf("invalid --delete-wait-timeout: %w", err)
+		}
+
 		return nil
 	},
 	Run: func(_ *cobra.Command, _ []string) {
@@ -123,30 +235,88 @@ func init() {
 	dieOnError(viper.BindEnv("port", "PORT"))
 	dieOnError(viper.BindEnv("NODE_TLS_REJECT_UNAUTHORIZED"))
 	dieOnError(viper.BindEnv("verbose", "VERBOSE"))
+	dieOnError(viper.BindEnv("log-level", "LOG_LEVEL"))
+	dieOnError(viper.BindEnv("log-format", "LOG_FORMAT"))
+	dieOnError(viper.BindEnv("log-module-levels", "LOG_MODULE_LEVELS"))
+	dieOnError(viper.BindEnv("log-file", "LOG_FILE"))
+	dieOnError(viper.BindEnv("log-file-max-size", "LOG_FILE_MAX_SIZE"))
+	dieOnError(viper.BindEnv("log-file-max-backups", "LOG_FILE_MAX_BACKUPS"))
+	dieOnError(viper.BindEnv("log-sample-initial", "LOG_SAMPLE_INITIAL"))
+	dieOnError(viper.BindEnv("log-sample-interval", "LOG_SAMPLE_INTERVAL"))
+	dieOnError(viper.BindEnv("redact-pattern", "REDACT_PATTERN"))
 	dieOnError(viper.BindEnv("newrelic-license-key", "NEWRELIC_LICENSE_KEY"))
 	dieOnError(viper.BindEnv("newrelic-appname", "NEWRELIC_APPNAME"))
+	dieOnError(viper.BindEnv("otel-enabled", "CF_TELEMETRY_OTEL_ENABLE"))
+	dieOnError(viper.BindEnv("otel-exporter-protocol", "OTEL_EXPORTER_OTLP_PROTOCOL"))
+	dieOnError(viper.BindEnv("otel-exporter-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT"))
+	dieOnError(viper.BindEnv("otel-service-name", "OTEL_SERVICE_NAME"))
+	dieOnError(viper.BindEnv("otel-sample-ratio", "OTEL_TRACES_SAMPLER_ARG"))
 	dieOnError(viper.BindEnv("task-pulling-interval", "TASK_PULLING_INTERVAL"))
 	dieOnError(viper.BindEnv("status-reporting-interval", "STATUS_REPORTING_INTERVAL"))
+	dieOnError(viper.BindEnv("drain-timeout", "DRAIN_TIMEOUT"))
 	dieOnError(viper.BindEnv("workflow-concurrency", "WORKFLOW_CONCURRENCY"))
 	dieOnError(viper.BindEnv("workflow-buffer-size", "WORKFLOW_BUFFER_SIZE"))
 	dieOnError(viper.BindEnv("k8s-client-qps", "K8S_CLIENT_QPS"))
 	dieOnError(viper.BindEnv("k8s-client-burst", "K8S_CLIENT_BURST"))
 	dieOnError(viper.BindEnv("force-delete-pvc", "FORCE_DELETE_PVC"))
+	dieOnError(viper.BindEnv("remote-runtime-operator", "REMOTE_RUNTIME_OPERATOR"))
+	dieOnError(viper.BindEnv("remote-runtime-namespace", "REMOTE_RUNTIME_NAMESPACE"))
+	dieOnError(viper.BindEnv("record-file", "RECORD_FILE"))
+	dieOnError(viper.BindEnv("record-file-max-size", "RECORD_FILE_MAX_SIZE"))
+	dieOnError(viper.BindEnv("record-file-max-backups", "RECORD_FILE_MAX_BACKUPS"))
+	dieOnError(viper.BindEnv("dry-run", "DRY_RUN"))
+	dieOnError(viper.BindEnv("task-ttl", "TASK_TTL"))
+	dieOnError(viper.BindEnv("task-dedup-cache-size", "TASK_DEDUP_CACHE_SIZE"))
+	dieOnError(viper.BindEnv("workflow-timeout", "WORKFLOW_TIMEOUT"))
+	dieOnError(viper.BindEnv("task-timeout", "TASK_TIMEOUT"))
+	dieOnError(viper.BindEnv("capacity-wait-timeout", "CAPACITY_WAIT_TIMEOUT"))
+	dieOnError(viper.BindEnv("mutation-policy", "MUTATION_POLICY"))
+	dieOnError(viper.BindEnv("admission-policy", "ADMISSION_POLICY"))
+	dieOnError(viper.BindEnv("registry-config", "REGISTRY_CONFIG"))
+	dieOnError(viper.BindEnv("pvc-pool-config", "PVC_POOL_CONFIG"))
+	dieOnError(viper.BindEnv("delete-wait-timeout", "DELETE_WAIT_TIMEOUT"))
+	dieOnError(viper.BindEnv("delete-force-after", "DELETE_FORCE_AFTER"))
+	dieOnError(viper.BindEnv("delete-remove-finalizers-after", "DELETE_REMOVE_FINALIZERS_AFTER"))
 
 	viper.SetDefault("codefresh-host", defaultCodefreshHost)
 	viper.SetDefault("port", "8080")
 	viper.SetDefault("NODE_TLS_REJECT_UNAUTHORIZED", "1")
+	viper.SetDefault("log-level", defaultLogLevel)
+	viper.SetDefault("log-format", defaultLogFormat)
+	viper.SetDefault("log-file-max-size", defaultLogFileMaxSize)
+	viper.SetDefault("log-file-max-backups", defaultLogFileMaxBackups)
+	viper.SetDefault("record-file-max-size", defaultLogFileMaxSize)
+	viper.SetDefault("record-file-max-backups", defaultLogFileMaxBackups)
+	viper.SetDefault("log-sample-interval", defaultLogSampleInterval)
 	viper.SetDefault("in-cluster-runtime", "")
 	viper.SetDefault("newrelic-appname", AppName)
+	viper.SetDefault("otel-exporter-protocol", defaultOtelExporterProtocol)
+	viper.SetDefault("otel-service-name", AppName)
+	viper.SetDefault("otel-sample-ratio", defaultOtelSampleRatio)
 	viper.SetDefault("task-pulling-interval", defaultTaskPullingInterval)
 	viper.SetDefault("status-reporting-interval", defaultStatusReportingInterval)
+	viper.SetDefault("drain-timeout", defaultDrainTimeout)
 	viper.SetDefault("workflow-concurrency", defaultWorkflowConcurrency)
 	viper.SetDefault("workflow-buffer-size", defaultWorkflowBufferSize)
 	viper.SetDefault("k8s-client-qps", defaultK8sClientQPS)
 	viper.SetDefault("k8s-client-burst", defaultK8sClientBurst)
 	viper.SetDefault("force-delete-pvc", defaultForceDeletePvc)
+	viper.SetDefault("task-ttl", defaultTaskTTLs)
+	viper.SetDefault("task-dedup-cache-size", defaultTaskDedupCacheSize)
+	viper.SetDefault("workflow-timeout", defaultWorkflowTimeout)
+	viper.SetDefault("task-timeout", defaultTaskTimeouts)
+	viper.SetDefault("capacity-wait-timeout", defaultCapacityWaitTimeout)
 
 	startCmd.Flags().BoolVar(&startCmdOptions.verbose, "verbose", viper.GetBool("verbose"), "Show more logs")
+	startCmd.Flags().StringVar(&startCmdOptions.logLevel, "log-level", viper.GetString("log-level"), "Log level: debug, info, warn, error, crit [$LOG_LEVEL]")
+	startCmd.Flags().StringVar(&startCmdOptions.logFormat, "log-format", viper.GetString("log-format"), "Log format: logfmt or json [$LOG_FORMAT]")
+	startCmd.Flags().StringVar(&startCmdOptions.logModuleLevels, "log-module-levels", viper.GetString("log-module-levels"), "Per module log level overrides, e.g. k8s=debug,agent=warn [$LOG_MODULE_LEVELS]")
+	startCmd.Flags().StringVar(&startCmdOptions.logFile, "log-file", viper.GetString("log-file"), "Path of a file to write the logs to, in addition to stdout [$LOG_FILE]")
+	startCmd.Flags().IntVar(&startCmdOptions.logFileMaxSize, "log-file-max-size", viper.GetInt("log-file-max-size"), "The size (MB) of the log file before it is rotated [$LOG_FILE_MAX_SIZE]")
+	startCmd.Flags().IntVar(&startCmdOptions.logFileMaxBackups, "log-file-max-backups", viper.GetInt("log-file-max-backups"), "How many rotated log files to keep [$LOG_FILE_MAX_BACKUPS]")
+	startCmd.Flags().IntVar(&startCmdOptions.logSampleInitial, "log-sample-initial", viper.GetInt("log-sample-initial"), "How many identical log lines to write per sampling interval, 0 disables sampling [$LOG_SAMPLE_INITIAL]")
+	startCmd.Flags().Int64Var(&startCmdOptions.logSampleSecondsInterval, "log-sample-interval", viper.GetInt64("log-sample-interval"), "The log sampling interval (seconds) [$LOG_SAMPLE_INTERVAL]")
+	startCmd.Flags().StringArrayVar(&startCmdOptions.redactPatterns, "redact-pattern", []string{}, "A regex to mask in logs and errors, can be repeated [$REDACT_PATTERN]")
 	startCmd.Flags().BoolVar(&startCmdOptions.rejectTLSUnauthorized, "tls-reject-unauthorized", viper.GetBool("NODE_TLS_REJECT_UNAUTHORIZED"), "Disable certificate validation for TLS connections")
 	startCmd.Flags().StringVar(&startCmdOptions.inClusterRuntime, "in-cluster-runtime", viper.GetString("in-cluster-runtime"), "Runtime name to run agent in cluster mode [$CODEFRESH_IN_CLUSTER_RUNTIME]")
 	startCmd.Flags().StringVar(&startCmdOptions.agentID, "agent-id", viper.GetString("agent-id"), "ID of the agent [$AGENT_ID]")
@@ -156,13 +326,37 @@ func init() {
 	startCmd.Flags().StringVar(&startCmdOptions.codefreshHost, "codefresh-host", viper.GetString("codefresh-host"), "Codefresh API host default [$CODEFRESH_HOST]")
 	startCmd.Flags().Int64Var(&startCmdOptions.taskPullingSecondsInterval, "task-pulling-interval", viper.GetInt64("task-pulling-interval"), "The interval (seconds) to pull new tasks from Codefresh [$TASK_PULLING_INTERVAL]")
 	startCmd.Flags().Int64Var(&startCmdOptions.statusReportingSecondsInterval, "status-reporting-interval", viper.GetInt64("status-reporting-interval"), "The interval (seconds) to report status back to Codefresh [$STATUS_REPORTING_INTERVAL]")
+	startCmd.Flags().Int64Var(&startCmdOptions.drainTimeoutSeconds, "drain-timeout", viper.GetInt64("drain-timeout"), "The time (seconds) to wait for in-flight workflows to finish on shutdown, before cancelling them [$DRAIN_TIMEOUT]")
 	startCmd.Flags().IntVar(&startCmdOptions.concurrency, "workflow-concurrency", viper.GetInt("workflow-concurrency"), "How many workflow tasks to handle concurrently [$WORKFLOW_CONCURRENCY]")
 	startCmd.Flags().IntVar(&startCmdOptions.bufferSize, "workflow-buffer-size", viper.GetInt("workflow-cbuffer-sizeoncurrency"), "The size of the workflow channel buffer [$WORKFLOW_BUFFER_SIZE]")
 	startCmd.Flags().StringVar(&startCmdOptions.newrelicLicenseKey, "newrelic-license-key", viper.GetString("newrelic-license-key"), "New-Relic license key [$NEWRELIC_LICENSE_KEY]")
 	startCmd.Flags().StringVar(&startCmdOptions.newrelicAppname, "newrelic-appname", viper.GetString("newrelic-appname"), "New-Relic application name [$NEWRELIC_APPNAME]")
-	startCmd.Flags().Float32Var(&startCmdOptions.qps, "k8s-client-qps", float32(viper.GetFloat64("k8s-client-qps")), "the maximum QPS to the master from this client [$K8S_CLIENT_QPS]")
-	startCmd.Flags().IntVar(&startCmdOptions.burst, "k8s-client-burst", viper.GetInt("k8s-client-burst"), "k8s client maximum burst for throttle [$K8S_CLIENT_BURST]")
-	startCmd.Flags().BoolVar(&startCmdOptions.forceDeletePvc, "force-delete-pvc", viper.GetBool("force-delete-pvc"), "set to true to disable PVC protection [$FORCE_DELETE_PVC]")
+	startCmd.Flags().BoolVar(&startCmdOptions.otelEnabled, "otel-enabled", viper.GetBool("otel-enabled"), "Export traces with OpenTelemetry [$CF_TELEMETRY_OTEL_ENABLE]")
+	startCmd.Flags().StringVar(&startCmdOptions.otelExporterProtocol, "otel-exporter-protocol", viper.GetString("otel-exporter-protocol"), "OTLP exporter protocol, grpc or http/protobuf [$OTEL_EXPORTER_OTLP_PROTOCOL]")
+	startCmd.Flags().StringVar(&startCmdOptions.otelExporterEndpoint, "otel-exporter-endpoint", viper.GetString("otel-exporter-endpoint"), "OTLP collector endpoint URL [$OTEL_EXPORTER_OTLP_ENDPOINT]")
+	startCmd.Flags().StringVar(&startCmdOptions.otelServiceName, "otel-service-name", viper.GetString("otel-service-name"), "OpenTelemetry service name [$OTEL_SERVICE_NAME]")
+	startCmd.Flags().Float64Var(&startCmdOptions.otelSampleRatio, "otel-sample-ratio", viper.GetFloat64("otel-sample-ratio"), "Ratio of the traces to sample, between 0 and 1 [$OTEL_TRACES_SAMPLER_ARG]")
+	startCmd.Flags().Float32Var(&startCmdOptions.qps, "k8s-client-qps", float32(viper.GetFloat64("k8s-client-qps")), "the maximum QPS to the master from this client, each runtime has a client of its own. Runtimes override it with client.qps in their config file [$K8S_CLIENT_QPS]")
+	startCmd.Flags().IntVar(&startCmdOptions.burst, "k8s-client-burst", viper.GetInt("k8s-client-burst"), "k8s client maximum burst for throttle. Runtimes override it with client.burst in their config file [$K8S_CLIENT_BURST]")
+	startCmd.Flags().BoolVar(&startCmdOptions.forceDeletePvc, "force-delete-pvc", viper.GetBool("force-delete-pvc"), "set to true to disable PVC protection. Runtimes override it with client.forceDeletePvc in their config file [$FORCE_DELETE_PVC]")
+	startCmd.Flags().BoolVar(&startCmdOptions.remoteRuntimeOperator, "remote-runtime-operator", viper.GetBool("remote-runtime-operator"), "Add the runtimes defined by RemoteRuntime resources, in addition to the config dir [$REMOTE_RUNTIME_OPERATOR]")
+	startCmd.Flags().StringVar(&startCmdOptions.remoteRuntimeNamespace, "remote-runtime-namespace", viper.GetString("remote-runtime-namespace"), "The namespace of the RemoteRuntime resources, all namespaces when empty [$REMOTE_RUNTIME_NAMESPACE]")
+	startCmd.Flags().StringVar(&startCmdOptions.recordFile, "record-file", viper.GetString("record-file"), "Path of a JSONL file to record the pulled tasks and reported task statuses to, for replaying them later with venona dev replay [$RECORD_FILE]")
+	startCmd.Flags().IntVar(&startCmdOptions.recordFileMaxSize, "record-file-max-size", viper.GetInt("record-file-max-size"), "The size (MB) of the record file before it is rotated [$RECORD_FILE_MAX_SIZE]")
+	startCmd.Flags().IntVar(&startCmdOptions.recordFileMaxBackups, "record-file-max-backups", viper.GetInt("record-file-max-backups"), "How many rotated record files to keep [$RECORD_FILE_MAX_BACKUPS]")
+	startCmd.Flags().StringVar(&startCmdOptions.taskTTLs, "task-ttl", viper.GetString("task-ttl"), "The TTL of tasks by type from their creation, e.g. CreatePod=1h,CreatePvc=1h. Expired tasks are reported as failed without retry, 0 disables the TTL of a type [$TASK_TTL]")
+	startCmd.Flags().IntVar(&startCmdOptions.taskDedupCacheSize, "task-dedup-cache-size", viper.GetInt("task-dedup-cache-size"), "How many task ids to remember in order to drop tasks that are delivered twice [$TASK_DEDUP_CACHE_SIZE]")
+	startCmd.Flags().DurationVar(&startCmdOptions.workflowTimeout, "workflow-timeout", viper.GetDuration("workflow-timeout"), "The deadline of handling all the tasks of a workflow batch, 0 disables it. Tasks that hit a deadline are reported as failed with retry [$WORKFLOW_TIMEOUT]")
+	startCmd.Flags().StringVar(&startCmdOptions.taskTimeouts, "task-timeout", viper.GetString("task-timeout"), "The deadline of handling a single task by type, e.g. CreatePod=1m,DeletePod=1m. 0 disables the deadline of a type. The DeletePod and DeletePvc deadlines include waiting until the resource is gone, and must be longer than the deletion wait timeout [$TASK_TIMEOUT]")
+	startCmd.Flags().DurationVar(&startCmdOptions.capacityWaitTimeout, "capacity-wait-timeout", viper.GetDuration("capacity-wait-timeout"), "How long a CreatePod or CreatePvc task that exceeded a ResourceQuota waits for capacity, reported as Waiting, before it fails with retry. 0 fails it right away. The wait is bound by --workflow-timeout as well [$CAPACITY_WAIT_TIMEOUT]")
+	startCmd.Flags().StringVar(&startCmdOptions.mutationPolicy, "mutation-policy", viper.GetString("mutation-policy"), "Path of the mutation policy file of the in-cluster runtime, the other runtimes set mutationPolicy in their config file [$MUTATION_POLICY]")
+	startCmd.Flags().StringVar(&startCmdOptions.admissionPolicy, "admission-policy", viper.GetString("admission-policy"), "Path of the admission policy file of the in-cluster runtime, the other runtimes set admissionPolicy in their config file [$ADMISSION_POLICY]")
+	startCmd.Flags().StringVar(&startCmdOptions.registryConfig, "registry-config", viper.GetString("registry-config"), "Path of the image registry rewrites, digests and pull secrets of the in-cluster runtime, the other runtimes set registry in their config file [$REGISTRY_CONFIG]")
+	startCmd.Flags().StringVar(&startCmdOptions.pvcPoolConfig, "pvc-pool-config", viper.GetString("pvc-pool-config"), "Path of the PVC pool config of the in-cluster runtime, which keeps pre-provisioned PVCs for its CreatePvc tasks. The other runtimes set pvcPool in their config file [$PVC_POOL_CONFIG]")
+	startCmd.Flags().DurationVar(&startCmdOptions.deleteWaitTimeout, "delete-wait-timeout", viper.GetDuration("delete-wait-timeout"), "How long a DeletePod or DeletePvc task of the in-cluster runtime waits until the resource is gone, before it fails with retry. 0 does not wait. The wait runs within the DeletePod and DeletePvc task timeouts, and must be shorter than them. The other runtimes set deletion in their config file [$DELETE_WAIT_TIMEOUT]")
+	startCmd.Flags().DurationVar(&startCmdOptions.deleteForceAfter, "delete-force-after", viper.GetDuration("delete-force-after"), "Delete a pod of the in-cluster runtime that is still terminating after it again with grace period 0, while waiting. 0 never does [$DELETE_FORCE_AFTER]")
+	startCmd.Flags().DurationVar(&startCmdOptions.deleteRemoveFinalizersAfter, "delete-remove-finalizers-after", viper.GetDuration("delete-remove-finalizers-after"), "Remove the finalizers of a pod or PVC of the in-cluster runtime that is still terminating after it, while waiting. 0 never does [$DELETE_REMOVE_FINALIZERS_AFTER]")
+	startCmd.Flags().BoolVar(&startCmdOptions.dryRun, "dry-run", viper.GetBool("dry-run"), "Send all the Kubernetes requests with server-side dry run, skip agent tasks and do not report task statuses, the summary is served in /dry-run [$DRY_RUN]")
 
 	startCmd.Flags().VisitAll(func(f *pflag.Flag) {
 		if viper.IsSet(f.Name) && viper.GetString(f.Name) != "" {
@@ -178,9 +372,10 @@ func init() {
 }
 
 func run(options startOptions) {
-	log := logger.New(logger.Options{
-		Verbose: options.verbose,
-	})
+	registerSecrets(options)
+	logOpts, err := loggerOptions(options)
+	dieOnError(err)
+	log := logger.New(logOpts)
 
 	log.Debug("Starting", "pid", os.Getpid(), "version", version)
 	if !options.rejectTLSUnauthorized {
@@ -190,29 +385,29 @@ func run(options startOptions) {
 	reg := prometheus.NewRegistry()
 	metrics.Register(reg)
 
+	monitor := buildMonitor(options, log)
+
+	var dryRun *dryrun.Summary
+	if options.dryRun {
+		log.Warn("Running in dry run mode, no resources are created or deleted, no task statuses are reported and pulled tasks are released")
+		dryRun = dryrun.NewSummary(0, log.New("module", "dryrun"))
+	}
+
 	var runtimes map[string]runtime.Runtime
+	var pools []*pvcpool.Pool
 	k8sLog := log.New("module", "k8s")
 	if options.inClusterRuntime != "" {
-		runtimes = inClusterRuntimeConfiguration(options, k8sLog)
-	} else {
-		runtimes = remoteRuntimeConfiguration(options, k8sLog)
+		runtimes, pools = inClusterRuntimeConfiguration(options, k8sLog, monitor, dryRun)
+	} else if options.configDir != "" || !options.remoteRuntimeOperator {
+		runtimes, pools = remoteRuntimeConfiguration(options, k8sLog, monitor, dryRun)
 	}
 
-	monitor := monitoring.NewEmpty()
-	var err error
-
-	if options.newrelicLicenseKey != "" {
-		monitor, err = newrelic.New(
-			nr.ConfigAppName(options.newrelicAppname),
-			nr.ConfigLicense(options.newrelicLicenseKey),
-		)
-		if err != nil {
-			log.Warn("Failed to create monitor", "error", err)
-		} else {
-			log.Info("Using New Relic monitor", "app-name", options.newrelicAppname, "license-key", options.newrelicLicenseKey)
-		}
-	} else {
-		log.Warn("New Relic not starting without license key!")
+	var registry *runtime.Registry
+	var op *operator.Operator
+	if options.remoteRuntimeOperator {
+		registry = runtime.NewRegistry(nil)
+		op, err = buildOperator(options, registry, log, monitor, dryRun)
+		dieOnError(err)
 	}
 
 	var cf codefresh.Codefresh
@@ -248,6 +443,38 @@ func run(options startOptions) {
 		})
 	}
 
+	if options.recordFile != "" {
+		out, err := logger.NewRotatingFile(options.recordFile, options.recordFileMaxSize, options.recordFileMaxBackups)
+		dieOnError(err)
+		defer out.Close()
+
+		log.Info("Recording tasks", "file", options.recordFile)
+		cf, err = recorder.New(&recorder.Options{
+			Codefresh: cf,
+			Output:    out,
+			Logger:    log.New("module", "recorder"),
+		})
+		dieOnError(err)
+	}
+
+	serverHandlers := map[string]http.Handler{}
+	if dryRun != nil {
+		cf = dryrun.NewCodefresh(cf, dryRun)
+		serverHandlers["/dry-run"] = dryRun
+		defer dryRun.LogReport()
+	}
+
+	// validated in PreRunE
+	ttls, _ := taskfilter.ParseTTLs(options.taskTTLs)
+	taskFilter, err := taskfilter.New(&taskfilter.Options{
+		TTLs:      ttls,
+		CacheSize: options.taskDedupCacheSize,
+		Logger:    log.New("module", "taskfilter"),
+	})
+	dieOnError(err)
+
+	// validated in PreRunE
+	taskTimeouts, _ := queue.ParseTaskTimeouts(options.taskTimeouts)
 	agent, err := agent.New(&agent.Options{
 		Codefresh:                      cf,
 		Logger:                         log.New("module", "agent"),
@@ -255,9 +482,16 @@ func run(options startOptions) {
 		ID:                             options.agentID,
 		TaskPullingSecondsInterval:     time.Duration(options.taskPullingSecondsInterval) * time.Second,
 		StatusReportingSecondsInterval: time.Duration(options.statusReportingSecondsInterval) * time.Second,
+		DrainTimeout:                   time.Duration(options.drainTimeoutSeconds) * time.Second,
 		Monitor:                        monitor,
 		Concurrency:                    options.concurrency,
 		BufferSize:                     options.bufferSize,
+		Registry:                       registry,
+		DryRun:                         dryRun,
+		TaskFilter:                     taskFilter,
+		WorkflowTimeout:                options.workflowTimeout,
+		TaskTimeouts:                   taskTimeouts,
+		CapacityWaitTimeout:            options.capacityWaitTimeout,
 	})
 	dieOnError(err)
 
@@ -266,55 +500,269 @@ func run(options startOptions) {
 		Logger:          log.New("module", "server"),
 		Monitor:         monitor,
 		MetricsRegistry: reg,
+		Handlers:        serverHandlers,
 	})
 	dieOnError(err)
 
 	ctx := context.Background()
 
 	ctx = withSignals(ctx, server.Stop, agent.Stop, log)
+	if op != nil {
+		dieOnError(op.Start(ctx))
+	}
+
+	for _, pool := range pools {
+		go pool.Run(ctx)
+	}
+
 	go func() { dieOnError(agent.Start(ctx)) }()
 	go func() { dieOnError(server.Start()) }()
 
 	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), defaultMonitorShutdownTimeout)
+	defer cancel()
+	if err := monitor.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to shutdown monitor", "error", err)
+	}
+}
+
+// registerSecrets makes sure the known secrets never show up in logs and errors,
+// runtime tokens are registered when the runtimes are loaded
+func registerSecrets(options startOptions) {
+	redact.AddSecret(options.codefreshToken)
+	redact.AddSecret(options.newrelicLicenseKey)
+	for _, p := range options.redactPatterns {
+		dieOnError(redact.AddPattern(p))
+	}
+}
+
+func loggerOptions(options startOptions) (logger.Options, error) {
+	moduleLevels, err := logger.ParseModuleLevels(options.logModuleLevels)
+	if err != nil {
+		return logger.Options{}, err
+	}
+
+	return logger.Options{
+		Verbose:        options.verbose,
+		Level:          options.logLevel,
+		ModuleLevels:   moduleLevels,
+		Format:         options.logFormat,
+		File:           options.logFile,
+		FileMaxSizeMB:  options.logFileMaxSize,
+		FileMaxBackups: options.logFileMaxBackups,
+		SampleInitial:  options.logSampleInitial,
+		SampleInterval: time.Duration(options.logSampleSecondsInterval) * time.Second,
+	}, nil
+}
+
+func buildMonitor(options startOptions, log logger.Logger) monitoring.Monitor {
+	if options.otelEnabled {
+		if options.newrelicLicenseKey != "" {
+			log.Warn("Both OpenTelemetry and New Relic are configured, using OpenTelemetry")
+		}
+
+		monitor, err := opentelemetry.New(context.Background(), opentelemetry.Options{
+			Protocol:       options.otelExporterProtocol,
+			Endpoint:       options.otelExporterEndpoint,
+			ServiceName:    options.otelServiceName,
+			ServiceVersion: version,
+			SampleRatio:    options.otelSampleRatio,
+		})
+		if err != nil {
+			log.Warn("Failed to create monitor", "error", err)
+			return monitoring.NewEmpty()
+		}
+
+		log.Info("Using OpenTelemetry monitor", "protocol", options.otelExporterProtocol, "endpoint", options.otelExporterEndpoint, "service-name", options.otelServiceName)
+		return monitor
+	}
+
+	if options.newrelicLicenseKey == "" {
+		log.Warn("New Relic not starting without license key!")
+		return monitoring.NewEmpty()
+	}
+
+	monitor, err := newrelic.New(
+		nr.ConfigAppName(options.newrelicAppname),
+		nr.ConfigLicense(options.newrelicLicenseKey),
+	)
+	if err != nil {
+		log.Warn("Failed to create monitor", "error", err)
+		return monitoring.NewEmpty()
+	}
+
+	log.Info("Using New Relic monitor", "app-name", options.newrelicAppname)
+	return monitor
 }
 
-func inClusterRuntimeConfiguration(options startOptions, log logger.Logger) map[string]runtime.Runtime {
-	k, err := kubernetes.NewInCluster(log, options.qps, options.burst, options.forceDeletePvc)
+func inClusterRuntimeConfiguration(options startOptions, log logger.Logger, monitor monitoring.Monitor, dryRun *dryrun.Summary) (map[string]runtime.Runtime, []*pvcpool.Pool) {
+	mutationPolicy, admissionPolicy, err := loadPolicies(options.mutationPolicy, options.admissionPolicy)
+	dieOnError(err)
+	var registryConfig *registry.Config
+	if options.registryConfig != "" {
+		registryConfig, err = registry.LoadConfig(options.registryConfig)
+		dieOnError(err)
+	}
+
+	rewriter, err := newRegistryRewriter(registryConfig)
+	dieOnError(err)
+	var poolConfig *pvcpool.Config
+	if options.pvcPoolConfig != "" {
+		poolConfig, err = pvcpool.LoadConfig(options.pvcPoolConfig)
+		dieOnError(err)
+	}
+
+	opts := kubernetes.Options{
+		Logger:          log,
+		QPS:             options.qps,
+		Burst:           options.burst,
+		ForceDeletePvc:  options.forceDeletePvc,
+		Monitor:         monitor,
+		DryRun:          dryRun,
+		MutationPolicy:  mutationPolicy,
+		AdmissionPolicy: admissionPolicy,
+		Registry:        rewriter,
+		Deletion: kubernetes.DeletionConfig{
+			WaitTimeout:           options.deleteWaitTimeout,
+			ForceAfter:            options.deleteForceAfter,
+			RemoveFinalizersAfter: options.deleteRemoveFinalizersAfter,
+		},
+	}
+	client, err := kubernetes.NewInClusterClient(opts)
+	dieOnError(err)
+	opts.PVCPool, err = newPVCPool(client, poolConfig, log, dryRun)
 	dieOnError(err)
 	re := runtime.New(runtime.Options{
-		Kubernetes: k,
+		Kubernetes: kubernetes.NewForClient(client, opts),
 	})
-	return map[string]runtime.Runtime{options.inClusterRuntime: re}
+	return map[string]runtime.Runtime{options.inClusterRuntime: re}, poolsOf(opts.PVCPool)
 }
 
-func remoteRuntimeConfiguration(options startOptions, log logger.Logger) map[string]runtime.Runtime {
+func remoteRuntimeConfiguration(options startOptions, log logger.Logger, monitor monitoring.Monitor, dryRun *dryrun.Summary) (map[string]runtime.Runtime, []*pvcpool.Pool) {
 	configs, err := config.Load(options.configDir, ".*.runtime.yaml", log.New("module", "config-loader"))
 	dieOnError(err)
 	runtimes := map[string]runtime.Runtime{}
+	var pools []*pvcpool.Pool
 	for name, config := range configs {
-		k, err := kubernetes.New(kubernetes.Options{
-			Logger:         log,
-			Token:          config.Token,
-			Type:           config.Type,
-			Host:           config.Host,
-			Cert:           config.Cert,
-			Insecure:       !options.rejectTLSUnauthorized,
-			QPS:            options.qps,
-			Burst:          options.burst,
-			ForceDeletePvc: options.forceDeletePvc,
-		})
+		re, _, pool, err := newRemoteRuntime(config, options, log, monitor, dryRun)
 		if err != nil {
-			log.Error("Failed to load kubernetes", "error", err.Error(), "file", name, "name", config.Name)
+			log.Error("Failed to load runtime", "error", err.Error(), "file", name, "name", config.Name)
 			continue
 		}
 
-		re := runtime.New(runtime.Options{
-			Kubernetes: k,
-		})
 		runtimes[config.Name] = re
+		pools = append(pools, poolsOf(pool)...)
+	}
+
+	return runtimes, pools
+}
+
+// newRemoteRuntime builds the runtime of a remote cluster with the settings of its config, over the flags of the agent.
+// It returns the client of the runtime, and its PVC pool that the caller runs
+func newRemoteRuntime(config config.Config, options startOptions, log logger.Logger, monitor monitoring.Monitor, dryRun *dryrun.Summary) (runtime.Runtime, k8s.Interface, *pvcpool.Pool, error) {
+	redact.AddSecret(config.Token)
+	mutationPolicy, admissionPolicy, err := loadPolicies(config.MutationPolicy, config.AdmissionPolicy)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed loading policies: %w", err)
+	}
+
+	rewriter, err := newRegistryRewriter(config.Registry)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed loading registry: %w", err)
+	}
+
+	opts := kubernetes.Options{
+		Logger:          log,
+		Token:           config.Token,
+		Type:            config.Type,
+		Host:            config.Host,
+		Cert:            config.Cert,
+		Insecure:        !options.rejectTLSUnauthorized,
+		QPS:             options.qps,
+		Burst:           options.burst,
+		ForceDeletePvc:  options.forceDeletePvc,
+		Monitor:         monitor,
+		DryRun:          dryRun,
+		MutationPolicy:  mutationPolicy,
+		AdmissionPolicy: admissionPolicy,
+		Registry:        rewriter,
+	}.WithClientConfig(config.Client)
+	if config.Deletion != nil {
+		taskTimeouts, _ := queue.ParseTaskTimeouts(options.taskTimeouts)
+		if err := config.Deletion.CheckTaskTimeouts(taskTimeouts); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid deletion: %w", err)
+		}
+
+		opts.Deletion = *config.Deletion
+	}
+
+	client, err := kubernetes.NewClient(opts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed creating kubernetes client: %w", err)
+	}
+
+	opts.PVCPool, err = newPVCPool(client, config.PVCPool, log, dryRun)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed loading pvc pool: %w", err)
+	}
+
+	re := runtime.New(runtime.Options{
+		Kubernetes: kubernetes.NewForClient(client, opts),
+	})
+	return re, client, opts.PVCPool, nil
+}
+
+// loadPolicies loads the mutation and admission policy files of a runtime, an empty path means no policy
+func loadPolicies(mutationFile string, admissionFile string) (*mutation.Policy, *admission.Policy, error) {
+	var mutationPolicy *mutation.Policy
+	var admissionPolicy *admission.Policy
+	var err error
+	if mutationFile != "" {
+		if mutationPolicy, err = mutation.Load(mutationFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if admissionFile != "" {
+		if admissionPolicy, err = admission.Load(admissionFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return mutationPolicy, admissionPolicy, nil
+}
+
+// newRegistryRewriter creates the image rewriter of a runtime, nil when it has no registry config
+func newRegistryRewriter(cfg *registry.Config) (*registry.Rewriter, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	return registry.New(cfg)
+}
+
+// newPVCPool creates the PVC pool of a runtime, nil when it has no pool config. The pool is disabled in dry run mode,
+// since it creates and deletes PVCs in the background
+func newPVCPool(client k8s.Interface, cfg *pvcpool.Config, log logger.Logger, dryRun *dryrun.Summary) (*pvcpool.Pool, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	if dryRun != nil {
+		log.Warn("PVC pool is disabled in dry run mode", "namespace", cfg.Namespace)
+		return nil, nil
+	}
+
+	return pvcpool.New(client, cfg, log.New("module", "pvcpool"))
+}
+
+func poolsOf(pool *pvcpool.Pool) []*pvcpool.Pool {
+	if pool == nil {
+		return nil
 	}
 
-	return runtimes
+	return []*pvcpool.Pool{pool}
 }
 
 func withSignals(
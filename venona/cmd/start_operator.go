@@ -0,0 +1,69 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/codefresh-io/go/venona/pkg/config"
+	"github.com/codefresh-io/go/venona/pkg/dryrun"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/monitoring"
+	"github.com/codefresh-io/go/venona/pkg/operator"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+
+	"k8s.io/client-go/dynamic"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// buildOperator creates the operator that adds the runtimes of RemoteRuntime resources to the registry,
+// it watches the resources with the in-cluster credentials of the runner
+func buildOperator(options startOptions, registry *runtime.Registry, log logger.Logger, monitor monitoring.Monitor, dryRun *dryrun.Summary) (*operator.Operator, error) {
+	cnf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cnf)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := k8s.NewForConfig(cnf)
+	if err != nil {
+		return nil, err
+	}
+
+	return operator.New(&operator.Options{
+		DynamicClient: dynamicClient,
+		KubeClient:    kubeClient,
+		Namespace:     options.remoteRuntimeNamespace,
+		Registry:      registry,
+		Logger:        log.New("module", "operator"),
+		NewRuntime: func(ctx context.Context, c config.Config) (runtime.Runtime, k8s.Interface, error) {
+			re, client, pool, err := newRemoteRuntime(c, options, log.New("module", "k8s"), monitor, dryRun)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if pool != nil {
+				go pool.Run(ctx)
+			}
+
+			return re, client, nil
+		},
+	})
+}
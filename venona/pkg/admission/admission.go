@@ -0,0 +1,278 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/codefresh-io/go/venona/pkg/registry"
+
+	v1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ModeEnforce denies the objects that violate a rule
+	ModeEnforce Mode = "enforce"
+	// ModeAudit only reports the violations, the objects are still created
+	ModeAudit Mode = "audit"
+)
+
+// Checks that a rule can deny
+const (
+	CheckPrivileged          Check = "privileged"
+	CheckPrivilegeEscalation Check = "privilegeEscalation"
+	CheckHostPath            Check = "hostPath"
+	CheckHostNetwork         Check = "hostNetwork"
+	CheckHostPID             Check = "hostPID"
+	CheckHostIPC             Check = "hostIPC"
+)
+
+type (
+	// Mode of a policy, enforce or audit
+	Mode string
+
+	// Check is a property of a pod that a rule can deny
+	Check string
+
+	// Policy holds the rules that the pods of a runtime are validated against before they are created
+	Policy struct {
+		// Mode is enforce unless set to audit
+		Mode  Mode   `json:"mode,omitempty"`
+		Rules []Rule `json:"rules"`
+	}
+
+	// Rule denies pods that have any of the Deny checks, or images that match none of AllowedImages
+	Rule struct {
+		Name string `json:"name"`
+		// Namespaces the rule applies to, all when empty
+		Namespaces []string `json:"namespaces,omitempty"`
+		Deny       []Check  `json:"deny,omitempty"`
+		// AllowedImages are path.Match patterns, e.g. docker.io/codefresh/*. Every container image must match one of them,
+		// either as it is or with its registry, see registry.Normalize
+		AllowedImages []string `json:"allowedImages,omitempty"`
+	}
+
+	// Violation of a rule by an object
+	Violation struct {
+		Rule    string
+		Message string
+	}
+
+	// Error denies an object that violates rules of a policy in enforce mode
+	Error struct {
+		Violations []Violation
+	}
+)
+
+var checks = map[Check]func(pod *v1.Pod) []string{
+	CheckPrivileged: func(pod *v1.Pod) []string {
+		return containersWith(pod, func(c *v1.Container) bool {
+			return c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+		})
+	},
+	CheckPrivilegeEscalation: func(pod *v1.Pod) []string {
+		return containersWith(pod, func(c *v1.Container) bool {
+			return c.SecurityContext != nil && c.SecurityContext.AllowPrivilegeEscalation != nil && *c.SecurityContext.AllowPrivilegeEscalation
+		})
+	},
+	CheckHostPath: func(pod *v1.Pod) []string {
+		res := []string{}
+		for _, v := range pod.Spec.Volumes {
+			if v.HostPath != nil {
+				res = append(res, fmt.Sprintf("volume %s", v.Name))
+			}
+		}
+
+		return res
+	},
+	CheckHostNetwork: func(pod *v1.Pod) []string {
+		return podWith(pod.Spec.HostNetwork)
+	},
+	CheckHostPID: func(pod *v1.Pod) []string {
+		return podWith(pod.Spec.HostPID)
+	},
+	CheckHostIPC: func(pod *v1.Pod) []string {
+		return podWith(pod.Spec.HostIPC)
+	},
+}
+
+// Load reads a YAML or JSON policy file, and validates its rules
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("failed parsing admission policy \"%s\": %w", file, err)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid admission policy \"%s\": %w", file, err)
+	}
+
+	return p, nil
+}
+
+// Audit returns true when the violations of the policy are only reported
+func (p *Policy) Audit() bool {
+	return p.Mode == ModeAudit
+}
+
+// Validate returns the violations of the object, only pods can violate rules
+func (p *Policy) Validate(obj k8sruntime.Object) []Violation {
+	pod, ok := obj.(*v1.Pod)
+	if p == nil || !ok {
+		return nil
+	}
+
+	var res []Violation
+	for _, r := range p.Rules {
+		if len(r.Namespaces) > 0 && !contains(r.Namespaces, pod.Namespace) {
+			continue
+		}
+
+		for _, check := range r.Deny {
+			for _, what := range checks[check](pod) {
+				res = append(res, Violation{Rule: r.Name, Message: fmt.Sprintf("%s: %s", what, check)})
+			}
+		}
+
+		if len(r.AllowedImages) == 0 {
+			continue
+		}
+
+		for _, c := range containers(pod) {
+			if !matchesImage(r.AllowedImages, c.Image) {
+				res = append(res, Violation{Rule: r.Name, Message: fmt.Sprintf("image \"%s\" of container %s is not allowed", c.Image, c.Name)})
+			}
+		}
+	}
+
+	return res
+}
+
+func (p *Policy) validate() error {
+	if p.Mode != "" && p.Mode != ModeEnforce && p.Mode != ModeAudit {
+		return fmt.Errorf("unknown mode \"%s\", expected enforce or audit", p.Mode)
+	}
+
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+
+		if len(r.Deny) == 0 && len(r.AllowedImages) == 0 {
+			return fmt.Errorf("rule \"%s\" has neither deny nor allowedImages", r.Name)
+		}
+
+		for _, check := range r.Deny {
+			if _, ok := checks[check]; !ok {
+				return fmt.Errorf("rule \"%s\" denies an unknown check \"%s\"", r.Name, check)
+			}
+		}
+
+		for _, pattern := range r.AllowedImages {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule \"%s\" has an invalid image pattern \"%s\": %w", r.Name, pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Error lists the violated rules
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+
+	return fmt.Sprintf("denied by admission policy: %s", strings.Join(msgs, "; "))
+}
+
+// IsRetriable is false, the object would be denied again
+func (e *Error) IsRetriable() bool {
+	return false
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("rule \"%s\": %s", v.Rule, v.Message)
+}
+
+func containers(pod *v1.Pod) []*v1.Container {
+	res := make([]*v1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for i := range pod.Spec.InitContainers {
+		res = append(res, &pod.Spec.InitContainers[i])
+	}
+
+	for i := range pod.Spec.Containers {
+		res = append(res, &pod.Spec.Containers[i])
+	}
+
+	return res
+}
+
+func containersWith(pod *v1.Pod, f func(c *v1.Container) bool) []string {
+	res := []string{}
+	for _, c := range containers(pod) {
+		if f(c) {
+			res = append(res, fmt.Sprintf("container %s", c.Name))
+		}
+	}
+
+	return res
+}
+
+func podWith(set bool) []string {
+	if !set {
+		return nil
+	}
+
+	return []string{"pod"}
+}
+
+// matchesImage returns true when the image, or the image with its registry, matches one of the patterns
+func matchesImage(patterns []string, image string) bool {
+	normalized := registry.Normalize(image)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, image); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, normalized); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const policyFile = `
+mode: enforce
+rules:
+- name: no-privileged
+  deny: [privileged, hostPath, hostNetwork]
+- name: trusted-registries
+  namespaces: [codefresh]
+  allowedImages: ["docker.io/codefresh/*", "quay.io/codefresh/*"]
+`
+
+func loadPolicy(t *testing.T, data string) (*Policy, error) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return Load(file)
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	privileged := true
+	tests := map[string]struct {
+		pod  *v1.Pod
+		want []Violation
+	}{
+		"should admit a pod that violates no rule": {
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "codefresh"},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "engine", Image: "quay.io/codefresh/engine:1.0"}},
+				},
+			},
+		},
+		"should admit short image names of an allowed registry": {
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "codefresh"},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "engine", Image: "codefresh/engine:1.0"}, {Name: "dind", Image: "codefresh/dind:1.0"}},
+				},
+			},
+		},
+		"should list every violation": {
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "codefresh"},
+				Spec: v1.PodSpec{
+					HostNetwork:    true,
+					InitContainers: []v1.Container{{Name: "init", Image: "alpine"}},
+					Containers: []v1.Container{{
+						Name:            "dind",
+						Image:           "docker.io/codefresh/dind:1.0",
+						SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+					}},
+					Volumes: []v1.Volume{{Name: "docker", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/var/run"}}}},
+				},
+			},
+			want: []Violation{
+				{Rule: "no-privileged", Message: "container dind: privileged"},
+				{Rule: "no-privileged", Message: "volume docker: hostPath"},
+				{Rule: "no-privileged", Message: "pod: hostNetwork"},
+				{Rule: "trusted-registries", Message: "image \"alpine\" of container init is not allowed"},
+			},
+		},
+		"should only apply rules to their namespaces": {
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "other"},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "engine", Image: "alpine"}},
+				},
+			},
+		},
+	}
+	p, err := loadPolicy(t, policyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.Validate(tt.pod))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	err := &Error{Violations: []Violation{
+		{Rule: "no-privileged", Message: "container dind: privileged"},
+		{Rule: "trusted-registries", Message: "image \"alpine\" of container init is not allowed"},
+	}}
+	assert.EqualError(t, err, "denied by admission policy: rule \"no-privileged\": container dind: privileged; rule \"trusted-registries\": image \"alpine\" of container init is not allowed")
+	assert.False(t, err.IsRetriable())
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"should fail on an unknown mode": {
+			data:    "mode: warn\nrules: []\n",
+			wantErr: "unknown mode \"warn\", expected enforce or audit",
+		},
+		"should fail on a rule without a name": {
+			data:    "rules:\n- deny: [privileged]\n",
+			wantErr: "rule 0 has no name",
+		},
+		"should fail on a rule that checks nothing": {
+			data:    "rules:\n- name: empty\n",
+			wantErr: "rule \"empty\" has neither deny nor allowedImages",
+		},
+		"should fail on an unknown check": {
+			data:    "rules:\n- name: root\n  deny: [runAsRoot]\n",
+			wantErr: "rule \"root\" denies an unknown check \"runAsRoot\"",
+		},
+		"should fail on an invalid image pattern": {
+			data:    "rules:\n- name: images\n  allowedImages: [\"[\"]\n",
+			wantErr: "rule \"images\" has an invalid image pattern \"[\"",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadPolicy(t, tt.data)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
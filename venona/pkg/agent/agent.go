@@ -26,12 +26,16 @@ import (
 	"time"
 
 	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/dryrun"
+	ierrors "github.com/codefresh-io/go/venona/pkg/errors"
 	"github.com/codefresh-io/go/venona/pkg/logger"
 	"github.com/codefresh-io/go/venona/pkg/metrics"
 	"github.com/codefresh-io/go/venona/pkg/monitoring"
 	"github.com/codefresh-io/go/venona/pkg/queue"
+	"github.com/codefresh-io/go/venona/pkg/redact"
 	"github.com/codefresh-io/go/venona/pkg/runtime"
 	"github.com/codefresh-io/go/venona/pkg/task"
+	"github.com/codefresh-io/go/venona/pkg/taskfilter"
 	"github.com/codefresh-io/go/venona/pkg/workflow"
 
 	retryablehttp "github.com/hashicorp/go-retryablehttp"
@@ -50,6 +54,20 @@ type (
 		Monitor                        monitoring.Monitor
 		Concurrency                    int
 		BufferSize                     int
+		DrainTimeout                   time.Duration
+		// Registry holds runtimes that are added and removed while the agent is running,
+		// Runtimes are added to it. Optional, when Runtimes are given
+		Registry *runtime.Registry
+		// DryRun skips executing agent tasks, and adds them to the summary
+		DryRun *dryrun.Summary
+		// TaskFilter drops expired and duplicate tasks after they are pulled. Optional
+		TaskFilter *taskfilter.Filter
+		// WorkflowTimeout is the deadline of handling a workflow batch, 0 means no deadline
+		WorkflowTimeout time.Duration
+		// TaskTimeouts are the deadlines of handling workflow tasks by type. Optional
+		TaskTimeouts map[task.Type]time.Duration
+		// CapacityWaitTimeout is how long a workflow task that exceeded a ResourceQuota waits for capacity, 0 means no wait
+		CapacityWaitTimeout time.Duration
 	}
 
 	// Agent holds all the references from Codefresh
@@ -65,6 +83,12 @@ type (
 		lastStatus         Status
 		wg                 *sync.WaitGroup
 		monitor            monitoring.Monitor
+		drainTimeout       time.Duration
+		cancel             context.CancelFunc
+		stopPulling        chan struct{}
+		pullerDone         chan struct{}
+		dryRun             *dryrun.Summary
+		taskFilter         *taskfilter.Filter
 	}
 
 	// Status of the agent
@@ -77,6 +101,12 @@ type (
 const (
 	defaultProxyRequestTimeout = time.Second * 30
 	defaultProxyRequestRetries = 3
+	defaultDrainTimeout        = time.Second * 25
+	defaultReleaseTasksTimeout = time.Second * 5
+
+	coalescedReason = "skipped, the resource is created and deleted in the same batch of tasks"
+	// notFoundCancelReason is reported when the cancelled workflow is neither queued nor being handled
+	notFoundCancelReason = "nothing to cancel, the workflow is not queued nor handled by the agent"
 )
 
 var (
@@ -117,15 +147,32 @@ func New(opts *Options) (*Agent, error) {
 		opts.Monitor = monitoring.NewEmpty()
 	}
 
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = defaultDrainTimeout
+	}
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = runtime.NewRegistry(nil)
+	}
+
+	for name, rt := range opts.Runtimes {
+		registry.Set(name, rt)
+	}
+
 	httpClient.HTTPClient.Transport = opts.Monitor.NewRoundTripper(httpClient.HTTPClient.Transport)
 	wfq := queue.New(&queue.Options{
-		Runtimes:    opts.Runtimes,
+		Runtimes:    registry,
 		Log:         log,
 		WG:          wg,
 		Monitor:     opts.Monitor,
 		Concurrency: opts.Concurrency,
 		BufferSize:  opts.BufferSize,
 		Codefresh:   opts.Codefresh,
+
+		WorkflowTimeout:     opts.WorkflowTimeout,
+		TaskTimeouts:        opts.TaskTimeouts,
+		CapacityWaitTimeout: opts.CapacityWaitTimeout,
 	})
 	return &Agent{
 		id:                 id,
@@ -138,6 +185,11 @@ func New(opts *Options) (*Agent, error) {
 		lastStatus:         Status{},
 		wg:                 wg,
 		monitor:            opts.Monitor,
+		drainTimeout:       opts.DrainTimeout,
+		stopPulling:        make(chan struct{}),
+		pullerDone:         make(chan struct{}),
+		dryRun:             opts.DryRun,
+		taskFilter:         opts.TaskFilter,
 	}, nil
 }
 
@@ -150,6 +202,8 @@ func (a *Agent) Start(ctx context.Context) error {
 	a.running = true
 	a.log.Info("Starting agent")
 
+	// in-flight workflows are cancelled through this context once the drain deadline is exceeded
+	ctx, a.cancel = context.WithCancel(ctx)
 	go a.startTaskPullerRoutine(ctx)
 	go a.startStatusReporterRoutine(ctx)
 	a.wfQueue.Start(ctx)
@@ -161,19 +215,41 @@ func (a *Agent) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the agents work and blocks until all leftover tasks are finished
+// Stop drains the agent: it stops pulling new tasks, hands the queued workflows back to Codefresh
+// and waits for the in-flight ones to finish. Workflows that are still running once the drain
+// timeout is exceeded are cancelled
 func (a *Agent) Stop() error {
 	if !a.running {
 		return errAlreadyStopped
 	}
 
 	a.running = false
-	a.log.Warn("Received graceful termination request, stopping tasks...")
+	a.log.Warn("Received graceful termination request, stopping tasks...", "drainTimeout", a.drainTimeout)
 	a.reportStatusTicker.Stop()
 	a.taskPullerTicker.Stop()
-	a.wfQueue.Stop()
+	close(a.stopPulling)
+	<-a.pullerDone
 	a.log.Warn("stopped both tickers")
-	a.wg.Wait()
+
+	leftovers := a.wfQueue.Drain()
+	a.releaseWorkflows(leftovers)
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		a.log.Info("all in-flight tasks are done")
+	case <-time.After(a.drainTimeout):
+		a.log.Warn("drain timeout exceeded, cancelling in-flight tasks", "drainTimeout", a.drainTimeout)
+		a.cancel()
+		<-done
+	}
+
+	a.cancel()
 	return nil
 }
 
@@ -183,13 +259,17 @@ func (a *Agent) Status() Status {
 }
 
 func (a *Agent) startTaskPullerRoutine(ctx context.Context) {
+	defer close(a.pullerDone)
 	for {
 		select {
 		case <-ctx.Done():
 			a.log.Info("stopping task puller routine")
 			return
+		case <-a.stopPulling:
+			a.log.Info("stopping task puller routine, agent is draining")
+			return
 		case <-a.taskPullerTicker.C:
-			agentTasks, workflows := a.getTasks(ctx)
+			agentTasks, cancelTasks, workflows := a.getTasks(ctx)
 
 			// perform all agentTasks (in goroutine)
 			for i := range agentTasks {
@@ -201,6 +281,9 @@ func (a *Agent) startTaskPullerRoutine(ctx context.Context) {
 				a.wfQueue.Enqueue(workflows[i])
 			}
 
+			// cancel after enqueueing, workflows of the same poll are cancelled as well
+			a.cancelWorkflows(ctx, cancelTasks)
+
 			size := a.wfQueue.Size()
 			agentTasksLen := len(agentTasks)
 			wfTasksLen := len(workflows)
@@ -250,7 +333,9 @@ func (a *Agent) reportTaskStatus(ctx context.Context, taskDef task.Task, err err
 	if err != nil {
 		status.Status = task.StatusError
 		status.Reason = err.Error()
-		status.IsRetriable = true // TODO: make this configurable depending on the error
+		// errors are retriable, unless they tell otherwise
+		var re ierrors.RetriableError
+		status.IsRetriable = !errors.As(err, &re) || re.IsRetriable()
 	} else {
 		status.Status = task.StatusSuccess
 	}
@@ -261,14 +346,168 @@ func (a *Agent) reportTaskStatus(ctx context.Context, taskDef task.Task, err err
 	}
 }
 
-func (a *Agent) getTasks(ctx context.Context) (task.Tasks, []*workflow.Workflow) {
+// releaseWorkflows hands the tasks of workflows that were not handled back to Codefresh
+func (a *Agent) releaseWorkflows(workflows []*workflow.Workflow) {
+	if len(workflows) == 0 {
+		return
+	}
+
+	ids := []string{}
+	for _, wf := range workflows {
+		for _, t := range wf.Tasks {
+			ids = append(ids, t.Id)
+		}
+	}
+
+	a.log.Warn("releasing unprocessed tasks", "workflows", len(workflows), "tasks", len(ids))
+	if a.taskFilter != nil {
+		// the platform may deliver them again, to this agent as well
+		a.taskFilter.Forget(ids)
+	}
+
+	a.releaseTasks(ids)
+}
+
+// releaseDryRunTasks hands the tasks of a batch that was only dry-run back to Codefresh, since the platform treats
+// pulled tasks as claimed and the agents that run them would never get them. They are not forgotten by the task
+// filter, so this agent does not dry-run them again
+func (a *Agent) releaseDryRunTasks(tasks task.Tasks) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.Id)
+	}
+
+	a.log.Info("releasing dry run tasks", "tasks", len(ids))
+	a.releaseTasks(ids)
+}
+
+func (a *Agent) releaseTasks(ids []string) {
+	// the agent context might already be cancelled at this point
+	ctx, cancel := context.WithTimeout(context.Background(), defaultReleaseTasksTimeout)
+	defer cancel()
+	if err := a.cf.ReleaseTasks(ctx, ids); err != nil {
+		a.log.Error("failed releasing tasks", "error", err, "tasks", ids)
+	}
+}
+
+func (a *Agent) getTasks(ctx context.Context) (task.Tasks, task.Tasks, []*workflow.Workflow) {
 	tasks := a.pullTasks(ctx)
-	return a.splitTasks(tasks)
+	if a.dryRun != nil {
+		defer a.releaseDryRunTasks(tasks)
+	}
+
+	if a.taskFilter != nil {
+		var expired []*taskfilter.ExpiredError
+		tasks, expired = a.taskFilter.Filter(tasks)
+		a.reportExpiredTasks(ctx, expired)
+	}
+
+	tasks = a.rejectInvalidTasks(ctx, tasks)
+	agentTasks, cancelTasks, workflows := a.splitTasks(tasks)
+	return agentTasks, cancelTasks, a.coalesceWorkflows(ctx, workflows)
+}
+
+// cancelWorkflows cancels the queued and in-flight workflows of the cancel tasks, and reports the cancel tasks as successful
+func (a *Agent) cancelWorkflows(ctx context.Context, cancelTasks task.Tasks) {
+	for _, t := range cancelTasks {
+		spec, _ := t.Spec.(task.CancelWorkflowSpec)
+		found := a.wfQueue.Cancel(t.Metadata.WorkflowId)
+		a.log.Info("Cancelling workflow", "workflow", t.Metadata.WorkflowId, "runtime", t.Metadata.ReName, "reason", spec.Reason, "found", found)
+		metrics.IncCancelledWorkflows(found)
+		if !t.Metadata.ShouldReportStatus {
+			continue
+		}
+
+		status := task.TaskStatus{
+			Status:         task.StatusSuccess,
+			OccurredAt:     time.Now(),
+			StatusRevision: t.Metadata.CurrentStatusRevision + 1,
+		}
+		if !found {
+			status.Reason = notFoundCancelReason
+		}
+
+		if err := a.cf.ReportTaskStatus(ctx, t.Id, status); err != nil {
+			a.log.Error("failed reporting task status", "error", err, "task", t.Id, "workflow", t.Metadata.WorkflowId)
+		}
+	}
+}
+
+// rejectInvalidTasks reports the tasks whose spec does not match the schema of their type with a non-retriable error,
+// and returns the valid ones
+func (a *Agent) rejectInvalidTasks(ctx context.Context, tasks task.Tasks) task.Tasks {
+	res := make(task.Tasks, 0, len(tasks))
+	for _, t := range tasks {
+		specErr := t.SpecError()
+		if specErr == nil {
+			res = append(res, t)
+			continue
+		}
+
+		a.log.Error("Rejecting task with an invalid spec", "task", t.Id, "type", t.Type, "workflow", t.Metadata.WorkflowId, "error", specErr)
+		metrics.IncInvalidTasks(t.Type)
+		if t.Metadata.ShouldReportStatus {
+			a.reportTaskStatus(ctx, t, specErr)
+		}
+	}
+
+	return res
+}
+
+// coalesceWorkflows skips the tasks that create resources which are deleted in the same poll, and reports them
+// as successful. Workflows that are left without tasks are dropped
+func (a *Agent) coalesceWorkflows(ctx context.Context, workflows []*workflow.Workflow) []*workflow.Workflow {
+	res := make([]*workflow.Workflow, 0, len(workflows))
+	for _, wf := range workflows {
+		coalesced := wf.Coalesce()
+		if len(coalesced) > 0 {
+			a.log.Info("Coalesced tasks that create and delete the same resources", "workflow", wf.Metadata.WorkflowId, "runtime", wf.Metadata.ReName, "tasks", len(coalesced))
+		}
+
+		for _, t := range coalesced {
+			metrics.IncCoalescedTasks(t.Type)
+			if !t.Metadata.ShouldReportStatus {
+				continue
+			}
+
+			status := task.TaskStatus{
+				Status:         task.StatusSuccess,
+				OccurredAt:     time.Now(),
+				StatusRevision: t.Metadata.CurrentStatusRevision + 1,
+				Reason:         coalescedReason,
+			}
+			if err := a.cf.ReportTaskStatus(ctx, t.Id, status); err != nil {
+				a.log.Error("failed reporting task status", "error", err, "task", t.Id, "workflow", t.Metadata.WorkflowId)
+			}
+		}
+
+		if len(wf.Tasks) > 0 {
+			res = append(res, wf)
+		}
+	}
+
+	return res
+}
+
+// reportExpiredTasks reports the expired tasks with a non-retriable error, instead of handling them
+func (a *Agent) reportExpiredTasks(ctx context.Context, expired []*taskfilter.ExpiredError) {
+	for _, e := range expired {
+		if e.Task.Metadata.ShouldReportStatus {
+			a.reportTaskStatus(ctx, e.Task, e)
+		}
+	}
 }
 
 func (a *Agent) pullTasks(ctx context.Context) task.Tasks {
+	txn := a.monitor.NewTransaction("runner-tasks-pull")
+	defer txn.End()
+
 	start := time.Now()
-	tasks, err := a.cf.Tasks(ctx)
+	tasks, err := a.cf.Tasks(txn.NewContext(ctx))
 	status := "success"
 	if err != nil {
 		status = "error"
@@ -277,9 +516,12 @@ func (a *Agent) pullTasks(ctx context.Context) task.Tasks {
 
 	if err != nil {
 		a.log.Error("Failed pulling tasks", "error", err)
+		txn.NoticeError(err)
 		return task.Tasks{}
 	}
 
+	txn.AddAttribute("tasks", len(tasks))
+
 	if len(tasks) == 0 {
 		return task.Tasks{}
 	}
@@ -287,9 +529,10 @@ func (a *Agent) pullTasks(ctx context.Context) task.Tasks {
 	return tasks
 }
 
-func (a *Agent) splitTasks(tasks task.Tasks) (task.Tasks, []*workflow.Workflow) {
+func (a *Agent) splitTasks(tasks task.Tasks) (task.Tasks, task.Tasks, []*workflow.Workflow) {
 	pullTime := time.Now()
 	agentTasks := task.Tasks{}
+	cancelTasks := task.Tasks{}
 	wfMap := map[string]*workflow.Workflow{}
 
 	// divide tasks by types
@@ -299,6 +542,8 @@ func (a *Agent) splitTasks(tasks task.Tasks) (task.Tasks, []*workflow.Workflow)
 		case task.TypeAgentTask:
 			t.Timeline.Pulled = pullTime
 			agentTasks = append(agentTasks, t)
+		case task.TypeCancelWorkflow:
+			cancelTasks = append(cancelTasks, t)
 		case task.TypeCreatePod, task.TypeCreatePVC, task.TypeDeletePod, task.TypeDeletePVC:
 			wf, ok := wfMap[t.Metadata.WorkflowId]
 			if !ok {
@@ -311,7 +556,7 @@ func (a *Agent) splitTasks(tasks task.Tasks) (task.Tasks, []*workflow.Workflow)
 				a.log.Error("failed adding task to workflow", "error", err)
 			}
 		default:
-			a.log.Error("unrecognized task type", "type", t.Type, "tid", t.Metadata.WorkflowId, "runtime", t.Metadata.ReName)
+			a.log.Error("unrecognized task type", "type", t.Type, "workflow", t.Metadata.WorkflowId, "runtime", t.Metadata.ReName)
 		}
 	}
 
@@ -334,17 +579,17 @@ func (a *Agent) splitTasks(tasks task.Tasks) (task.Tasks, []*workflow.Workflow)
 		wf1, wf2 := workflows[i], workflows[j]
 		return workflow.Less(*wf1, *wf2)
 	})
-	return agentTasks, workflows
+	return agentTasks, cancelTasks, workflows
 }
 
 func (a *Agent) handleAgentTask(ctx context.Context, t *task.Task) {
-	a.log.Info("executing agent task", "tid", t.Metadata.WorkflowId)
+	a.log.Info("executing agent task", "workflow", t.Metadata.WorkflowId)
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
 		txn := task.NewTaskTransaction(a.monitor, t.Metadata)
 		defer txn.End()
-		err := a.executeAgentTask(ctx, t)
+		err := a.executeAgentTask(txn.NewContext(ctx), t)
 
 		if err != nil {
 			a.log.Error(err.Error())
@@ -356,14 +601,9 @@ func (a *Agent) handleAgentTask(ctx context.Context, t *task.Task) {
 
 func (a *Agent) executeAgentTask(ctx context.Context, t *task.Task) error {
 	t.Timeline.Started = time.Now()
-	specJSON, err := json.Marshal(t.Spec)
+	spec, err := agentTaskSpec(t)
 	if err != nil {
-		return errFailedToParseAgentTask
-	}
-
-	spec := task.AgentTask{}
-	if err = json.Unmarshal(specJSON, &spec); err != nil {
-		return errFailedToParseAgentTask
+		return err
 	}
 
 	e, ok := agentTaskExecutors[spec.Type]
@@ -371,13 +611,18 @@ func (a *Agent) executeAgentTask(ctx context.Context, t *task.Task) error {
 		return errUknownAgentTaskType
 	}
 
-	err = e(&spec, a.log)
+	if a.dryRun != nil {
+		a.dryRun.Add(dryrun.Action{Type: string(task.TypeAgentTask), Name: spec.Type})
+	} else {
+		err = e(&spec, a.log)
+	}
+
 	if t.Metadata.ShouldReportStatus {
 		a.reportTaskStatus(ctx, *t, err)
 	}
 	sinceCreation, inRunner, processed := t.GetLatency()
 	a.log.Info("Done handling agent task",
-		"tid", t.Metadata.WorkflowId,
+		"workflow", t.Metadata.WorkflowId,
 		"time since creation", sinceCreation,
 		"time in runner", inRunner,
 		"processing time", processed,
@@ -386,6 +631,25 @@ func (a *Agent) executeAgentTask(ctx context.Context, t *task.Task) error {
 	return err
 }
 
+// agentTaskSpec returns the typed spec of an agent task, the spec is decoded only when it is not typed already
+func agentTaskSpec(t *task.Task) (task.AgentTask, error) {
+	if spec, ok := t.Spec.(task.AgentTask); ok {
+		return spec, nil
+	}
+
+	spec := task.AgentTask{}
+	specJSON, err := json.Marshal(t.Spec)
+	if err != nil {
+		return spec, errFailedToParseAgentTask
+	}
+
+	if err = json.Unmarshal(specJSON, &spec); err != nil {
+		return spec, errFailedToParseAgentTask
+	}
+
+	return spec, nil
+}
+
 func proxyRequest(t *task.AgentTask, log logger.Logger) error {
 	spec := objx.Map(t.Params)
 	vars := objx.Map(spec.Get("runtimeContext.context.variables").MSI())
@@ -432,7 +696,9 @@ func proxyRequest(t *task.AgentTask, log logger.Logger) error {
 	}(resp.Body)
 
 	body, _ := io.ReadAll(resp.Body)
-	log.Info("finished proxy task", "url", url, "method", method, "status", resp.Status, "body", string(body))
+	log.Info("finished proxy task", "url", url, "method", method, "status", resp.Status)
+	// the response might echo back the proxy token, which is not known to the global redactor
+	log.Debug("proxy task response", "url", url, "body", redact.String(string(body), token))
 
 	return nil
 }
@@ -462,7 +728,7 @@ func checkOptions(opts *Options) error {
 		return errIDRequired
 	}
 
-	if len(opts.Runtimes) == 0 {
+	if len(opts.Runtimes) == 0 && opts.Registry == nil {
 		return errRuntimesRequired
 	}
 
@@ -15,16 +15,27 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/dryrun"
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
 	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/monitoring"
+	"github.com/codefresh-io/go/venona/pkg/queue"
+	"github.com/codefresh-io/go/venona/pkg/redact"
 	"github.com/codefresh-io/go/venona/pkg/runtime"
 	"github.com/codefresh-io/go/venona/pkg/task"
+	"github.com/codefresh-io/go/venona/pkg/taskfilter"
+	"github.com/codefresh-io/go/venona/pkg/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -245,7 +256,8 @@ func Test_executeAgentTask(t *testing.T) {
 				Spec: task.AgentTask{
 					Type: "test",
 					Params: map[string]interface{}{
-						"data": 3,
+						// as decoded from JSON
+						"data": float64(3),
 					},
 				},
 			},
@@ -272,6 +284,27 @@ func Test_executeAgentTask(t *testing.T) {
 	}
 }
 
+func Test_executeAgentTask_dryRun(t *testing.T) {
+	agentTaskExecutors["test"] = func(_ *task.AgentTask, _ logger.Logger) error {
+		t.Errorf("executor function should not be called in dry run")
+		return nil
+	}
+	defer delete(agentTaskExecutors, "test")
+	summary := dryrun.NewSummary(0, logger.New(logger.Options{}))
+	a := &Agent{
+		log:    logger.New(logger.Options{}),
+		dryRun: summary,
+	}
+	err := a.executeAgentTask(context.Background(), &task.Task{
+		Type: task.TypeAgentTask,
+		Spec: task.AgentTask{Type: "test"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{string(task.TypeAgentTask): 1}, summary.Report().Counts)
+	assert.Equal(t, "test", summary.Report().Recent[0].Name)
+}
+
 func Test_splitTasks(t *testing.T) {
 	tests := map[string]struct {
 		tasks task.Tasks
@@ -308,8 +341,263 @@ func Test_splitTasks(t *testing.T) {
 				cf:  &codefresh.MockCodefresh{},
 				log: logger.New(logger.Options{}),
 			}
-			_, workflows := a.splitTasks(tt.tasks)
+			_, _, workflows := a.splitTasks(tt.tasks)
 			assert.Equal(t, tt.want, workflows[0].Tasks)
 		})
 	}
 }
+
+func Test_getTasks_taskFilter(t *testing.T) {
+	created := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	tasks := task.Tasks{
+		{Id: "expired", Type: task.TypeCreatePod, Metadata: task.Metadata{WorkflowId: "wf1", CreatedAt: created, ShouldReportStatus: true}},
+		{Id: "fresh", Type: task.TypeDeletePod, Metadata: task.Metadata{WorkflowId: "wf1", CreatedAt: created, ShouldReportStatus: true}},
+	}
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().Tasks(mock.Anything).Return(tasks, nil).Twice()
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "expired", mock.MatchedBy(func(s task.TaskStatus) bool {
+		return s.Status == task.StatusError && !s.IsRetriable && s.StatusRevision == 1
+	})).Return(nil).Once()
+	filter, err := taskfilter.New(&taskfilter.Options{
+		TTLs:   map[task.Type]time.Duration{task.TypeCreatePod: time.Hour},
+		Logger: logger.New(logger.Options{}),
+	})
+	assert.NoError(t, err)
+	a := &Agent{
+		cf:         cf,
+		log:        logger.New(logger.Options{}),
+		monitor:    monitoring.NewEmpty(),
+		taskFilter: filter,
+	}
+
+	_, _, workflows := a.getTasks(context.Background())
+	assert.Len(t, workflows, 1)
+	assert.Len(t, workflows[0].Tasks, 1)
+	assert.Equal(t, "fresh", workflows[0].Tasks[0].Id)
+
+	// the same tasks are delivered again
+	_, _, workflows = a.getTasks(context.Background())
+	assert.Empty(t, workflows)
+}
+
+func Test_getTasks_dryRun(t *testing.T) {
+	created := time.Now().Format(time.RFC3339)
+	tasks := task.Tasks{
+		{Id: "agent", Type: task.TypeAgentTask, Spec: task.AgentTask{Type: "test"}, Metadata: task.Metadata{CreatedAt: created}},
+		{Id: "pod", Type: task.TypeCreatePod, Metadata: task.Metadata{WorkflowId: "wf1", CreatedAt: created}},
+		{Id: "pvc", Type: task.TypeCreatePVC, Metadata: task.Metadata{WorkflowId: "wf1", CreatedAt: created}},
+	}
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().Tasks(mock.Anything).Return(tasks, nil).Once()
+	cf.EXPECT().ReleaseTasks(mock.Anything, []string{"agent", "pod", "pvc"}).Return(nil).Once()
+	a := &Agent{
+		cf:      cf,
+		log:     logger.New(logger.Options{}),
+		monitor: monitoring.NewEmpty(),
+		dryRun:  dryrun.NewSummary(0, logger.New(logger.Options{})),
+	}
+
+	agentTasks, _, workflows := a.getTasks(context.Background())
+	assert.Len(t, agentTasks, 1)
+	assert.Len(t, workflows, 1)
+}
+
+func Test_pullTasks_redactsErrors(t *testing.T) {
+	redact.AddSecret("some-agent-codefresh-token")
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().Tasks(mock.Anything).Return(nil, errors.New(`unauthorized: {"token": "some-agent-codefresh-token"}`)).Once()
+	out := &bytes.Buffer{}
+	a := &Agent{
+		cf:      cf,
+		log:     logger.New(logger.Options{Output: out}),
+		monitor: monitoring.NewEmpty(),
+	}
+
+	assert.Empty(t, a.pullTasks(context.Background()))
+	assert.Contains(t, out.String(), "Failed pulling tasks")
+	assert.NotContains(t, out.String(), "some-agent-codefresh-token")
+}
+
+func Test_rejectInvalidTasks(t *testing.T) {
+	tasks, err := task.UnmarshalTasks([]byte(`[
+		{"_id":"invalid","type":"DeletePod","metadata":{"workflowId":"wf1","shouldReportStatus":true},"spec":{"name":"pod"}},
+		{"_id":"valid","type":"DeletePod","metadata":{"workflowId":"wf1","shouldReportStatus":true},"spec":{"name":"pod","namespace":"ns"}}
+	]`))
+	assert.NoError(t, err)
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "invalid", mock.MatchedBy(func(s task.TaskStatus) bool {
+		return s.Status == task.StatusError && !s.IsRetriable && s.Reason == "invalid DeletePod task spec: spec: missing property 'namespace'"
+	})).Return(nil).Once()
+	a := &Agent{
+		cf:  cf,
+		log: logger.New(logger.Options{}),
+	}
+
+	valid := a.rejectInvalidTasks(context.Background(), tasks)
+	assert.Len(t, valid, 1)
+	assert.Equal(t, "valid", valid[0].Id)
+	assert.Equal(t, task.DeleteResourceSpec{Name: "pod", Namespace: "ns"}, valid[0].Spec)
+}
+
+func Test_coalesceWorkflows(t *testing.T) {
+	metadata := task.Metadata{WorkflowId: "wf1", ReName: "some-rt", ShouldReportStatus: true}
+	tasks := task.Tasks{
+		{Id: "create", Type: task.TypeCreatePod, Metadata: metadata, Spec: map[string]interface{}{"metadata": map[string]interface{}{"name": "pod", "namespace": "ns"}}},
+		{Id: "delete", Type: task.TypeDeletePod, Metadata: metadata, Spec: map[string]interface{}{"name": "pod", "namespace": "ns"}},
+		{Id: "other", Type: task.TypeDeletePVC, Metadata: metadata, Spec: map[string]interface{}{"name": "pvc", "namespace": "ns"}},
+	}
+	cf := codefresh.NewMockCodefresh(t)
+	for _, id := range []string{"create", "delete"} {
+		cf.EXPECT().ReportTaskStatus(mock.Anything, id, mock.MatchedBy(func(s task.TaskStatus) bool {
+			return s.Status == task.StatusSuccess && s.Reason == coalescedReason
+		})).Return(nil).Once()
+	}
+	a := &Agent{
+		cf:  cf,
+		log: logger.New(logger.Options{}),
+	}
+
+	_, _, workflows := a.splitTasks(tasks)
+	workflows = a.coalesceWorkflows(context.Background(), workflows)
+	assert.Len(t, workflows, 1)
+	assert.Len(t, workflows[0].Tasks, 1)
+	assert.Equal(t, "other", workflows[0].Tasks[0].Id)
+
+	// a workflow that is left without tasks is dropped
+	_, _, workflows = a.splitTasks(tasks[:2])
+	cf.EXPECT().ReportTaskStatus(mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+	assert.Empty(t, a.coalesceWorkflows(context.Background(), workflows))
+}
+
+func Test_cancelWorkflows(t *testing.T) {
+	metadata := task.Metadata{WorkflowId: "wf1", ReName: "some-rt"}
+	tasks := task.Tasks{
+		{Id: "create", Type: task.TypeCreatePod, Metadata: metadata},
+		{Id: "cancel1", Type: task.TypeCancelWorkflow, Metadata: task.Metadata{WorkflowId: "wf1", ShouldReportStatus: true}, Spec: task.CancelWorkflowSpec{Reason: "build aborted"}},
+		{Id: "cancel2", Type: task.TypeCancelWorkflow, Metadata: task.Metadata{WorkflowId: "wf2", ShouldReportStatus: true}},
+	}
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "cancel1", mock.MatchedBy(func(s task.TaskStatus) bool {
+		return s.Status == task.StatusSuccess && s.Reason == ""
+	})).Return(nil).Once()
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "cancel2", mock.MatchedBy(func(s task.TaskStatus) bool {
+		return s.Status == task.StatusSuccess && s.Reason == notFoundCancelReason
+	})).Return(nil).Once()
+	a := &Agent{
+		cf:  cf,
+		log: logger.New(logger.Options{}),
+		wfQueue: queue.New(&queue.Options{
+			Runtimes:   runtime.NewRegistry(map[string]runtime.Runtime{}),
+			Log:        logger.New(logger.Options{}),
+			Monitor:    monitoring.NewEmpty(),
+			Codefresh:  cf,
+			BufferSize: 1,
+		}),
+	}
+
+	_, cancelTasks, workflows := a.splitTasks(tasks)
+	assert.Len(t, workflows, 1)
+	assert.Equal(t, []string{"cancel1", "cancel2"}, []string{cancelTasks[0].Id, cancelTasks[1].Id})
+	a.wfQueue.Enqueue(workflows[0])
+	a.cancelWorkflows(context.Background(), cancelTasks)
+}
+
+func TestAgent_Stop(t *testing.T) {
+	tests := map[string]struct {
+		drainTimeout  time.Duration
+		blockUntilCtx bool
+		wantCancelled bool
+	}{
+		"should release queued workflows and wait for in-flight ones": {
+			drainTimeout:  time.Second * 5,
+			blockUntilCtx: false,
+			wantCancelled: false,
+		},
+		"should cancel in-flight workflows once the drain timeout is exceeded": {
+			drainTimeout:  time.Millisecond * 100,
+			blockUntilCtx: true,
+			wantCancelled: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{})
+			cancelled := false
+			cf := codefresh.NewMockCodefresh(t)
+			cf.EXPECT().ReportStatus(mock.Anything, mock.Anything).Return(nil).Maybe()
+			cf.EXPECT().ReleaseTasks(mock.Anything, []string{"t2", "t3"}).Return(nil).Once()
+			k := kubernetes.NewMockKubernetes(t)
+			k.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, mock.Anything).RunAndReturn(func(ctx context.Context, _ task.Type, _ interface{}) error {
+				close(started)
+				if tt.blockUntilCtx {
+					<-ctx.Done()
+					cancelled = true
+					return ctx.Err()
+				}
+
+				time.Sleep(time.Millisecond * 50)
+				return nil
+			}).Once()
+
+			a, err := New(&Options{
+				ID:        "agent",
+				Codefresh: cf,
+				Runtimes: map[string]runtime.Runtime{
+					"some-rt": runtime.New(runtime.Options{Kubernetes: k}),
+				},
+				Logger:                         logger.New(logger.Options{}),
+				TaskPullingSecondsInterval:     time.Hour,
+				StatusReportingSecondsInterval: time.Hour,
+				Concurrency:                    1,
+				BufferSize:                     10,
+				DrainTimeout:                   tt.drainTimeout,
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, a.Start(context.Background()))
+
+			for _, id := range []string{"t1", "t2", "t3"} {
+				metadata := task.Metadata{WorkflowId: "wf-" + id, ReName: "some-rt"}
+				wf := workflow.New(metadata)
+				_ = wf.AddTask(&task.Task{Id: id, Type: task.TypeCreatePod, Metadata: metadata, Spec: id})
+				a.wfQueue.Enqueue(wf)
+				if id == "t1" {
+					<-started
+				}
+			}
+
+			assert.NoError(t, a.Stop())
+			assert.Equal(t, tt.wantCancelled, cancelled)
+			assert.Equal(t, errAlreadyStopped, a.Stop())
+		})
+	}
+}
+
+func Test_proxyRequest_redactsToken(t *testing.T) {
+	const token = "some-proxy-event-token"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"echo": "%s"}`, r.Header.Get("x-access-token"))
+	}))
+	defer server.Close()
+
+	buf := &bytes.Buffer{}
+	log := logger.New(logger.Options{Verbose: true, Output: buf})
+	err := proxyRequest(&task.AgentTask{
+		Type: "proxy",
+		Params: map[string]interface{}{
+			"runtimeContext": map[string]interface{}{
+				"context": map[string]interface{}{
+					"variables": map[string]interface{}{
+						"proxyUrl": server.URL,
+					},
+					"eventReporting": map[string]interface{}{
+						"token": token,
+					},
+				},
+			},
+		},
+	}, log)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "proxy task response")
+	assert.NotContains(t, buf.String(), token)
+}
@@ -0,0 +1,302 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench drives synthetic workflow batches through the workflow queue, and reports its throughput and latency
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/monitoring"
+	"github.com/codefresh-io/go/venona/pkg/queue"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+	"github.com/codefresh-io/go/venona/pkg/task"
+	"github.com/codefresh-io/go/venona/pkg/workflow"
+)
+
+const benchNamespace = "bench"
+
+type (
+	// Options for a benchmark run
+	Options struct {
+		// Runtimes handle the workflows, which are spread evenly between them
+		Runtimes map[string]runtime.Runtime
+		Logger   logger.Logger
+		// Concurrency and BufferSize of the workflow queue
+		Concurrency int
+		BufferSize  int
+		// Workflows is the total number of workflows, enqueued in batches of BatchSize every BatchInterval
+		Workflows     int
+		BatchSize     int
+		BatchInterval time.Duration
+		// Tasks are the task types of every workflow, in order
+		Tasks []task.Type
+	}
+
+	// Percentiles of a latency
+	Percentiles struct {
+		P50 time.Duration `json:"p50"`
+		P90 time.Duration `json:"p90"`
+		P95 time.Duration `json:"p95"`
+		P99 time.Duration `json:"p99"`
+		Max time.Duration `json:"max"`
+	}
+
+	// Report of a benchmark run
+	Report struct {
+		Workflows   int           `json:"workflows"`
+		Tasks       int           `json:"tasks"`
+		FailedTasks int           `json:"failedTasks"`
+		Duration    time.Duration `json:"duration"`
+		// Throughput is the number of workflows done per second
+		Throughput float64 `json:"throughput"`
+		// WorkflowLatency is the time from enqueueing a workflow until its last task status
+		WorkflowLatency Percentiles `json:"workflowLatency"`
+		// TaskLatency is the time from the previous task of the workflow (or enqueueing) until the task status
+		TaskLatency Percentiles `json:"taskLatency"`
+	}
+
+	// statusCollector is the Codefresh client of the queue, it only receives the task statuses
+	statusCollector struct {
+		codefresh.Codefresh
+		mutex    sync.Mutex
+		last     map[string]time.Time
+		remained map[string]int
+		enqueued map[string]time.Time
+		workflow []time.Duration
+		task     []time.Duration
+		failed   int
+		done     chan struct{}
+		pending  int
+	}
+)
+
+var (
+	errOptionsRequired   = errors.New("Options are required")
+	errRuntimesRequired  = errors.New("Runtimes options is required")
+	errLoggerRequired    = errors.New("Logger options is required")
+	errTasksRequired     = errors.New("Tasks options is required")
+	errInvalidWorkflows  = errors.New("Workflows and BatchSize options must be positive")
+	errInvalidQueueSizes = errors.New("Concurrency and BufferSize options must be positive")
+)
+
+// Run enqueues all the workflows and waits for their task statuses, or until ctx is cancelled
+func Run(ctx context.Context, opts *Options) (*Report, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(opts.Runtimes))
+	for name := range opts.Runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	collector := &statusCollector{
+		last:     map[string]time.Time{},
+		remained: map[string]int{},
+		enqueued: map[string]time.Time{},
+		done:     make(chan struct{}),
+		pending:  opts.Workflows,
+	}
+	wg := &sync.WaitGroup{}
+	q := queue.New(&queue.Options{
+		Runtimes:    runtime.NewRegistry(opts.Runtimes),
+		Log:         opts.Logger,
+		WG:          wg,
+		Monitor:     monitoring.NewEmpty(),
+		Concurrency: opts.Concurrency,
+		BufferSize:  opts.BufferSize,
+		Codefresh:   collector,
+	})
+	q.Start(ctx)
+	defer func() {
+		q.Stop()
+		wg.Wait()
+	}()
+
+	start := time.Now()
+	for i := 0; i < opts.Workflows; i++ {
+		if i > 0 && i%opts.BatchSize == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(opts.BatchInterval):
+			}
+		}
+
+		wf := newWorkflow(fmt.Sprintf("bench-%d", i), names[i%len(names)], opts.Tasks)
+		collector.enqueue(wf)
+		q.Enqueue(wf)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-collector.done:
+	}
+
+	return collector.report(time.Since(start)), nil
+}
+
+func newWorkflow(id string, reName string, types []task.Type) *workflow.Workflow {
+	metadata := task.Metadata{
+		WorkflowId:         id,
+		ReName:             reName,
+		CreatedAt:          time.Now().Format(time.RFC3339),
+		ShouldReportStatus: true,
+	}
+	wf := workflow.New(metadata)
+	for i, t := range types {
+		name := fmt.Sprintf("%s-%d", id, i)
+		_ = wf.AddTask(&task.Task{
+			Id:       name,
+			Type:     t,
+			Metadata: metadata,
+			Spec:     taskSpec(t, name),
+		})
+	}
+
+	return wf
+}
+
+func taskSpec(t task.Type, name string) interface{} {
+	switch t {
+	case task.TypeCreatePod:
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": name, "namespace": benchNamespace},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "main", "image": "alpine"}},
+			},
+		}
+	case task.TypeCreatePVC:
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"name": name, "namespace": benchNamespace},
+		}
+	default:
+		return map[string]interface{}{"name": name, "namespace": benchNamespace}
+	}
+}
+
+func (c *statusCollector) enqueue(wf *workflow.Workflow) {
+	now := time.Now()
+	wf.Timeline.Pulled = now
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.enqueued[wf.Metadata.WorkflowId] = now
+	c.last[wf.Metadata.WorkflowId] = now
+	c.remained[wf.Metadata.WorkflowId] = len(wf.Tasks)
+}
+
+// ReportTaskStatus records the latency of the task, and of its workflow when it is the last task
+func (c *statusCollector) ReportTaskStatus(_ context.Context, id string, status task.TaskStatus) error {
+	now := time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// the task ids are <workflow id>-<index>
+	wfID := id[:strings.LastIndexByte(id, '-')]
+	c.task = append(c.task, now.Sub(c.last[wfID]))
+	c.last[wfID] = now
+	if status.Status != task.StatusSuccess {
+		c.failed++
+	}
+
+	if c.remained[wfID]--; c.remained[wfID] == 0 {
+		c.workflow = append(c.workflow, now.Sub(c.enqueued[wfID]))
+		delete(c.enqueued, wfID)
+		delete(c.last, wfID)
+		delete(c.remained, wfID)
+		if c.pending--; c.pending == 0 {
+			close(c.done)
+		}
+	}
+
+	return nil
+}
+
+func (c *statusCollector) report(duration time.Duration) *Report {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return &Report{
+		Workflows:       len(c.workflow),
+		Tasks:           len(c.task),
+		FailedTasks:     c.failed,
+		Duration:        duration,
+		Throughput:      float64(len(c.workflow)) / duration.Seconds(),
+		WorkflowLatency: percentiles(c.workflow),
+		TaskLatency:     percentiles(c.task),
+	}
+}
+
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		// nearest rank
+		return sorted[max(0, int(math.Ceil(p*float64(len(sorted))))-1)]
+	}
+
+	return Percentiles{
+		P50: at(0.5),
+		P90: at(0.9),
+		P95: at(0.95),
+		P99: at(0.99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+func checkOptions(opts *Options) error {
+	if opts == nil {
+		return errOptionsRequired
+	}
+
+	if len(opts.Runtimes) == 0 {
+		return errRuntimesRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	if len(opts.Tasks) == 0 {
+		return errTasksRequired
+	}
+
+	if opts.Workflows <= 0 || opts.BatchSize <= 0 {
+		return errInvalidWorkflows
+	}
+
+	if opts.Concurrency <= 0 || opts.BufferSize <= 0 {
+		return errInvalidQueueSizes
+	}
+
+	return nil
+}
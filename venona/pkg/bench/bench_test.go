@@ -0,0 +1,120 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type runtimeFunc func(t *task.Task) error
+
+func (f runtimeFunc) HandleTask(_ context.Context, t *task.Task) error {
+	return f(t)
+}
+
+func TestRun(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		handled = map[string][]task.Type{}
+	)
+	handle := func(t *task.Task) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled[t.Metadata.ReName] = append(handled[t.Metadata.ReName], t.Type)
+		if t.Id == "bench-3-1" {
+			return errors.New("some error")
+		}
+
+		return nil
+	}
+
+	report, err := Run(context.Background(), &Options{
+		Runtimes: map[string]runtime.Runtime{
+			"runtime-0": runtimeFunc(handle),
+			"runtime-1": runtimeFunc(handle),
+		},
+		Logger:        logger.New(logger.Options{Level: "crit"}),
+		Concurrency:   2,
+		BufferSize:    10,
+		Workflows:     5,
+		BatchSize:     2,
+		BatchInterval: 10 * time.Millisecond,
+		Tasks:         []task.Type{task.TypeCreatePVC, task.TypeCreatePod},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Workflows)
+	assert.Equal(t, 10, report.Tasks)
+	assert.Equal(t, 1, report.FailedTasks)
+	// two batch intervals
+	assert.GreaterOrEqual(t, report.Duration, 20*time.Millisecond)
+	assert.LessOrEqual(t, report.WorkflowLatency.P50, report.WorkflowLatency.Max)
+	assert.Len(t, handled["runtime-0"], 6)
+	assert.Len(t, handled["runtime-1"], 4)
+}
+
+func TestRun_options(t *testing.T) {
+	tests := map[string]struct {
+		opts    *Options
+		wantErr error
+	}{
+		"should fail without options": {
+			wantErr: errOptionsRequired,
+		},
+		"should fail without runtimes": {
+			opts:    &Options{},
+			wantErr: errRuntimesRequired,
+		},
+		"should fail without workflows": {
+			opts: &Options{
+				Runtimes: map[string]runtime.Runtime{"runtime-0": runtimeFunc(nil)},
+				Logger:   logger.New(logger.Options{}),
+				Tasks:    []task.Type{task.TypeCreatePod},
+			},
+			wantErr: errInvalidWorkflows,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Run(context.Background(), tt.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_percentiles(t *testing.T) {
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, Percentiles{
+		P50: 50 * time.Millisecond,
+		P90: 90 * time.Millisecond,
+		P95: 95 * time.Millisecond,
+		P99: 99 * time.Millisecond,
+		Max: 100 * time.Millisecond,
+	}, percentiles(durations))
+	assert.Equal(t, Percentiles{}, percentiles(nil))
+}
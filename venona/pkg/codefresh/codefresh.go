@@ -17,6 +17,8 @@ package codefresh
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -36,10 +38,24 @@ type (
 	Codefresh interface {
 		Tasks(ctx context.Context) (task.Tasks, error)
 		ReportTaskStatus(ctx context.Context, id string, status task.TaskStatus) error
+		ReleaseTasks(ctx context.Context, ids []string) error
 		ReportStatus(ctx context.Context, status AgentStatus) error
 		Host() string
 	}
 
+	// Admin API client, creates and removes the agent and its runtime environments with a user token
+	Admin interface {
+		GetAgent(ctx context.Context, name string) (*Agent, error)
+		CreateAgent(ctx context.Context, name string) (*Agent, error)
+		UpdateAgent(ctx context.Context, id string, req UpdateAgentRequest) error
+		DeleteAgent(ctx context.Context, id string) error
+		CreateRuntimeEnvironment(ctx context.Context, req CreateRuntimeEnvironmentRequest) error
+		GetRuntimeEnvironment(ctx context.Context, name string, system bool) (map[string]interface{}, error)
+		PatchRuntimeEnvironment(ctx context.Context, name string, spec map[string]interface{}, system bool) error
+		ModifyRuntimeEnvironmentAccounts(ctx context.Context, name string, accounts []string) error
+		DeleteRuntimeEnvironment(ctx context.Context, name string, system bool) error
+	}
+
 	// RequestDoer runs HTTP request
 	RequestDoer interface {
 		Do(*http.Request) (*http.Response, error)
@@ -65,6 +81,15 @@ type (
 
 // New build Codefresh client from options
 func New(opts Options) Codefresh {
+	return newCf(opts)
+}
+
+// NewAdmin builds the Codefresh admin client from options
+func NewAdmin(opts Options) Admin {
+	return newCf(opts)
+}
+
+func newCf(opts Options) *cf {
 	host := opts.Host
 	if host == "" {
 		host = defaultHost
@@ -112,6 +137,28 @@ func (c cf) ReportTaskStatus(ctx context.Context, id string, status task.TaskSta
 	return nil
 }
 
+// ReleaseTasks hands pulled but unprocessed tasks back to Codefresh, so they can be picked up by another agent
+func (c cf) ReleaseTasks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	r := ReleaseTasksRequest{
+		Tasks: ids,
+	}
+	s, err := r.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed marshalling when releasing tasks: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "POST", bytes.NewBuffer(s), nil, "api", "agent", c.agentID, "tasks", "release")
+	if err != nil {
+		return fmt.Errorf("failed sending request when releasing tasks: %w", err)
+	}
+
+	return nil
+}
+
 // Host returns the host
 func (c cf) Host() string {
 	return c.host
@@ -132,6 +179,158 @@ func (c cf) ReportStatus(ctx context.Context, status AgentStatus) error {
 	return nil
 }
 
+// GetAgent returns the agent with the given name, or nil if it does not exist
+func (c cf) GetAgent(ctx context.Context, name string) (*Agent, error) {
+	res, err := c.doRequest(ctx, "GET", nil, nil, "api", "agents", "name", name)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed getting agent \"%s\": %w", name, err)
+	}
+
+	agent := &Agent{}
+	if err := json.Unmarshal(res, agent); err != nil {
+		return nil, fmt.Errorf("failed unmarshalling agent \"%s\": %w", name, err)
+	}
+
+	return agent, nil
+}
+
+// CreateAgent creates a new agent, the returned agent holds its token
+func (c cf) CreateAgent(ctx context.Context, name string) (*Agent, error) {
+	s, err := json.Marshal(CreateAgentRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling when creating agent: %w", err)
+	}
+
+	res, err := c.doRequest(ctx, "POST", bytes.NewBuffer(s), nil, "api", "agents")
+	if err != nil {
+		return nil, fmt.Errorf("failed sending request when creating agent: %w", err)
+	}
+
+	agent := &Agent{}
+	if err := json.Unmarshal(res, agent); err != nil {
+		return nil, fmt.Errorf("failed unmarshalling agent \"%s\": %w", name, err)
+	}
+
+	if agent.Token == "" {
+		return nil, errors.New("created agent without a token")
+	}
+
+	return agent, nil
+}
+
+// UpdateAgent updates the agent entity
+func (c cf) UpdateAgent(ctx context.Context, id string, req UpdateAgentRequest) error {
+	s, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed marshalling when updating agent: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "PUT", bytes.NewBuffer(s), nil, "api", "agents", id)
+	if err != nil {
+		return fmt.Errorf("failed sending request when updating agent: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAgent deletes the agent entity
+func (c cf) DeleteAgent(ctx context.Context, id string) error {
+	_, err := c.doRequest(ctx, "DELETE", nil, nil, "api", "agents", id)
+	if err != nil {
+		return fmt.Errorf("failed sending request when deleting agent: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRuntimeEnvironment creates a new runtime environment
+func (c cf) CreateRuntimeEnvironment(ctx context.Context, req CreateRuntimeEnvironmentRequest) error {
+	s, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed marshalling when creating runtime environment: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "POST", bytes.NewBuffer(s), nil, "api", "runtime-environments")
+	if err != nil {
+		return fmt.Errorf("failed sending request when creating runtime environment: %w", err)
+	}
+
+	return nil
+}
+
+// GetRuntimeEnvironment returns the spec of the runtime environment, or nil if it does not exist
+func (c cf) GetRuntimeEnvironment(ctx context.Context, name string, system bool) (map[string]interface{}, error) {
+	res, err := c.doRequest(ctx, "GET", nil, nil, runtimeEnvironmentPath(system, name)...)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed getting runtime environment \"%s\": %w", name, err)
+	}
+
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(res, &spec); err != nil {
+		return nil, fmt.Errorf("failed unmarshalling runtime environment \"%s\": %w", name, err)
+	}
+
+	return spec, nil
+}
+
+// PatchRuntimeEnvironment updates the runtime environment with the given spec,
+// system runtime environments are updated through the admin API
+func (c cf) PatchRuntimeEnvironment(ctx context.Context, name string, spec map[string]interface{}, system bool) error {
+	s, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed marshalling when patching runtime environment: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "PUT", bytes.NewBuffer(s), nil, runtimeEnvironmentPath(system, name)...)
+	if err != nil {
+		return fmt.Errorf("failed sending request when patching runtime environment: %w", err)
+	}
+
+	return nil
+}
+
+// ModifyRuntimeEnvironmentAccounts replaces the accounts a system runtime environment is shared with
+func (c cf) ModifyRuntimeEnvironmentAccounts(ctx context.Context, name string, accounts []string) error {
+	s, err := json.Marshal(ModifyAccountsRequest{Accounts: accounts})
+	if err != nil {
+		return fmt.Errorf("failed marshalling when modifying runtime environment accounts: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "PUT", bytes.NewBuffer(s), nil, "api", "admin", "runtime-environments", "account", "modify", name)
+	if err != nil {
+		return fmt.Errorf("failed sending request when modifying runtime environment accounts: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRuntimeEnvironment deletes the runtime environment,
+// system runtime environments are deleted through the admin API
+func (c cf) DeleteRuntimeEnvironment(ctx context.Context, name string, system bool) error {
+	_, err := c.doRequest(ctx, "DELETE", nil, nil, runtimeEnvironmentPath(system, name)...)
+	if err != nil {
+		return fmt.Errorf("failed sending request when deleting runtime environment: %w", err)
+	}
+
+	return nil
+}
+
+func runtimeEnvironmentPath(system bool, name string) []string {
+	if system {
+		return []string{"api", "admin", "runtime-environments", name}
+	}
+
+	return []string{"api", "runtime-environments", name}
+}
+
 func (c cf) buildErrorFromResponse(status int, body []byte) error {
 	return Error{
 		APIStatusCode: status,
@@ -177,6 +376,9 @@ func (c cf) prepareRequest(method string, data io.Reader, query map[string]strin
 	}
 
 	req.Header = c.headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
 	if c.token != "" {
 		req.Header.Add("Authorization", c.token)
 	}
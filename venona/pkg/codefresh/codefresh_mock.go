@@ -77,6 +77,49 @@ func (_c *MockCodefresh_Host_Call) RunAndReturn(run func() string) *MockCodefres
 	return _c
 }
 
+// ReleaseTasks provides a mock function with given fields: ctx, ids
+func (_m *MockCodefresh) ReleaseTasks(ctx context.Context, ids []string) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockCodefresh_ReleaseTasks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseTasks'
+type MockCodefresh_ReleaseTasks_Call struct {
+	*mock.Call
+}
+
+// ReleaseTasks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ids []string
+func (_e *MockCodefresh_Expecter) ReleaseTasks(ctx interface{}, ids interface{}) *MockCodefresh_ReleaseTasks_Call {
+	return &MockCodefresh_ReleaseTasks_Call{Call: _e.mock.On("ReleaseTasks", ctx, ids)}
+}
+
+func (_c *MockCodefresh_ReleaseTasks_Call) Run(run func(ctx context.Context, ids []string)) *MockCodefresh_ReleaseTasks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockCodefresh_ReleaseTasks_Call) Return(_a0 error) *MockCodefresh_ReleaseTasks_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCodefresh_ReleaseTasks_Call) RunAndReturn(run func(context.Context, []string) error) *MockCodefresh_ReleaseTasks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ReportStatus provides a mock function with given fields: ctx, status
 func (_m *MockCodefresh) ReportStatus(ctx context.Context, status AgentStatus) error {
 	ret := _m.Called(ctx, status)
@@ -15,7 +15,10 @@
 package codefresh
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -137,3 +140,118 @@ func Test_cf_prepareURL(t *testing.T) {
 		})
 	}
 }
+
+func TestError_Error(t *testing.T) {
+	err := Error{
+		APIStatusCode: 401,
+		Message:       `{"message": "invalid token", "token": "some-leaked-token"}`,
+	}
+	assert.EqualError(t, err, `HTTP request to Codefresh API rejected. Status-Code: 401. Message: {"message": "invalid token", "token": "[REDACTED]"}`)
+}
+
+func Test_cf_GetAgent(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		body    string
+		want    *Agent
+		wantErr string
+	}{
+		"should return the agent": {
+			status: http.StatusOK,
+			body:   `{"id": "1", "name": "some-agent", "runtimes": ["some-runtime"]}`,
+			want:   &Agent{ID: "1", Name: "some-agent", Runtimes: []string{"some-runtime"}},
+		},
+		"should return nil when the agent does not exist": {
+			status: http.StatusNotFound,
+		},
+		"should fail on other errors": {
+			status:  http.StatusInternalServerError,
+			body:    "oops",
+			wantErr: "failed getting agent \"some-agent\": HTTP request to Codefresh API rejected. Status-Code: 500. Message: oops",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/agents/name/some-agent", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			c := NewAdmin(Options{Host: server.URL, HTTPClient: server.Client()})
+			got, err := c.GetAgent(context.Background(), "some-agent")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_cf_GetRuntimeEnvironment(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		body   string
+		want   map[string]interface{}
+	}{
+		"should return the runtime environment": {
+			status: http.StatusOK,
+			body:   `{"metadata": {"name": "some-cluster/ns"}}`,
+			want:   map[string]interface{}{"metadata": map[string]interface{}{"name": "some-cluster/ns"}},
+		},
+		"should return nil when the runtime environment does not exist": {
+			status: http.StatusNotFound,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/runtime-environments/some-cluster%2Fns", r.URL.EscapedPath())
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			c := NewAdmin(Options{Host: server.URL, HTTPClient: server.Client()})
+			got, err := c.GetRuntimeEnvironment(context.Background(), "some-cluster/ns", false)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_cf_PatchRuntimeEnvironment(t *testing.T) {
+	tests := map[string]struct {
+		system   bool
+		wantPath string
+	}{
+		"should patch a runtime environment": {
+			wantPath: "/api/runtime-environments/some-cluster%2Fns",
+		},
+		"should patch a system runtime environment with the admin api": {
+			system:   true,
+			wantPath: "/api/admin/runtime-environments/some-cluster%2Fns",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, tt.wantPath, r.URL.EscapedPath())
+				assert.JSONEq(t, `{"metadata": {"name": "some-cluster/ns"}}`, string(body))
+			}))
+			defer server.Close()
+
+			c := NewAdmin(Options{Host: server.URL, HTTPClient: server.Client()})
+			err := c.PatchRuntimeEnvironment(context.Background(), "some-cluster/ns", map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "some-cluster/ns"},
+			}, tt.system)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codefresh
+
+type (
+	// Agent is the platform entity of a runner
+	Agent struct {
+		ID       string   `json:"id"`
+		Name     string   `json:"name"`
+		Runtimes []string `json:"runtimes,omitempty"`
+		// Token is only returned when the agent is created
+		Token string `json:"token,omitempty"`
+	}
+
+	// CreateAgentRequest creates a new agent
+	CreateAgentRequest struct {
+		Name string `json:"name"`
+	}
+
+	// UpdateAgentRequest replaces the runtimes the agent is attached to
+	UpdateAgentRequest struct {
+		Runtimes []string `json:"runtimes"`
+	}
+
+	// CreateRuntimeEnvironmentRequest creates a runtime environment that is handled by an agent
+	CreateRuntimeEnvironmentRequest struct {
+		RuntimeEnvironmentName string `json:"runtimeEnvironmentName"`
+		ClusterName            string `json:"clusterName"`
+		Namespace              string `json:"namespace"`
+		Agent                  bool   `json:"agent"`
+	}
+
+	// ModifyAccountsRequest replaces the accounts a system runtime environment is shared with
+	ModifyAccountsRequest struct {
+		Accounts []string `json:"accounts"`
+	}
+)
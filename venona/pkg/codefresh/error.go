@@ -14,7 +14,13 @@
 
 package codefresh
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/codefresh-io/go/venona/pkg/redact"
+)
 
 type (
 	// Error is an error that may be thrown from Codefresh API
@@ -25,5 +31,21 @@ type (
 )
 
 func (c Error) Error() string {
-	return fmt.Sprintf("HTTP request to Codefresh API rejected. Status-Code: %d. Message: %s", c.APIStatusCode, c.Message)
+	// the message is the response body, which might echo back the request credentials
+	return fmt.Sprintf("HTTP request to Codefresh API rejected. Status-Code: %d. Message: %s", c.APIStatusCode, redact.String(c.Message))
+}
+
+// IsNotFound returns true if the Codefresh API rejected the request with 404
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict returns true if the Codefresh API rejected the request with 409, usually when the entity already exists
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+func hasStatusCode(err error, status int) bool {
+	var cfErr Error
+	return errors.As(err, &cfErr) && cfErr.APIStatusCode == status
 }
@@ -21,9 +21,19 @@ type (
 	AgentStatus struct {
 		Message string `json:"message"`
 	}
+
+	// ReleaseTasksRequest lists the ids of the tasks that are handed back to Codefresh
+	ReleaseTasksRequest struct {
+		Tasks []string `json:"tasks"`
+	}
 )
 
 // Marshal status
 func (r *AgentStatus) Marshal() ([]byte, error) {
 	return json.Marshal(r)
 }
+
+// Marshal release tasks request
+func (r *ReleaseTasksRequest) Marshal() ([]byte, error) {
+	return json.Marshal(r)
+}
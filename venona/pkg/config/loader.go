@@ -19,7 +19,11 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
 	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/pvcpool"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/registry"
 
 	"gopkg.in/yaml.v2"
 )
@@ -37,6 +41,18 @@ type (
 		Token string `yaml:"token" json:"token"`
 		Host  string `yaml:"host" json:"host"`
 		Name  string `yaml:"name" json:"name"`
+		// MutationPolicy is the path of the mutation policy file of the runtime, relative to the config file
+		MutationPolicy string `yaml:"mutationPolicy,omitempty" json:"mutationPolicy,omitempty"`
+		// AdmissionPolicy is the path of the admission policy file of the runtime, relative to the config file
+		AdmissionPolicy string `yaml:"admissionPolicy,omitempty" json:"admissionPolicy,omitempty"`
+		// Registry rewrites the images of the pods of the runtime, its digests file is relative to the config file
+		Registry *registry.Config `yaml:"registry,omitempty" json:"registry,omitempty"`
+		// PVCPool keeps pre-provisioned PVCs for the CreatePvc tasks of the runtime
+		PVCPool *pvcpool.Config `yaml:"pvcPool,omitempty" json:"pvcPool,omitempty"`
+		// Deletion waits until the deleted pods and PVCs of the runtime are gone, and escalates the stuck ones
+		Deletion *kubernetes.DeletionConfig `yaml:"deletion,omitempty" json:"deletion,omitempty"`
+		// Client tunes the Kubernetes client of the runtime, over the client flags of the agent
+		Client *kubernetes.ClientConfig `yaml:"client,omitempty" json:"client,omitempty"`
 	}
 
 	// Options to load the config
@@ -65,7 +81,7 @@ func Load(dir string, pattern string, logger logger.Logger) (map[string]Config,
 func visit(files *[]string, re *regexp.Regexp, log logger.Logger) filepath.WalkFunc {
 	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			log.Error("Failed to visit", "path", path, "err", err.Error())
+			log.Error("Failed to visit", "path", path, "error", err.Error())
 			return nil
 		}
 
@@ -98,18 +114,35 @@ func buildConfigMap(files []string, logger logger.Logger) (map[string]Config, er
 	for _, file := range files {
 		b, err := readfile(file)
 		if err != nil {
-			logger.Error("Failed to read file content", "file", file, "err", err.Error())
+			logger.Error("Failed to read file content", "file", file, "error", err.Error())
 			continue
 		}
 
 		cnf, err := unmarshalConfig(b)
 		if err != nil {
-			logger.Error("Failed to unmarshal file content into struct", "file", file, "err", err.Error())
+			logger.Error("Failed to unmarshal file content into struct", "file", file, "error", err.Error())
 			continue
 		}
 
+		// the token is masked in every log from now on, including the errors of the runtime's client
+		redact.AddSecret(cnf.Token)
+		cnf.MutationPolicy = relativeTo(file, cnf.MutationPolicy)
+		cnf.AdmissionPolicy = relativeTo(file, cnf.AdmissionPolicy)
+		if cnf.Registry != nil {
+			cnf.Registry.Digests = relativeTo(file, cnf.Registry.Digests)
+		}
+
 		result[file] = cnf
 	}
 
 	return result, nil
 }
+
+// relativeTo resolves a path that is relative to the config file
+func relativeTo(file string, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+
+	return filepath.Join(filepath.Dir(file), p)
+}
@@ -15,11 +15,17 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
 	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/registry"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -70,6 +76,54 @@ func TestLoad(t *testing.T) {
 				return []byte{}, nil
 			},
 		},
+		"should resolve the policies relative to the config file": {
+			args: args{
+				dir:     "location",
+				pattern: ".*",
+			},
+			want: map[string]Config{
+				"location/a.yaml": {Name: "a", MutationPolicy: "location/policies/a.yaml", AdmissionPolicy: "location/admission.yaml"},
+				"location/b.yaml": {Name: "b", MutationPolicy: "/etc/policies/b.yaml", Registry: &registry.Config{
+					Rewrites: []registry.Rewrite{{From: "docker.io/", To: "registry.local/"}},
+					Digests:  "location/digests.yaml",
+				}},
+			},
+			walkFileFunc: func(root string, fn filepath.WalkFunc) error {
+				_ = fn("location/a.yaml", &info{name: "a.yaml"}, nil)
+				return fn("location/b.yaml", &info{name: "b.yaml"}, nil)
+			},
+			fileReadFunc: func(file string) ([]byte, error) {
+				if file == "location/a.yaml" {
+					return []byte("name: a\nmutationPolicy: policies/a.yaml\nadmissionPolicy: admission.yaml"), nil
+				}
+
+				return []byte("name: b\nmutationPolicy: /etc/policies/b.yaml\nregistry:\n  rewrites:\n  - from: docker.io/\n    to: registry.local/\n  digests: digests.yaml"), nil
+			},
+		},
+		"should load the client and deletion tuning of a runtime": {
+			args: args{
+				dir:     "location",
+				pattern: ".*",
+			},
+			want: map[string]Config{
+				"location/a.yaml": {
+					Name: "a",
+					Client: &kubernetes.ClientConfig{
+						QPS:         20,
+						Timeout:     30 * time.Second,
+						ProxyURL:    "http://proxy:3128",
+						Impersonate: &kubernetes.Impersonate{User: "runner", Groups: []string{"builders"}},
+					},
+					Deletion: &kubernetes.DeletionConfig{WaitTimeout: 2 * time.Minute, ForceAfter: 30 * time.Second},
+				},
+			},
+			walkFileFunc: func(root string, fn filepath.WalkFunc) error {
+				return fn("location/a.yaml", &info{name: "a.yaml"}, nil)
+			},
+			fileReadFunc: func(string) ([]byte, error) {
+				return []byte("name: a\nclient:\n  qps: 20\n  timeout: 30s\n  proxyURL: http://proxy:3128\n  impersonate:\n    user: runner\n    groups: [builders]\ndeletion:\n  waitTimeout: 2m\n  forceAfter: 30s"), nil
+			},
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -89,3 +143,24 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_redactsToken(t *testing.T) {
+	defer func() {
+		readfile = os.ReadFile
+		walkFilePath = filepath.Walk
+	}()
+	walkFilePath = func(_ string, fn filepath.WalkFunc) error {
+		return fn("location/rt.runtime.yaml", &info{name: "rt.runtime.yaml"}, nil)
+	}
+	readfile = func(string) ([]byte, error) {
+		return []byte("type: runtime\nname: rt\ntoken: some-config-file-token\n"), nil
+	}
+	out := &bytes.Buffer{}
+	log := logger.New(logger.Options{Output: out})
+
+	_, err := Load("location", ".*.runtime.yaml", log)
+	assert.NoError(t, err)
+	log.Error("Failed to load kubernetes", "error", errors.New("unauthorized: some-config-file-token"))
+	assert.Contains(t, out.String(), "unauthorized: "+redact.Mask)
+	assert.NotContains(t, out.String(), "some-config-file-token")
+}
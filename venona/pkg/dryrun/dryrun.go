@@ -0,0 +1,167 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dryrun collects what the agent would have done when it runs with --dry-run:
+// the resources it would have created and deleted, the agent tasks it would have executed,
+// and the task statuses it would have reported
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/task"
+)
+
+const (
+	// ActionTaskStatus is the type of a suppressed task status report
+	ActionTaskStatus = "TaskStatus"
+
+	defaultMaxActions = 1000
+)
+
+type (
+	// Action the agent would have done
+	Action struct {
+		Time time.Time `json:"time"`
+		// Type is the task type, or ActionTaskStatus
+		Type      string `json:"type"`
+		Namespace string `json:"namespace,omitempty"`
+		// Name of the resource, or id of the task
+		Name string `json:"name"`
+		// Status is the suppressed task status
+		Status task.Status `json:"status,omitempty"`
+		// Error is set when the dry run request failed, which means the real one would have failed too
+		Error string `json:"error,omitempty"`
+	}
+
+	// Report of a dry run
+	Report struct {
+		Since time.Time `json:"since"`
+		// Counts of the actions by type
+		Counts map[string]int `json:"counts"`
+		Errors int            `json:"errors"`
+		// Recent actions, oldest first
+		Recent []Action `json:"recent"`
+	}
+
+	// Summary of a dry run, safe for concurrent use
+	Summary struct {
+		mutex      sync.Mutex
+		since      time.Time
+		counts     map[string]int
+		errors     int
+		recent     []Action
+		maxActions int
+		log        logger.Logger
+	}
+
+	// codefreshClient suppresses the task status reports, all other calls are passed to the wrapped client
+	codefreshClient struct {
+		codefresh.Codefresh
+		summary *Summary
+	}
+)
+
+// NewSummary creates a new Summary that keeps the last maxActions actions (default 1000),
+// and logs every action to log
+func NewSummary(maxActions int, log logger.Logger) *Summary {
+	if maxActions <= 0 {
+		maxActions = defaultMaxActions
+	}
+
+	return &Summary{
+		since:      time.Now(),
+		counts:     map[string]int{},
+		maxActions: maxActions,
+		log:        log,
+	}
+}
+
+// Add records an action
+func (s *Summary) Add(a Action) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+
+	s.log.Info("Dry run", "type", a.Type, "namespace", a.Namespace, "name", a.Name, "status", a.Status, "error", a.Error)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.counts[a.Type]++
+	if a.Error != "" {
+		s.errors++
+	}
+
+	if len(s.recent) == s.maxActions {
+		s.recent = s.recent[1:]
+	}
+
+	s.recent = append(s.recent, a)
+}
+
+// Report returns the counts and the recent actions
+func (s *Summary) Report() Report {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	counts := make(map[string]int, len(s.counts))
+	for t, c := range s.counts {
+		counts[t] = c
+	}
+
+	return Report{
+		Since:  s.since,
+		Counts: counts,
+		Errors: s.errors,
+		Recent: append([]Action{}, s.recent...),
+	}
+}
+
+// LogReport logs the counts of the actions
+func (s *Summary) LogReport() {
+	r := s.Report()
+	ctx := []interface{}{"since", r.Since, "errors", r.Errors}
+	for t, c := range r.Counts {
+		ctx = append(ctx, t, c)
+	}
+
+	s.log.Info("Dry run summary", ctx...)
+}
+
+// ServeHTTP writes the report as JSON
+func (s *Summary) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Report())
+}
+
+// NewCodefresh wraps a Codefresh client so task statuses are added to the summary instead of being reported
+func NewCodefresh(cf codefresh.Codefresh, summary *Summary) codefresh.Codefresh {
+	return &codefreshClient{
+		Codefresh: cf,
+		summary:   summary,
+	}
+}
+
+func (c *codefreshClient) ReportTaskStatus(_ context.Context, id string, status task.TaskStatus) error {
+	c.summary.Add(Action{
+		Type:   ActionTaskStatus,
+		Name:   id,
+		Status: status.Status,
+	})
+	return nil
+}
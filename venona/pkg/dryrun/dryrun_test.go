@@ -0,0 +1,106 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary_Report(t *testing.T) {
+	tests := map[string]struct {
+		maxActions int
+		actions    []Action
+		wantCounts map[string]int
+		wantErrors int
+		wantRecent []string
+	}{
+		"should count the actions by type": {
+			actions: []Action{
+				{Type: "CreatePod", Name: "a"},
+				{Type: "CreatePod", Name: "b"},
+				{Type: "DeletePod", Name: "c", Error: "not found"},
+			},
+			wantCounts: map[string]int{"CreatePod": 2, "DeletePod": 1},
+			wantErrors: 1,
+			wantRecent: []string{"a", "b", "c"},
+		},
+		"should keep only the last actions": {
+			maxActions: 2,
+			actions: []Action{
+				{Type: "CreatePod", Name: "a"},
+				{Type: "CreatePod", Name: "b"},
+				{Type: "CreatePod", Name: "c"},
+			},
+			wantCounts: map[string]int{"CreatePod": 3},
+			wantRecent: []string{"b", "c"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSummary(tt.maxActions, logger.New(logger.Options{}))
+			for _, a := range tt.actions {
+				s.Add(a)
+			}
+
+			r := s.Report()
+			assert.Equal(t, tt.wantCounts, r.Counts)
+			assert.Equal(t, tt.wantErrors, r.Errors)
+			names := []string{}
+			for _, a := range r.Recent {
+				assert.False(t, a.Time.IsZero())
+				names = append(names, a.Name)
+			}
+			assert.Equal(t, tt.wantRecent, names)
+		})
+	}
+}
+
+func TestSummary_ServeHTTP(t *testing.T) {
+	s := NewSummary(0, logger.New(logger.Options{}))
+	s.Add(Action{Type: "CreatePVC", Namespace: "ns", Name: "pvc"})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dry-run", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	r := Report{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+	assert.Equal(t, map[string]int{"CreatePVC": 1}, r.Counts)
+	assert.Len(t, r.Recent, 1)
+	assert.Equal(t, "ns", r.Recent[0].Namespace)
+}
+
+func TestNewCodefresh(t *testing.T) {
+	// the mock fails the test on any unexpected call, so the status must not reach it
+	cf := codefresh.NewMockCodefresh(t)
+	s := NewSummary(0, logger.New(logger.Options{}))
+	err := NewCodefresh(cf, s).ReportTaskStatus(context.Background(), "task-1", task.TaskStatus{Status: task.StatusSuccess})
+
+	assert.NoError(t, err)
+	r := s.Report()
+	assert.Equal(t, map[string]int{ActionTaskStatus: 1}, r.Counts)
+	assert.Equal(t, "task-1", r.Recent[0].Name)
+	assert.Equal(t, task.StatusSuccess, r.Recent[0].Status)
+}
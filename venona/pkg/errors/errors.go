@@ -14,11 +14,63 @@
 
 package errors
 
-type RetriableError interface {
-	IsRetriable() bool
-}
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	RetriableError interface {
+		IsRetriable() bool
+	}
+
+	// WaitableError is the error of an operation that is worth retrying once Wait returns
+	WaitableError interface {
+		error
+		// Wait blocks until the operation might succeed on another attempt, it fails when ctx is done
+		Wait(ctx context.Context) error
+	}
+
+	// TimeoutError is the error of an operation that did not finish before its deadline.
+	// It is retriable, the operation might finish in time on another attempt
+	TimeoutError struct {
+		Operation string
+		Timeout   time.Duration
+		// Err is the error the operation failed with once the deadline was exceeded, if any
+		Err error
+	}
+)
 
+// IsRetriable returns true for timeouts, and for errors that tell they are retriable
 func IsRetriable(err error) bool {
+	if IsTimeout(err) {
+		return true
+	}
+
 	e, ok := err.(RetriableError)
 	return ok && e.IsRetriable()
 }
+
+// IsTimeout returns true when the error is, or wraps, a TimeoutError or context.DeadlineExceeded
+func IsTimeout(err error) bool {
+	var te *TimeoutError
+	return errors.As(err, &te) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s timed out after %s", e.Operation, e.Timeout)
+	}
+
+	return fmt.Sprintf("%s timed out after %s: %s", e.Operation, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) IsRetriable() bool {
+	return true
+}
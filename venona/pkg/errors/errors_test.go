@@ -0,0 +1,68 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type retriable bool
+
+func (r retriable) Error() string {
+	return "some error"
+}
+
+func (r retriable) IsRetriable() bool {
+	return bool(r)
+}
+
+func TestIsRetriable(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"should be false for a plain error": {
+			err:  errors.New("some error"),
+			want: false,
+		},
+		"should be true for a retriable error": {
+			err:  retriable(true),
+			want: true,
+		},
+		"should be false for a non-retriable error": {
+			err:  retriable(false),
+			want: false,
+		},
+		"should be true for a timeout": {
+			err:  &TimeoutError{Operation: "task", Timeout: time.Second, Err: retriable(false)},
+			want: true,
+		},
+		"should be true for an exceeded deadline": {
+			err:  fmt.Errorf("failed creating pod: %w", context.DeadlineExceeded),
+			want: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetriable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,388 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeplatform is a fake of the Codefresh agent API, for running the agent end-to-end without a Codefresh account.
+// It serves scripted tasks, records the reported statuses and can inject latency and errors
+package fakeplatform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// EndpointTasks is the tasks pulling endpoint, GET /api/agent/:id/tasks
+	EndpointTasks Endpoint = "tasks"
+	// EndpointTaskStatus is the task status reporting endpoint, POST /api/agent/:id/tasks/:taskId/statuses
+	EndpointTaskStatus Endpoint = "statuses"
+	// EndpointRelease is the tasks release endpoint, POST /api/agent/:id/tasks/release
+	EndpointRelease Endpoint = "release"
+	// EndpointAgentStatus is the agent status reporting endpoint, PUT /api/agent/:id/status
+	EndpointAgentStatus Endpoint = "status"
+)
+
+type (
+	// Endpoint of the agent API, faults are injected per endpoint
+	Endpoint string
+
+	// Options for creating a new Platform
+	Options struct {
+		// AgentID only accepts requests of this agent, any agent when empty
+		AgentID string
+		// Token is compared to the Authorization header, when set
+		Token  string
+		Logger logger.Logger
+	}
+
+	// Fault injected into the responses of an endpoint
+	Fault struct {
+		// Latency before responding
+		Latency time.Duration
+		// StatusCode responds with this error status instead of handling the request, when set
+		StatusCode int
+		// Count is how many requests are affected, all of them when 0
+		Count int
+	}
+
+	// TaskStatusReport is a task status that was reported by the agent
+	TaskStatusReport struct {
+		TaskID     string          `json:"taskId"`
+		Status     task.TaskStatus `json:"status"`
+		ReceivedAt time.Time       `json:"receivedAt"`
+	}
+
+	// Platform is a fake of the Codefresh agent API
+	Platform struct {
+		agentID       string
+		token         string
+		log           logger.Logger
+		mutex         sync.Mutex
+		pending       task.Tasks
+		pulled        map[string]task.Task
+		statuses      []TaskStatusReport
+		agentStatuses []codefresh.AgentStatus
+		released      []string
+		faults        map[Endpoint]*Fault
+		handler       http.Handler
+	}
+)
+
+var errUnknownEndpoint = errors.New("unknown endpoint")
+
+// New creates a new Platform
+func New(opts Options) *Platform {
+	log := opts.Logger
+	if log == nil {
+		log = logger.New(logger.Options{})
+	}
+
+	p := &Platform{
+		agentID: opts.AgentID,
+		token:   opts.Token,
+		log:     log,
+		pulled:  map[string]task.Task{},
+		faults:  map[Endpoint]*Fault{},
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/api/agent/{agent}/tasks", p.handle(EndpointTasks, p.getTasks)).Methods(http.MethodGet)
+	r.HandleFunc("/api/agent/{agent}/tasks/release", p.handle(EndpointRelease, p.releaseTasks)).Methods(http.MethodPost)
+	r.HandleFunc("/api/agent/{agent}/tasks/{task}/statuses", p.handle(EndpointTaskStatus, p.reportTaskStatus)).Methods(http.MethodPost)
+	r.HandleFunc("/api/agent/{agent}/status", p.handle(EndpointAgentStatus, p.reportStatus)).Methods(http.MethodPut)
+	// control endpoints, for feeding and inspecting a running fake platform
+	r.HandleFunc("/fake/tasks", p.postTasks).Methods(http.MethodPost)
+	r.HandleFunc("/fake/statuses", p.getStatuses).Methods(http.MethodGet)
+	p.handler = r
+	return p
+}
+
+// ServeHTTP serves the agent API
+func (p *Platform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
+
+// AddTasks makes the tasks available to the next pull
+func (p *Platform) AddTasks(tasks ...task.Task) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.pending = append(p.pending, tasks...)
+}
+
+// InjectFault replaces the fault of the endpoint, a zero fault removes it
+func (p *Platform) InjectFault(endpoint Endpoint, fault Fault) error {
+	switch endpoint {
+	case EndpointTasks, EndpointTaskStatus, EndpointRelease, EndpointAgentStatus:
+	default:
+		return fmt.Errorf("%w \"%s\"", errUnknownEndpoint, endpoint)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if fault == (Fault{}) {
+		delete(p.faults, endpoint)
+		return nil
+	}
+
+	p.faults[endpoint] = &fault
+	return nil
+}
+
+// ParseFault reads a fault in the form "<endpoint>[,status=<code>][,latency=<duration>][,count=<n>]",
+// e.g. "tasks,status=503,count=3"
+func ParseFault(s string) (Endpoint, Fault, error) {
+	parts := strings.Split(s, ",")
+	endpoint := Endpoint(parts[0])
+	fault := Fault{}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", fault, fmt.Errorf("invalid fault \"%s\": expected key=value, got \"%s\"", s, part)
+		}
+
+		var err error
+		switch key {
+		case "status":
+			fault.StatusCode, err = strconv.Atoi(value)
+		case "latency":
+			fault.Latency, err = time.ParseDuration(value)
+		case "count":
+			fault.Count, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown key \"%s\"", key)
+		}
+
+		if err != nil {
+			return "", fault, fmt.Errorf("invalid fault \"%s\": %w", s, err)
+		}
+	}
+
+	return endpoint, fault, nil
+}
+
+// Statuses returns all the reported task statuses, in the order they were received
+func (p *Platform) Statuses() []TaskStatusReport {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]TaskStatusReport(nil), p.statuses...)
+}
+
+// StatusesOf returns the reported statuses of a single task
+func (p *Platform) StatusesOf(taskID string) []task.TaskStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	res := []task.TaskStatus{}
+	for _, s := range p.statuses {
+		if s.TaskID == taskID {
+			res = append(res, s.Status)
+		}
+	}
+
+	return res
+}
+
+// AgentStatuses returns all the reported agent statuses
+func (p *Platform) AgentStatuses() []codefresh.AgentStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]codefresh.AgentStatus(nil), p.agentStatuses...)
+}
+
+// Released returns the ids of all the tasks that were handed back by the agent
+func (p *Platform) Released() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.released...)
+}
+
+// Pending returns how many tasks are waiting to be pulled
+func (p *Platform) Pending() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.pending)
+}
+
+// handle authorizes the request and applies the fault of the endpoint, before calling the handler
+func (p *Platform) handle(endpoint Endpoint, handler func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p.token != "" && r.Header.Get("Authorization") != p.token {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		if p.agentID != "" && mux.Vars(r)["agent"] != p.agentID {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("agent \"%s\" not found", mux.Vars(r)["agent"]))
+			return
+		}
+
+		fault := p.takeFault(endpoint)
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if fault.StatusCode != 0 {
+			p.log.Debug("injected fault", "endpoint", endpoint, "status", fault.StatusCode)
+			writeError(w, fault.StatusCode, "injected fault")
+			return
+		}
+
+		if err := handler(w, r); err != nil {
+			p.log.Error("failed handling request", "endpoint", endpoint, "error", err)
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+	}
+}
+
+func (p *Platform) takeFault(endpoint Endpoint) Fault {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	fault, ok := p.faults[endpoint]
+	if !ok {
+		return Fault{}
+	}
+
+	res := *fault
+	if fault.Count > 0 {
+		if fault.Count--; fault.Count == 0 {
+			delete(p.faults, endpoint)
+		}
+	}
+
+	return res
+}
+
+func (p *Platform) getTasks(w http.ResponseWriter, _ *http.Request) error {
+	p.mutex.Lock()
+	tasks := p.pending
+	p.pending = nil
+	for _, t := range tasks {
+		p.pulled[t.Id] = t
+	}
+	p.mutex.Unlock()
+
+	if tasks == nil {
+		tasks = task.Tasks{}
+	}
+
+	if len(tasks) > 0 {
+		p.log.Info("tasks pulled", "tasks", len(tasks))
+	}
+
+	return writeJSON(w, tasks)
+}
+
+func (p *Platform) releaseTasks(w http.ResponseWriter, r *http.Request) error {
+	req := codefresh.ReleaseTasksRequest{}
+	if err := readJSON(r, &req); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	for _, id := range req.Tasks {
+		p.released = append(p.released, id)
+		if t, ok := p.pulled[id]; ok {
+			// released tasks are served again, like the platform does for another agent
+			p.pending = append(p.pending, t)
+			delete(p.pulled, id)
+		}
+	}
+	p.mutex.Unlock()
+
+	p.log.Info("tasks released", "tasks", len(req.Tasks))
+	return writeJSON(w, struct{}{})
+}
+
+func (p *Platform) reportTaskStatus(w http.ResponseWriter, r *http.Request) error {
+	status := task.TaskStatus{}
+	if err := readJSON(r, &status); err != nil {
+		return err
+	}
+
+	id := mux.Vars(r)["task"]
+	p.mutex.Lock()
+	p.statuses = append(p.statuses, TaskStatusReport{
+		TaskID:     id,
+		Status:     status,
+		ReceivedAt: time.Now(),
+	})
+	p.mutex.Unlock()
+
+	p.log.Info("task status reported", "task", id, "status", status.Status, "reason", status.Reason)
+	return writeJSON(w, struct{}{})
+}
+
+func (p *Platform) reportStatus(w http.ResponseWriter, r *http.Request) error {
+	status := codefresh.AgentStatus{}
+	if err := readJSON(r, &status); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.agentStatuses = append(p.agentStatuses, status)
+	p.mutex.Unlock()
+
+	return writeJSON(w, struct{}{})
+}
+
+func (p *Platform) postTasks(w http.ResponseWriter, r *http.Request) {
+	tasks := task.Tasks{}
+	if err := readJSON(r, &tasks); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p.AddTasks(tasks...)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (p *Platform) getStatuses(w http.ResponseWriter, _ *http.Request) {
+	if err := writeJSON(w, p.Statuses()); err != nil {
+		p.log.Error("failed writing statuses", "error", err)
+	}
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeplatform_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/agent"
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/fakeplatform"
+	"github.com/codefresh-io/go/venona/pkg/fakeplatform/fakeplatformtest"
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podSpec(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "some-namespace",
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "engine", "image": "codefresh/engine"},
+			},
+		},
+	}
+}
+
+// startAgent runs the agent and its queue against the fake platform and a fake cluster
+func startAgent(t *testing.T, cf codefresh.Codefresh) *fake.Clientset {
+	client := fake.NewClientset()
+	log := logger.New(logger.Options{})
+	a, err := agent.New(&agent.Options{
+		ID:        "fake-agent",
+		Codefresh: cf,
+		Logger:    log,
+		Runtimes: map[string]runtime.Runtime{
+			"some-runtime": runtime.New(runtime.Options{
+				Kubernetes: kubernetes.NewForClient(client, kubernetes.Options{Logger: log}),
+			}),
+		},
+		TaskPullingSecondsInterval:     10 * time.Millisecond,
+		StatusReportingSecondsInterval: time.Hour,
+		Concurrency:                    2,
+		BufferSize:                     10,
+		DrainTimeout:                   time.Second,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, a.Start(context.Background()))
+	t.Cleanup(func() { _ = a.Stop() })
+	return client
+}
+
+func TestPlatform_endToEnd(t *testing.T) {
+	platform, cf := fakeplatformtest.NewServer(t, fakeplatform.Options{
+		AgentID: "fake-agent",
+		Token:   "some-token",
+	})
+	// the first pulls fail, the agent keeps pulling
+	assert.NoError(t, platform.InjectFault(fakeplatform.EndpointTasks, fakeplatform.Fault{StatusCode: http.StatusServiceUnavailable, Count: 2}))
+	platform.AddTasks(fakeplatform.Workflow{
+		ID:      "wf1",
+		Runtime: "some-runtime",
+		Tasks: task.Tasks{
+			{Type: task.TypeCreatePod, Spec: podSpec("pod-1")},
+		},
+	}.Build()...)
+	platform.AddTasks(fakeplatform.Workflow{
+		ID:      "wf2",
+		Runtime: "unknown-runtime",
+		Tasks: task.Tasks{
+			{Type: task.TypeCreatePod, Spec: podSpec("pod-2")},
+		},
+	}.Build()...)
+
+	client := startAgent(t, cf)
+	assert.Eventually(t, func() bool {
+		return len(platform.StatusesOf("wf1-0")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, task.StatusSuccess, platform.StatusesOf("wf1-0")[0].Status)
+	assert.Empty(t, platform.StatusesOf("wf2-0"))
+	assert.Equal(t, 0, platform.Pending())
+	assert.NotEmpty(t, platform.AgentStatuses())
+
+	_, err := client.CoreV1().Pods("some-namespace").Get(context.Background(), "pod-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestPlatform_rejects(t *testing.T) {
+	tests := map[string]struct {
+		opts       fakeplatform.Options
+		wantStatus int
+	}{
+		"should reject an invalid token": {
+			opts:       fakeplatform.Options{Token: "some-token"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		"should reject an unknown agent": {
+			opts:       fakeplatform.Options{AgentID: "other-agent"},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(fakeplatform.New(tt.opts))
+			defer server.Close()
+			cf := codefresh.New(codefresh.Options{
+				Host:       server.URL,
+				AgentID:    "fake-agent",
+				HTTPClient: server.Client(),
+			})
+
+			_, err := cf.Tasks(context.Background())
+			cfErr := codefresh.Error{}
+			assert.ErrorAs(t, err, &cfErr)
+			assert.Equal(t, tt.wantStatus, cfErr.APIStatusCode)
+		})
+	}
+}
+
+func TestParseFault(t *testing.T) {
+	tests := map[string]struct {
+		fault        string
+		wantEndpoint fakeplatform.Endpoint
+		want         fakeplatform.Fault
+		wantErr      string
+	}{
+		"should parse all the keys": {
+			fault:        "tasks,status=503,latency=2s,count=3",
+			wantEndpoint: fakeplatform.EndpointTasks,
+			want:         fakeplatform.Fault{StatusCode: 503, Latency: 2 * time.Second, Count: 3},
+		},
+		"should fail on unknown keys": {
+			fault:   "tasks,delay=1s",
+			wantErr: "invalid fault \"tasks,delay=1s\": unknown key \"delay\"",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			endpoint, fault, err := fakeplatform.ParseFault(tt.fault)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, endpoint)
+			assert.Equal(t, tt.want, fault)
+		})
+	}
+}
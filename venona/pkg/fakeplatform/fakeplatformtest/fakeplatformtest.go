@@ -0,0 +1,45 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeplatformtest starts a fake platform in tests, kept apart from fakeplatform so the testing package
+// is not linked into the agent
+package fakeplatformtest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/fakeplatform"
+)
+
+// NewServer starts a Platform on a local port, both are closed at the end of the test.
+// The returned client is configured for the agent of the platform
+func NewServer(tb testing.TB, opts fakeplatform.Options) (*fakeplatform.Platform, codefresh.Codefresh) {
+	tb.Helper()
+	p := fakeplatform.New(opts)
+	server := httptest.NewServer(p)
+	tb.Cleanup(server.Close)
+	agentID := opts.AgentID
+	if agentID == "" {
+		agentID = "fake-agent"
+	}
+
+	return p, codefresh.New(codefresh.Options{
+		Host:       server.URL,
+		Token:      opts.Token,
+		AgentID:    agentID,
+		HTTPClient: server.Client(),
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeplatform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"sigs.k8s.io/yaml"
+)
+
+type (
+	// Script feeds the platform with batches of workflows over time
+	Script struct {
+		Batches []Batch `json:"batches"`
+	}
+
+	// Batch of workflows that are added together
+	Batch struct {
+		// After is the delay since the previous batch, or since the script started
+		After     Duration   `json:"after"`
+		Workflows []Workflow `json:"workflows"`
+	}
+
+	// Workflow is a group of tasks of the same workflow and runtime
+	Workflow struct {
+		ID      string     `json:"id"`
+		Runtime string     `json:"runtime"`
+		Tasks   task.Tasks `json:"tasks"`
+	}
+
+	// Duration is a time.Duration that is written as a string, e.g. "1.5s"
+	Duration time.Duration
+)
+
+// LoadScript reads a script from a YAML or JSON file
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	script := &Script{}
+	if err := yaml.Unmarshal(data, script); err != nil {
+		return nil, fmt.Errorf("failed parsing script \"%s\": %w", path, err)
+	}
+
+	return script, nil
+}
+
+// Play adds the batches of the script to the platform at their time, it returns when the script is done or ctx is cancelled
+func (p *Platform) Play(ctx context.Context, script *Script) {
+	for i, batch := range script.Batches {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(batch.After)):
+		}
+
+		for _, wf := range batch.Workflows {
+			p.AddTasks(wf.Build()...)
+		}
+
+		p.log.Info("added scripted batch", "batch", i, "workflows", len(batch.Workflows))
+	}
+}
+
+// Build returns the tasks of the workflow, with the metadata filled the way the platform does.
+// Ids that are not set are generated from the workflow id
+func (wf Workflow) Build() task.Tasks {
+	createdAt := time.Now().Format(time.RFC3339)
+	tasks := make(task.Tasks, 0, len(wf.Tasks))
+	for i, t := range wf.Tasks {
+		if t.Id == "" {
+			t.Id = fmt.Sprintf("%s-%d", wf.ID, i)
+		}
+
+		t.Metadata.WorkflowId = wf.ID
+		t.Metadata.ReName = wf.Runtime
+		t.Metadata.ShouldReportStatus = true
+		if t.Metadata.CreatedAt == "" {
+			t.Metadata.CreatedAt = createdAt
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	return tasks
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+type (
+	// capacityWatcher signals the creations that exceeded a ResourceQuota whenever the quotas or the pods of their
+	// namespace change, which might have freed capacity. The informers of a namespace only run while there are waiters
+	capacityWatcher struct {
+		client     kubernetes.Interface
+		mutex      sync.Mutex
+		namespaces map[string]*namespaceWatch
+	}
+
+	namespaceWatch struct {
+		// changed is closed and replaced on every change
+		changed chan struct{}
+		waiters int
+		stop    chan struct{}
+	}
+
+	// quotaError is the error of a creation that exceeded a ResourceQuota of its namespace.
+	// It implements errors.WaitableError, the creation can be retried once there might be capacity for it
+	quotaError struct {
+		error
+		namespace string
+		watcher   *capacityWatcher
+	}
+)
+
+// capacityPollInterval bounds a single wait, in case a change that freed capacity was missed
+var capacityPollInterval = 30 * time.Second
+
+// isQuotaExceeded returns true for the Forbidden errors of the API server when a creation exceeds a ResourceQuota
+func isQuotaExceeded(err error) bool {
+	return k8serrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota")
+}
+
+func newCapacityWatcher(client kubernetes.Interface) *capacityWatcher {
+	return &capacityWatcher{
+		client:     client,
+		namespaces: map[string]*namespaceWatch{},
+	}
+}
+
+func (e *quotaError) Unwrap() error {
+	return e.error
+}
+
+// Wait blocks until the quotas or the pods of the namespace change, the poll interval passes, or ctx is done
+func (e *quotaError) Wait(ctx context.Context) error {
+	return e.watcher.wait(ctx, e.namespace)
+}
+
+func (c *capacityWatcher) wait(ctx context.Context, namespace string) error {
+	changed := c.subscribe(namespace)
+	defer c.unsubscribe(namespace)
+
+	timer := time.NewTimer(capacityPollInterval)
+	defer timer.Stop()
+	select {
+	case <-changed:
+		return nil
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// subscribe returns the channel of the next change in the namespace, starting its informers for the first waiter
+func (c *capacityWatcher) subscribe(namespace string) <-chan struct{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w, ok := c.namespaces[namespace]
+	if !ok {
+		w = &namespaceWatch{
+			changed: make(chan struct{}),
+			stop:    make(chan struct{}),
+		}
+		c.namespaces[namespace] = w
+		c.startInformers(namespace, w.stop)
+	}
+
+	w.waiters++
+	return w.changed
+}
+
+// unsubscribe stops the informers of the namespace after its last waiter
+func (c *capacityWatcher) unsubscribe(namespace string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w, ok := c.namespaces[namespace]
+	if !ok {
+		return
+	}
+
+	w.waiters--
+	if w.waiters == 0 {
+		close(w.stop)
+		delete(c.namespaces, namespace)
+	}
+}
+
+func (c *capacityWatcher) notify(namespace string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if w, ok := c.namespaces[namespace]; ok {
+		close(w.changed)
+		w.changed = make(chan struct{})
+	}
+}
+
+// startInformers notifies on every change of a ResourceQuota, whose usage is updated by the quota controller,
+// and on every pod that is deleted or terminates, since a terminated pod stops counting against the quota.
+// The objects that already exist when the informers start are not changes
+func (c *capacityWatcher) startInformers(namespace string, stop chan struct{}) {
+	notify := func() { c.notify(namespace) }
+	handler := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(_ interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				notify()
+			}
+		},
+		UpdateFunc: func(_, _ interface{}) { notify() },
+		DeleteFunc: func(_ interface{}) { notify() },
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(c.client, 0, informers.WithNamespace(namespace))
+	_, _ = factory.Core().V1().ResourceQuotas().Informer().AddEventHandler(handler)
+	_, _ = factory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, _ := oldObj.(*v1.Pod)
+			newPod, _ := newObj.(*v1.Pod)
+			if oldPod != nil && newPod != nil && !isTerminated(oldPod) && isTerminated(newPod) {
+				notify()
+			}
+		},
+		DeleteFunc: handler.DeleteFunc,
+	})
+	factory.Start(stop)
+}
+
+func isTerminated(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
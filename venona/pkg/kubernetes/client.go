@@ -0,0 +1,79 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import "time"
+
+// ClientConfig of a runtime, in the client section of the runtime config. The options that are set override
+// the ones of the agent flags for the runtime
+type ClientConfig struct {
+	QPS            float32 `yaml:"qps,omitempty" json:"qps,omitempty"`
+	Burst          int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+	ForceDeletePvc *bool   `yaml:"forceDeletePvc,omitempty" json:"forceDeletePvc,omitempty"`
+	// RejectTLSUnauthorized verifies the certificate of the API server, false skips the verification
+	RejectTLSUnauthorized *bool         `yaml:"rejectTLSUnauthorized,omitempty" json:"rejectTLSUnauthorized,omitempty"`
+	Timeout               time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	UserAgent             string        `yaml:"userAgent,omitempty" json:"userAgent,omitempty"`
+	ProxyURL              string        `yaml:"proxyURL,omitempty" json:"proxyURL,omitempty"`
+	Impersonate           *Impersonate  `yaml:"impersonate,omitempty" json:"impersonate,omitempty"`
+}
+
+// Impersonate makes the requests of a runtime act as another user and groups
+type Impersonate struct {
+	User   string   `yaml:"user,omitempty" json:"user,omitempty"`
+	Groups []string `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// WithClientConfig returns a copy of the options, with the options that are set in the client config
+func (o Options) WithClientConfig(c *ClientConfig) Options {
+	if c == nil {
+		return o
+	}
+
+	if c.QPS > 0 {
+		o.QPS = c.QPS
+	}
+
+	if c.Burst > 0 {
+		o.Burst = c.Burst
+	}
+
+	if c.ForceDeletePvc != nil {
+		o.ForceDeletePvc = *c.ForceDeletePvc
+	}
+
+	if c.RejectTLSUnauthorized != nil {
+		o.Insecure = !*c.RejectTLSUnauthorized
+	}
+
+	if c.Timeout > 0 {
+		o.Timeout = c.Timeout
+	}
+
+	if c.UserAgent != "" {
+		o.UserAgent = c.UserAgent
+	}
+
+	if c.ProxyURL != "" {
+		o.ProxyURL = c.ProxyURL
+	}
+
+	if c.Impersonate != nil {
+		o.ImpersonateUser = c.Impersonate.User
+		o.ImpersonateGroups = c.Impersonate.Groups
+	}
+
+	return o
+}
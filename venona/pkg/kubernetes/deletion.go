@@ -0,0 +1,167 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ierrors "github.com/codefresh-io/go/venona/pkg/errors"
+	"github.com/codefresh-io/go/venona/pkg/metrics"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// the escalations of a deletion that is stuck terminating
+	escalationForce      = "force"
+	escalationFinalizers = "finalizers"
+
+	deletionWaitOperation = "deletion wait"
+)
+
+// DeletionConfig of a runtime, in the deletion section of the runtime config. The escalations only happen while
+// waiting, their timeouts are measured from the delete request
+type DeletionConfig struct {
+	// WaitTimeout waits for up to it until a deleted pod or PVC is gone, 0 does not wait. It runs within the
+	// DeletePod and DeletePvc task timeouts and must be shorter than them
+	WaitTimeout time.Duration `yaml:"waitTimeout,omitempty" json:"waitTimeout,omitempty"`
+	// ForceAfter deletes a pod that is still terminating after it again, with grace period 0. 0 never does
+	ForceAfter time.Duration `yaml:"forceAfter,omitempty" json:"forceAfter,omitempty"`
+	// RemoveFinalizersAfter removes the finalizers of a pod or PVC that is still terminating after it,
+	// e.g. the kubernetes.io/pvc-protection of a PVC that is still mounted. 0 never does
+	RemoveFinalizersAfter time.Duration `yaml:"removeFinalizersAfter,omitempty" json:"removeFinalizersAfter,omitempty"`
+}
+
+// CheckTaskTimeouts fails when the wait can outlast the deadline of the DeletePod or DeletePvc tasks. The wait runs
+// within that deadline, so the task would always time out before the deletion wait does
+func (c DeletionConfig) CheckTaskTimeouts(timeouts map[task.Type]time.Duration) error {
+	for _, t := range []task.Type{task.TypeDeletePod, task.TypeDeletePVC} {
+		if timeout := timeouts[t]; timeout > 0 && c.WaitTimeout >= timeout {
+			return fmt.Errorf("the deletion wait timeout %s must be shorter than the %s task timeout %s", c.WaitTimeout, t, timeout)
+		}
+	}
+
+	return nil
+}
+
+// deletionPollInterval is how often a deleted object is checked while waiting until it is gone
+var deletionPollInterval = time.Second
+
+// deleteOptions returns the options of the delete request of a task
+func (k kube) deleteOptions(opts DeleteOptions) metav1.DeleteOptions {
+	o := metav1.DeleteOptions{
+		DryRun:             k.dryRunOption(),
+		GracePeriodSeconds: opts.GracePeriodSeconds,
+	}
+	if opts.PropagationPolicy != "" {
+		policy := metav1.DeletionPropagation(opts.PropagationPolicy)
+		o.PropagationPolicy = &policy
+	}
+
+	return o
+}
+
+// waitForGone polls a deleted pod or PVC until it is gone, or replaced by an object with the same name that is not
+// being deleted. While it is stuck terminating, a pod is deleted again with grace period 0 after ForceAfter, and
+// the finalizers of the object are removed after RemoveFinalizersAfter. An object that is still there after
+// WaitTimeout fails the deletion with a retriable TimeoutError
+func (k kube) waitForGone(ctx context.Context, kind task.Type, namespace string, name string) error {
+	if k.deletion.WaitTimeout <= 0 || k.dryRun != nil {
+		return nil
+	}
+
+	start := time.Now()
+	forced, finalizersRemoved := false, false
+	ticker := time.NewTicker(deletionPollInterval)
+	defer ticker.Stop()
+	for {
+		obj, err := k.getObject(ctx, kind, namespace, name)
+		if k8serrors.IsNotFound(err) || (err == nil && obj.GetDeletionTimestamp() == nil) {
+			return nil
+		}
+
+		elapsed := time.Since(start)
+		if err != nil {
+			k.log.Warn("Failed checking deleted resource", "type", kind, "namespace", namespace, "name", name, "error", err)
+		} else {
+			if kind == task.TypeDeletePod && k.deletion.ForceAfter > 0 && elapsed >= k.deletion.ForceAfter && !forced {
+				forced = true
+				k.forceDelete(ctx, namespace, name)
+			}
+
+			if k.deletion.RemoveFinalizersAfter > 0 && elapsed >= k.deletion.RemoveFinalizersAfter && !finalizersRemoved && len(obj.GetFinalizers()) > 0 {
+				finalizersRemoved = true
+				k.removeFinalizers(ctx, kind, namespace, name, obj.GetFinalizers())
+			}
+		}
+
+		if elapsed >= k.deletion.WaitTimeout {
+			metrics.IncStuckDeletions(kind)
+			k.log.Warn("Deleted resource is stuck terminating", "type", kind, "namespace", namespace, "name", name, "wait", elapsed)
+			return &K8sError{
+				error: &ierrors.TimeoutError{
+					Operation: deletionWaitOperation,
+					Timeout:   k.deletion.WaitTimeout,
+					Err:       fmt.Errorf("\"%s\\%s\" is still terminating", namespace, name),
+				},
+				isRetriable: true,
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return NewK8sError(fmt.Errorf("failed waiting for \"%s\\%s\" to be deleted: %w", namespace, name, ctx.Err()), TypeK8sDeleteResource)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k kube) getObject(ctx context.Context, kind task.Type, namespace string, name string) (metav1.Object, error) {
+	if kind == task.TypeDeletePod {
+		return k.client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+
+	return k.client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (k kube) forceDelete(ctx context.Context, namespace string, name string) {
+	metrics.IncDeletionEscalations(task.TypeDeletePod, escalationForce)
+	k.log.Warn("Force deleting pod that is stuck terminating", "namespace", namespace, "name", name)
+	grace := int64(0)
+	err := k.client.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: &grace})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		k.log.Error("Failed force deleting pod", "namespace", namespace, "name", name, "error", err)
+	}
+}
+
+func (k kube) removeFinalizers(ctx context.Context, kind task.Type, namespace string, name string, finalizers []string) {
+	metrics.IncDeletionEscalations(kind, escalationFinalizers)
+	k.log.Warn("Removing finalizers of resource that is stuck terminating", "type", kind, "namespace", namespace, "name", name, "finalizers", finalizers)
+	var err error
+	if kind == task.TypeDeletePod {
+		_, err = k.client.CoreV1().Pods(namespace).Patch(ctx, name, types.JSONPatchType, removeFinalizersJSONPatch, metav1.PatchOptions{})
+	} else {
+		_, err = k.client.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, types.JSONPatchType, removeFinalizersJSONPatch, metav1.PatchOptions{})
+	}
+
+	if err != nil && !k8serrors.IsNotFound(err) {
+		k.log.Error("Failed removing finalizers", "type", kind, "namespace", namespace, "name", name, "error", err)
+	}
+}
@@ -19,19 +19,31 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/codefresh-io/go/venona/pkg/admission"
+	"github.com/codefresh-io/go/venona/pkg/dryrun"
 	"github.com/codefresh-io/go/venona/pkg/logger"
 	"github.com/codefresh-io/go/venona/pkg/metrics"
+	"github.com/codefresh-io/go/venona/pkg/monitoring"
+	"github.com/codefresh-io/go/venona/pkg/mutation"
+	"github.com/codefresh-io/go/venona/pkg/pvcpool"
+	"github.com/codefresh-io/go/venona/pkg/registry"
 	"github.com/codefresh-io/go/venona/pkg/task"
 
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 const (
@@ -57,6 +69,30 @@ type (
 		QPS            float32
 		Burst          int
 		ForceDeletePvc bool
+		// Timeout of a single request to the API server, 0 means no timeout
+		Timeout time.Duration
+		// UserAgent of the requests, the default of client-go when empty
+		UserAgent string
+		// ProxyURL of the requests, the proxy of the environment when empty
+		ProxyURL string
+		// ImpersonateUser and ImpersonateGroups make the requests act as another user, optional
+		ImpersonateUser   string
+		ImpersonateGroups []string
+		Monitor           monitoring.Monitor
+		// DryRun sends all the requests with server-side dry run, and adds them to the summary
+		DryRun *dryrun.Summary
+		// MutationPolicy patches the pods and PVCs before they are created. Optional
+		MutationPolicy *mutation.Policy
+		// Registry rewrites the images of the pods after they are mutated, and adds pull secrets to them. Optional
+		Registry *registry.Rewriter
+		// AdmissionPolicy validates the pods after they are mutated and their images are rewritten,
+		// before they are created. Optional
+		AdmissionPolicy *admission.Policy
+		// PVCPool serves the CreatePvc tasks that match it with pre-provisioned PVCs, the pods and the DeletePvc tasks
+		// that refer to their PVCs are pointed to the pooled ones. Optional
+		PVCPool *pvcpool.Pool
+		// Deletion waits until deleted pods and PVCs are gone, and escalates the ones that are stuck terminating
+		Deletion DeletionConfig
 	}
 
 	// DeleteOptions to delete resource from the cluster
@@ -64,12 +100,24 @@ type (
 		Name      string
 		Namespace string
 		Kind      task.Type
+		// GracePeriodSeconds overrides the grace period of the object, optional
+		GracePeriodSeconds *int64
+		// PropagationPolicy is either Orphan, Background or Foreground, the default of the object when empty
+		PropagationPolicy string
 	}
 
 	kube struct {
-		client         kubernetes.Interface
-		log            logger.Logger
-		forceDeletePvc bool
+		client          kubernetes.Interface
+		log             logger.Logger
+		forceDeletePvc  bool
+		monitor         monitoring.Monitor
+		dryRun          *dryrun.Summary
+		mutationPolicy  *mutation.Policy
+		admissionPolicy *admission.Policy
+		registry        *registry.Rewriter
+		capacity        *capacityWatcher
+		pvcPool         *pvcpool.Pool
+		deletion        DeletionConfig
 	}
 
 	K8sOperation string
@@ -80,6 +128,8 @@ type (
 	}
 )
 
+const traceContextAnnotationPrefix = "codefresh.io/"
+
 var (
 	errNotValidType           = errors.New("not a valid type")
 	kubeDecode                = scheme.Codecs.UniversalDeserializer().Decode
@@ -90,9 +140,15 @@ func (e K8sError) IsRetriable() bool {
 	return e.isRetriable
 }
 
+func (e K8sError) Unwrap() error {
+	return e.error
+}
+
+// NewK8sError marks the error of an operation as retriable unless the API server would fail it again.
+// A creation that exceeded a ResourceQuota is retriable, it might succeed once the quota frees
 func NewK8sError(err error, operation K8sOperation) error {
 	isNotRetriable := k8serrors.IsBadRequest(err) ||
-		k8serrors.IsForbidden(err) ||
+		(k8serrors.IsForbidden(err) && !isQuotaExceeded(err)) ||
 		k8serrors.IsMethodNotSupported(err) ||
 		k8serrors.IsRequestEntityTooLargeError(err) ||
 		k8serrors.IsNotAcceptable(err) ||
@@ -107,13 +163,22 @@ func NewK8sError(err error, operation K8sOperation) error {
 	}
 }
 
-// NewInCluster build Kubernetes API based on local in cluster runtime
-func NewInCluster(log logger.Logger, qps float32, burst int, forceDeletePvc bool) (Kubernetes, error) {
-	client, err := buildKubeInCluster(qps, burst)
+// NewInCluster build Kubernetes API based on local in cluster runtime,
+// connection options (Type, Host, Token, Cert, Insecure) are ignored
+func NewInCluster(opts Options) (Kubernetes, error) {
+	client, err := buildKubeInCluster(opts)
 	return &kube{
-		client:         client,
-		log:            log,
-		forceDeletePvc: forceDeletePvc,
+		client:          client,
+		log:             opts.Logger,
+		forceDeletePvc:  opts.ForceDeletePvc,
+		monitor:         opts.Monitor,
+		dryRun:          opts.DryRun,
+		mutationPolicy:  opts.MutationPolicy,
+		admissionPolicy: opts.AdmissionPolicy,
+		registry:        opts.Registry,
+		capacity:        newCapacityWatcher(client),
+		pvcPool:         opts.PVCPool,
+		deletion:        opts.Deletion,
 	}, err
 }
 
@@ -123,39 +188,95 @@ func New(opts Options) (Kubernetes, error) {
 		return nil, errNotValidType
 	}
 
-	client, err := buildKubeClient(opts.Host, opts.Token, opts.Cert, opts.Insecure, opts.QPS, opts.Burst)
+	client, err := buildKubeClient(opts)
 	return &kube{
-		client:         client,
-		log:            opts.Logger,
-		forceDeletePvc: opts.ForceDeletePvc,
+		client:          client,
+		log:             opts.Logger,
+		forceDeletePvc:  opts.ForceDeletePvc,
+		monitor:         opts.Monitor,
+		dryRun:          opts.DryRun,
+		mutationPolicy:  opts.MutationPolicy,
+		admissionPolicy: opts.AdmissionPolicy,
+		registry:        opts.Registry,
+		capacity:        newCapacityWatcher(client),
+		pvcPool:         opts.PVCPool,
+		deletion:        opts.Deletion,
 	}, err
 }
 
+// NewClient builds the client of a remote runtime, with the same connection options as New
+func NewClient(opts Options) (kubernetes.Interface, error) {
+	if opts.Type != "runtime" {
+		return nil, errNotValidType
+	}
+
+	return buildKubeClient(opts)
+}
+
+// NewInClusterClient builds the client of the in-cluster runtime, with the same connection options as NewInCluster
+func NewInClusterClient(opts Options) (kubernetes.Interface, error) {
+	return buildKubeInCluster(opts)
+}
+
+// NewForClient build Kubernetes API on top of an existing client,
+// connection and client options (Type, Host, Token, Cert, Insecure, QPS, Burst, Timeout, UserAgent, ProxyURL,
+// ImpersonateUser, ImpersonateGroups) are ignored
+func NewForClient(client kubernetes.Interface, opts Options) Kubernetes {
+	return &kube{
+		client:          client,
+		log:             opts.Logger,
+		forceDeletePvc:  opts.ForceDeletePvc,
+		monitor:         opts.Monitor,
+		dryRun:          opts.DryRun,
+		mutationPolicy:  opts.MutationPolicy,
+		admissionPolicy: opts.AdmissionPolicy,
+		registry:        opts.Registry,
+		capacity:        newCapacityWatcher(client),
+		pvcPool:         opts.PVCPool,
+		deletion:        opts.Deletion,
+	}
+}
+
 func (k kube) CreateResource(ctx context.Context, taskType task.Type, spec interface{}) error {
 	start := time.Now()
-	bytes, err := json.Marshal(spec)
+	obj, err := decodeObject(spec)
 	if err != nil {
-		return NewK8sError(fmt.Errorf("failed marshalling when creating resource: %w", err), TypeK8sCreateResource)
+		return err
 	}
 
-	obj, _, err := kubeDecode(bytes, nil, nil)
-	if err != nil {
-		return NewK8sError(fmt.Errorf("failed decoding when creating resource: %w", err), TypeK8sCreateResource)
+	if err := k.mutate(ctx, taskType, obj); err != nil {
+		return err
+	}
+
+	if pod, ok := obj.(*v1.Pod); ok {
+		k.rewriteImages(ctx, pod)
+		k.rewriteClaims(ctx, pod)
+	}
+
+	if err := k.admit(ctx, taskType, obj); err != nil {
+		return err
 	}
 
 	var namespace, name string
 	switch obj := obj.(type) {
 	case *v1.PersistentVolumeClaim:
 		namespace, name = obj.Namespace, obj.Name
-		_, err = k.client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, obj, metav1.CreateOptions{})
+		if k.claimPooled(ctx, obj) {
+			break
+		}
+
+		_, err = k.client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, obj, metav1.CreateOptions{DryRun: k.dryRunOption()})
+		k.addDryRun(taskType, namespace, name, err)
 		if err != nil {
-			return NewK8sError(fmt.Errorf("failed creating persistent volume claims \"%s\\%s\": %w", namespace, obj.Name, err), TypeK8sCreateResource)
+			return k.createError(namespace, fmt.Errorf("failed creating persistent volume claims \"%s\\%s\": %w", namespace, obj.Name, err))
 		}
 	case *v1.Pod:
 		namespace, name = obj.Namespace, obj.Name
-		_, err = k.client.CoreV1().Pods(namespace).Create(ctx, obj, metav1.CreateOptions{})
+		k.injectTraceContext(ctx, obj)
+		_, err = k.client.CoreV1().Pods(namespace).Create(ctx, obj, metav1.CreateOptions{DryRun: k.dryRunOption()})
+		k.addDryRun(taskType, namespace, name, err)
 		if err != nil {
-			return NewK8sError(fmt.Errorf("failed creating pod \"%s\\%s\": %w", namespace, obj.Name, err), TypeK8sCreateResource)
+			return k.createError(namespace, fmt.Errorf("failed creating pod \"%s\\%s\": %w", namespace, obj.Name, err))
 		}
 
 		metrics.IncWorkflowRetries(name)
@@ -178,22 +299,41 @@ func (k kube) DeleteResource(ctx context.Context, opts DeleteOptions) error {
 	start := time.Now()
 	switch opts.Kind {
 	case task.TypeDeletePVC:
-		err := k.client.CoreV1().PersistentVolumeClaims(opts.Namespace).Delete(ctx, opts.Name, metav1.DeleteOptions{})
+		name := opts.Name
+		if pooled, ok := k.pvcPool.Resolve(opts.Namespace, opts.Name); ok {
+			name = pooled
+		}
+
+		err := k.client.CoreV1().PersistentVolumeClaims(opts.Namespace).Delete(ctx, name, k.deleteOptions(opts))
+		k.addDryRun(opts.Kind, opts.Namespace, name, err)
+		if err == nil || k8serrors.IsNotFound(err) {
+			k.pvcPool.Release(opts.Namespace, opts.Name)
+		}
+
 		if err != nil {
 			return NewK8sError(fmt.Errorf("failed deleting persistent volume claim \"%s\\%s\": %w", opts.Namespace, opts.Name, err), TypeK8sDeleteResource)
 		}
 
 		if k.forceDeletePvc {
-			_, err := k.client.CoreV1().PersistentVolumeClaims(opts.Namespace).Patch(ctx, opts.Name, types.JSONPatchType, removeFinalizersJSONPatch, metav1.PatchOptions{})
+			_, err := k.client.CoreV1().PersistentVolumeClaims(opts.Namespace).Patch(ctx, name, types.JSONPatchType, removeFinalizersJSONPatch, metav1.PatchOptions{DryRun: k.dryRunOption()})
 			if err != nil {
-				return NewK8sError(fmt.Errorf("failed removing finalizers from PVC \"%s\\%s\": %w", opts.Namespace, opts.Name, err), TypeK8sDeleteResource)
+				return NewK8sError(fmt.Errorf("failed removing finalizers from PVC \"%s\\%s\": %w", opts.Namespace, name, err), TypeK8sDeleteResource)
 			}
 		}
+
+		if err := k.waitForGone(ctx, opts.Kind, opts.Namespace, name); err != nil {
+			return err
+		}
 	case task.TypeDeletePod:
-		err := k.client.CoreV1().Pods(opts.Namespace).Delete(ctx, opts.Name, metav1.DeleteOptions{})
+		err := k.client.CoreV1().Pods(opts.Namespace).Delete(ctx, opts.Name, k.deleteOptions(opts))
+		k.addDryRun(opts.Kind, opts.Namespace, opts.Name, err)
 		if err != nil {
 			return NewK8sError(fmt.Errorf("failed deleting pod \"%s\\%s\": %w", opts.Namespace, opts.Name, err), TypeK8sDeleteResource)
 		}
+
+		if err := k.waitForGone(ctx, opts.Kind, opts.Namespace, opts.Name); err != nil {
+			return err
+		}
 	default:
 		return NewK8sError(fmt.Errorf("failed deleting resource of type %s", opts.Kind), TypeK8sDeleteResource)
 	}
@@ -209,34 +349,299 @@ func (k kube) DeleteResource(ctx context.Context, opts DeleteOptions) error {
 	return nil
 }
 
-func buildKubeClient(host string, token string, crt string, insecure bool, qps float32, burst int) (kubernetes.Interface, error) {
+// createError returns the error of a failed creation. A creation that exceeded a ResourceQuota can be waited on
+// until the quotas or the pods of its namespace change, see errors.WaitableError
+func (k kube) createError(namespace string, err error) error {
+	if !isQuotaExceeded(err) || k.capacity == nil || k.dryRun != nil {
+		return NewK8sError(err, TypeK8sCreateResource)
+	}
+
+	return &K8sError{
+		error: &quotaError{
+			error:     err,
+			namespace: namespace,
+			watcher:   k.capacity,
+		},
+		isRetriable: true,
+	}
+}
+
+// decodeObject returns the object of a create task, the spec is decoded only when it is not typed already.
+// A typed spec is copied, the object is mutated before it is created and the task may be handled again
+func decodeObject(spec interface{}) (k8sruntime.Object, error) {
+	if obj, ok := spec.(k8sruntime.Object); ok {
+		return obj.DeepCopyObject(), nil
+	}
+
+	bytes, err := json.Marshal(spec)
+	if err != nil {
+		return nil, NewK8sError(fmt.Errorf("failed marshalling when creating resource: %w", err), TypeK8sCreateResource)
+	}
+
+	obj, _, err := kubeDecode(bytes, nil, nil)
+	if err != nil {
+		return nil, NewK8sError(fmt.Errorf("failed decoding when creating resource: %w", err), TypeK8sCreateResource)
+	}
+
+	return obj, nil
+}
+
+// mutate applies the mutation policy to the object, a failure is not retriable since the policy would fail again
+func (k kube) mutate(ctx context.Context, taskType task.Type, obj k8sruntime.Object) error {
+	applied, err := k.mutationPolicy.Apply(obj)
+	if err != nil {
+		return &K8sError{
+			error:       fmt.Errorf("failed mutating resource: %w", err),
+			isRetriable: false,
+		}
+	}
+
+	if o, ok := obj.(metav1.Object); ok && len(applied) > 0 {
+		m, _ := task.MetadataFromContext(ctx)
+		k.log.Info("Applied mutation policy", "type", taskType, "workflow", m.WorkflowId, "namespace", o.GetNamespace(), "name", o.GetName(), "rules", applied)
+	}
+
+	return nil
+}
+
+// rewriteImages points the images of the pod to the registry of the runtime, and adds its pull secrets
+func (k kube) rewriteImages(ctx context.Context, pod *v1.Pod) {
+	changes := k.registry.Apply(pod)
+	if len(changes) == 0 {
+		return
+	}
+
+	images := make([]string, 0, len(changes))
+	for _, c := range changes {
+		images = append(images, c.String())
+	}
+
+	m, _ := task.MetadataFromContext(ctx)
+	k.log.Info("Rewrote pod images", "workflow", m.WorkflowId, "namespace", pod.Namespace, "name", pod.Name, "images", images)
+}
+
+// claimPooled binds a pooled PVC to the PVC of a CreatePvc task that matches the PVC pool, instead of creating it.
+// It returns false when the PVC should be created, also when the pool fails, since the pool only saves time
+func (k kube) claimPooled(ctx context.Context, pvc *v1.PersistentVolumeClaim) bool {
+	if k.pvcPool == nil || k.dryRun != nil {
+		return false
+	}
+
+	m, _ := task.MetadataFromContext(ctx)
+	pooled, err := k.pvcPool.Claim(ctx, pvc, m.WorkflowId)
+	if err != nil {
+		k.log.Warn("Failed claiming pooled PVC, creating it instead", "workflow", m.WorkflowId, "namespace", pvc.Namespace, "name", pvc.Name, "error", err)
+		return false
+	}
+
+	if pooled == "" {
+		return false
+	}
+
+	k.log.Info("Claimed pooled PVC", "workflow", m.WorkflowId, "namespace", pvc.Namespace, "name", pvc.Name, "pooled", pooled)
+	return true
+}
+
+// rewriteClaims points the volumes of the pod whose PVCs were served by the PVC pool to the pooled PVCs
+func (k kube) rewriteClaims(ctx context.Context, pod *v1.Pod) {
+	for i := range pod.Spec.Volumes {
+		claim := pod.Spec.Volumes[i].PersistentVolumeClaim
+		if claim == nil {
+			continue
+		}
+
+		if pooled, ok := k.pvcPool.Resolve(pod.Namespace, claim.ClaimName); ok {
+			m, _ := task.MetadataFromContext(ctx)
+			k.log.Info("Rewrote pod volume to pooled PVC", "workflow", m.WorkflowId, "namespace", pod.Namespace, "name", pod.Name, "volume", pod.Spec.Volumes[i].Name, "claim", claim.ClaimName, "pooled", pooled)
+			claim.ClaimName = pooled
+		}
+	}
+}
+
+// admit validates the object against the admission policy. Violations deny the object with a non-retriable error,
+// unless the policy is in audit mode
+func (k kube) admit(ctx context.Context, taskType task.Type, obj k8sruntime.Object) error {
+	violations := k.admissionPolicy.Validate(obj)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	mode := admission.ModeEnforce
+	if k.admissionPolicy.Audit() {
+		mode = admission.ModeAudit
+	}
+
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		metrics.IncAdmissionViolations(v.Rule, string(mode))
+		msgs = append(msgs, v.String())
+	}
+
+	m, _ := task.MetadataFromContext(ctx)
+	o, _ := obj.(metav1.Object)
+	k.log.Warn("Resource violates the admission policy", "type", taskType, "workflow", m.WorkflowId, "namespace", o.GetNamespace(), "name", o.GetName(), "mode", mode, "violations", msgs)
+	if mode == admission.ModeAudit {
+		return nil
+	}
+
+	return &K8sError{
+		error:       &admission.Error{Violations: violations},
+		isRetriable: false,
+	}
+}
+
+// dryRunOption returns the dry run option of the requests, which is empty unless the agent runs with --dry-run
+func (k kube) dryRunOption() []string {
+	if k.dryRun == nil {
+		return nil
+	}
+
+	return []string{metav1.DryRunAll}
+}
+
+func (k kube) addDryRun(taskType task.Type, namespace string, name string, err error) {
+	if k.dryRun == nil {
+		return
+	}
+
+	a := dryrun.Action{
+		Type:      string(taskType),
+		Namespace: namespace,
+		Name:      name,
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	k.dryRun.Add(a)
+}
+
+// injectTraceContext passes the trace-context of the current transaction to the pod, both as annotations
+// and as TRACEPARENT/TRACESTATE env vars, so the engine can continue the workflow trace
+func (k kube) injectTraceContext(ctx context.Context, pod *v1.Pod) {
+	if k.monitor == nil {
+		return
+	}
+
+	carrier := map[string]string{}
+	k.monitor.NewTransactionFromContext(ctx).InjectTraceContext(carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pod.Annotations[traceContextAnnotationPrefix+key] = carrier[key]
+	}
+
+	for i := range pod.Spec.InitContainers {
+		addTraceContextEnv(&pod.Spec.InitContainers[i], keys, carrier)
+	}
+
+	for i := range pod.Spec.Containers {
+		addTraceContextEnv(&pod.Spec.Containers[i], keys, carrier)
+	}
+}
+
+func addTraceContextEnv(container *v1.Container, keys []string, carrier map[string]string) {
+	existing := map[string]struct{}{}
+	for _, env := range container.Env {
+		existing[env.Name] = struct{}{}
+	}
+
+	for _, key := range keys {
+		name := strings.ToUpper(key)
+		if _, ok := existing[name]; ok {
+			// never override a value that was set explicitly in the spec
+			continue
+		}
+
+		container.Env = append(container.Env, v1.EnvVar{Name: name, Value: carrier[key]})
+	}
+}
+
+func buildKubeClient(opts Options) (kubernetes.Interface, error) {
 	var tlsconf rest.TLSClientConfig
-	if insecure {
+	if opts.Insecure {
 		tlsconf = rest.TLSClientConfig{
 			Insecure: true,
 		}
 	} else {
 		tlsconf = rest.TLSClientConfig{
-			CAData: []byte(crt),
+			CAData: []byte(opts.Cert),
 		}
 	}
 
-	return kubernetes.NewForConfig(&rest.Config{
-		Host:            host,
-		BearerToken:     token,
+	config := &rest.Config{
+		Host:            opts.Host,
+		BearerToken:     opts.Token,
 		TLSClientConfig: tlsconf,
-		QPS:             qps,
-		Burst:           burst,
-	})
+	}
+	if err := configureClient(config, opts); err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
 }
 
-func buildKubeInCluster(qps float32, burst int) (kubernetes.Interface, error) {
+func buildKubeInCluster(opts Options) (kubernetes.Interface, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	config.QPS = qps
-	config.Burst = burst
+	if err := configureClient(config, opts); err != nil {
+		return nil, err
+	}
+
 	return kubernetes.NewForConfig(config)
 }
+
+// configureClient applies the client options to the config. Every client gets a rate limiter of its own,
+// so a runtime whose cluster throttles the agent does not slow the requests to the other runtimes
+func configureClient(config *rest.Config, opts Options) error {
+	config.QPS = opts.QPS
+	config.Burst = opts.Burst
+	if opts.QPS > 0 {
+		config.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(opts.QPS, opts.Burst)
+	}
+
+	config.Timeout = opts.Timeout
+	if opts.UserAgent != "" {
+		config.UserAgent = opts.UserAgent
+	}
+
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("failed parsing proxy url: %w", err)
+		}
+
+		config.Proxy = http.ProxyURL(proxy)
+	}
+
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: opts.ImpersonateUser,
+		Groups:   opts.ImpersonateGroups,
+	}
+	wrapTransport(config, opts.Monitor)
+	return nil
+}
+
+// wrapTransport instruments each request to the API server as a segment of the transaction in the request context
+func wrapTransport(config *rest.Config, monitor monitoring.Monitor) {
+	if monitor == nil {
+		return
+	}
+
+	config.Wrap(monitor.NewRoundTripper)
+}
@@ -0,0 +1,352 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/config"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+
+	"gopkg.in/yaml.v2"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultServiceAccountName is the service account the runner uses in a remote cluster
+	DefaultServiceAccountName = "codefresh-runtime-user"
+
+	runtimeConfigType       = "runtime"
+	defaultTokenWaitTimeout = time.Minute
+)
+
+type (
+	// AddClusterOptions for preparing a remote cluster to run workflows
+	AddClusterOptions struct {
+		// RuntimeName is the name of the runtime environment the config is used for
+		RuntimeName string
+		// Namespace in the remote cluster where the workflows run
+		Namespace string
+		// Host and CAData of the remote cluster API server, as the runner reaches it
+		Host   string
+		CAData []byte
+		// ServiceAccountName is created in Namespace (default codefresh-runtime-user)
+		ServiceAccountName string
+		// TokenWaitTimeout is how long to wait for the service account token to be issued (default 1m)
+		TokenWaitTimeout time.Duration
+		Logger           logger.Logger
+	}
+
+	// accessCheck is a permission the runner needs in the remote namespace
+	accessCheck struct {
+		resource string
+		verb     string
+	}
+)
+
+var (
+	runtimeRules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "persistentvolumeclaims"},
+			Verbs:     []string{"get", "create", "delete", "patch"},
+		},
+		{
+			// the capacity watcher waits for quota to be freed
+			APIGroups: []string{""},
+			Resources: []string{"pods", "resourcequotas"},
+			Verbs:     []string{"list", "watch"},
+		},
+		{
+			// the pvc pool finds and claims its warm volumes
+			APIGroups: []string{""},
+			Resources: []string{"persistentvolumeclaims"},
+			Verbs:     []string{"list", "update"},
+		},
+	}
+
+	tokenPollInterval = time.Second
+	// newClientForConfig builds a client with the generated credentials, to verify them
+	newClientForConfig = func(cnf config.Config) (kubernetes.Interface, error) {
+		return kubernetes.NewForConfig(&rest.Config{
+			Host:            cnf.Host,
+			BearerToken:     cnf.Token,
+			TLSClientConfig: rest.TLSClientConfig{CAData: []byte(cnf.Cert)},
+		})
+	}
+
+	invalidFileNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+	errRuntimeNameRequired = errors.New("RuntimeName options is required")
+	errHostRequired        = errors.New("Host options is required")
+)
+
+// AddCluster creates the service account, RBAC and token the runner needs in the remote cluster,
+// verifies the token has the required access, and returns the runtime config to mount into the runner.
+// Running it again is safe, existing resources are updated
+func AddCluster(ctx context.Context, client kubernetes.Interface, opts AddClusterOptions) (*config.Config, error) {
+	if err := checkAddClusterOptions(&opts); err != nil {
+		return nil, err
+	}
+
+	log := opts.Logger
+	if err := ensureNamespace(ctx, client, opts.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := ensureServiceAccount(ctx, client, opts.Namespace, opts.ServiceAccountName); err != nil {
+		return nil, err
+	}
+
+	if err := ensureRBAC(ctx, client, opts.Namespace, opts.ServiceAccountName); err != nil {
+		return nil, err
+	}
+
+	log.Info("created service account and RBAC", "namespace", opts.Namespace, "serviceAccount", opts.ServiceAccountName)
+	token, err := ensureToken(ctx, client, opts.Namespace, opts.ServiceAccountName, opts.TokenWaitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	cnf := &config.Config{
+		Type:  runtimeConfigType,
+		Name:  opts.RuntimeName,
+		Host:  opts.Host,
+		Cert:  string(opts.CAData),
+		Token: token,
+	}
+	if err := verifyAccess(ctx, cnf, opts.Namespace); err != nil {
+		return nil, err
+	}
+
+	log.Info("verified access to the cluster", "host", opts.Host, "namespace", opts.Namespace)
+	return cnf, nil
+}
+
+// RuntimeConfigFileName is the name of the runtime config file, matched by the runner config loader
+func RuntimeConfigFileName(runtimeName string) string {
+	name := strings.Trim(invalidFileNameChars.ReplaceAllString(strings.ToLower(runtimeName), "-"), "-")
+	return fmt.Sprintf("%s.runtime.yaml", name)
+}
+
+// MarshalRuntimeConfig returns the runtime config as it is read by the runner
+func MarshalRuntimeConfig(cnf *config.Config) ([]byte, error) {
+	return yaml.Marshal(cnf)
+}
+
+// MarshalRuntimeConfigSecret returns a Secret manifest holding the runtime config, to be mounted as the runner config dir
+func MarshalRuntimeConfigSecret(cnf *config.Config, name, namespace string) ([]byte, error) {
+	data, err := MarshalRuntimeConfig(cnf)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: v1.SecretTypeOpaque,
+		StringData: map[string]string{
+			RuntimeConfigFileName(cnf.Name): string(data),
+		},
+	}
+	return sigsyaml.Marshal(secret)
+}
+
+func ensureNamespace(ctx context.Context, client kubernetes.Interface, name string) error {
+	_, err := client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+	}
+
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed creating namespace \"%s\": %w", name, err)
+	}
+
+	return nil
+}
+
+func ensureServiceAccount(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	_, err := client.CoreV1().ServiceAccounts(namespace).Create(ctx, sa, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed creating service account \"%s\": %w", name, err)
+	}
+
+	return nil
+}
+
+func ensureRBAC(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	roles := client.RbacV1().Roles(namespace)
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Rules:      runtimeRules,
+	}
+	if _, err := roles.Create(ctx, role, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+		if _, err := roles.Update(ctx, role, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed updating role \"%s\": %w", name, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed creating role \"%s\": %w", name, err)
+	}
+
+	bindings := client.RbacV1().RoleBindings(namespace)
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+	}
+	if _, err := bindings.Create(ctx, binding, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+		if _, err := bindings.Update(ctx, binding, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed updating role binding \"%s\": %w", name, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed creating role binding \"%s\": %w", name, err)
+	}
+
+	return nil
+}
+
+// ensureToken creates a long-lived token secret for the service account, and waits for the token controller to fill it
+func ensureToken(ctx context.Context, client kubernetes.Interface, namespace, serviceAccount string, timeout time.Duration) (string, error) {
+	name := serviceAccount + "-token"
+	secrets := client.CoreV1().Secrets(namespace)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{v1.ServiceAccountNameKey: serviceAccount},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed creating token secret \"%s\": %w", name, err)
+	}
+
+	var token string
+	err := wait.PollUntilContextTimeout(ctx, tokenPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		s, err := secrets.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		token = string(s.Data[v1.ServiceAccountTokenKey])
+		return token != "", nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed waiting for the token of service account \"%s\": %w", serviceAccount, err)
+	}
+
+	return token, nil
+}
+
+// verifyAccess checks that the generated credentials are allowed everything the runner does
+func verifyAccess(ctx context.Context, cnf *config.Config, namespace string) error {
+	client, err := newClientForConfig(*cnf)
+	if err != nil {
+		return fmt.Errorf("failed creating client to verify access: %w", err)
+	}
+
+	return CheckAccess(ctx, client, namespace)
+}
+
+// CheckAccess verifies the client is allowed everything the runner does in the namespace
+func CheckAccess(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	var denied []string
+	for _, rule := range runtimeRules {
+		for _, resource := range rule.Resources {
+			for _, verb := range rule.Verbs {
+				review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Namespace: namespace,
+							Verb:      verb,
+							Resource:  resource,
+						},
+					},
+				}, metav1.CreateOptions{})
+				if err != nil {
+					return fmt.Errorf("failed reviewing access to %s %s: %w", verb, resource, err)
+				}
+
+				if !review.Status.Allowed {
+					denied = append(denied, fmt.Sprintf("%s %s", verb, resource))
+				}
+			}
+		}
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("the token is not allowed to: %s", strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+func checkAddClusterOptions(opts *AddClusterOptions) error {
+	if opts.RuntimeName == "" {
+		return errRuntimeNameRequired
+	}
+
+	if opts.Namespace == "" {
+		return errNamespaceRequired
+	}
+
+	if opts.Host == "" {
+		return errHostRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	if opts.ServiceAccountName == "" {
+		opts.ServiceAccountName = DefaultServiceAccountName
+	}
+
+	if opts.TokenWaitTimeout <= 0 {
+		opts.TokenWaitTimeout = defaultTokenWaitTimeout
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/config"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// issueTokens fills token secrets on creation, like the token controller does
+func issueTokens(client *fake.Clientset) {
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.CreateAction).GetObject().(*v1.Secret)
+		if secret.Type == v1.SecretTypeServiceAccountToken {
+			secret.Data = map[string][]byte{v1.ServiceAccountTokenKey: []byte("some-sa-token")}
+		}
+
+		return false, nil, nil
+	})
+}
+
+// fakeAccess returns a client that allows everything except the denied verbs
+func fakeAccess(denied ...string) func(config.Config) (kubernetes.Interface, error) {
+	return func(cnf config.Config) (kubernetes.Interface, error) {
+		client := fake.NewClientset()
+		client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			review.Status.Allowed = cnf.Token == "some-sa-token"
+			for _, verb := range denied {
+				if review.Spec.ResourceAttributes.Verb == verb {
+					review.Status.Allowed = false
+				}
+			}
+
+			return true, review, nil
+		})
+		return client, nil
+	}
+}
+
+func TestAddCluster(t *testing.T) {
+	tests := map[string]struct {
+		objects     []runtime.Object
+		issueTokens bool
+		denied      []string
+		want        *config.Config
+		wantErr     string
+	}{
+		"should create the service account and return the runtime config": {
+			issueTokens: true,
+			want: &config.Config{
+				Type:  "runtime",
+				Name:  "some-cluster/some-namespace",
+				Host:  "https://some-host",
+				Cert:  "some-ca",
+				Token: "some-sa-token",
+			},
+		},
+		"should reuse existing resources": {
+			issueTokens: true,
+			objects: []runtime.Object{
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: DefaultServiceAccountName, Namespace: namespace}},
+			},
+			want: &config.Config{
+				Type:  "runtime",
+				Name:  "some-cluster/some-namespace",
+				Host:  "https://some-host",
+				Cert:  "some-ca",
+				Token: "some-sa-token",
+			},
+		},
+		"should fail when the token is not issued": {
+			wantErr: "failed waiting for the token of service account \"codefresh-runtime-user\": context deadline exceeded",
+		},
+		"should fail when the token is missing permissions": {
+			issueTokens: true,
+			denied:      []string{"delete"},
+			wantErr:     "the token is not allowed to: delete pods, delete persistentvolumeclaims",
+		},
+		"should fail when the token can't watch for capacity": {
+			issueTokens: true,
+			denied:      []string{"watch"},
+			wantErr:     "the token is not allowed to: watch pods, watch resourcequotas",
+		},
+		"should fail when the token can't claim pooled volumes": {
+			issueTokens: true,
+			denied:      []string{"update"},
+			wantErr:     "the token is not allowed to: update persistentvolumeclaims",
+		},
+	}
+
+	origNewClient, origInterval := newClientForConfig, tokenPollInterval
+	defer func() { newClientForConfig, tokenPollInterval = origNewClient, origInterval }()
+	tokenPollInterval = time.Millisecond
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewClientset(tt.objects...)
+			if tt.issueTokens {
+				issueTokens(client)
+			}
+
+			newClientForConfig = fakeAccess(tt.denied...)
+			got, err := AddCluster(context.Background(), client, AddClusterOptions{
+				RuntimeName:      "some-cluster/some-namespace",
+				Namespace:        namespace,
+				Host:             "https://some-host",
+				CAData:           []byte("some-ca"),
+				TokenWaitTimeout: 50 * time.Millisecond,
+				Logger:           logger.New(logger.Options{}),
+			})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			binding, err := client.RbacV1().RoleBindings(namespace).Get(context.Background(), DefaultServiceAccountName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultServiceAccountName, binding.Subjects[0].Name)
+		})
+	}
+}
+
+func TestMarshalRuntimeConfigSecret(t *testing.T) {
+	cnf := &config.Config{
+		Type:  "runtime",
+		Name:  "Some-Cluster/some-namespace",
+		Host:  "https://some-host",
+		Cert:  "some-ca",
+		Token: "some-sa-token",
+	}
+	data, err := MarshalRuntimeConfigSecret(cnf, "runtime-configs", "runner")
+	assert.NoError(t, err)
+
+	secret := &v1.Secret{}
+	assert.NoError(t, sigsyaml.Unmarshal(data, secret))
+	assert.Equal(t, "Secret", secret.Kind)
+	assert.Equal(t, "runner", secret.Namespace)
+
+	content, ok := secret.StringData["some-cluster-some-namespace.runtime.yaml"]
+	assert.True(t, ok)
+	assert.Equal(t, "type: runtime\ncrt: some-ca\ntoken: some-sa-token\nhost: https://some-host\nname: Some-Cluster/some-namespace\n", content)
+}
@@ -0,0 +1,353 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AgentTokenKey is the key of the agent token in the agent secret
+	AgentTokenKey = "agent-codefresh-token"
+
+	internalLabel = "codefresh.io/internal"
+)
+
+type (
+	// Options for creating a new Lifecycle instance
+	Options struct {
+		Codefresh  codefresh.Admin
+		KubeClient kubernetes.Interface
+		Namespace  string
+		Logger     logger.Logger
+	}
+
+	// Lifecycle installs, updates and removes the runner and its runtime environments in the platform
+	Lifecycle struct {
+		cf        codefresh.Admin
+		client    kubernetes.Interface
+		namespace string
+		log       logger.Logger
+	}
+
+	// InitOptions for initializing the agent and its runtime environment
+	InitOptions struct {
+		AgentName   string
+		RuntimeName string
+		// ClusterName is the name of the cluster integration in the platform
+		ClusterName string
+		// OwnerName is the deployment that owns the agent secret, the secret is removed with it
+		OwnerName  string
+		SecretName string
+		// AgentToken is the token from an existing agent secret, when set only the runtime is attached
+		AgentToken string
+		// ExistingAgentToken is a token of an agent that was created in advance
+		ExistingAgentToken string
+		// SkipAttach only stores the existing agent token, when there is no codefresh token to attach the runtime with
+		SkipAttach bool
+	}
+
+	// CleanupOptions for removing the agent and its runtime environment
+	CleanupOptions struct {
+		AgentName   string
+		RuntimeName string
+		Agent       bool
+		SecretNames []string
+	}
+)
+
+var (
+	errOptionsRequired    = errors.New("Options are required")
+	errCodefreshRequired  = errors.New("Codefresh options is required")
+	errKubeClientRequired = errors.New("KubeClient options is required")
+	errNamespaceRequired  = errors.New("Namespace options is required")
+	errLoggerRequired     = errors.New("Logger options is required")
+)
+
+// New creates a new Lifecycle instance
+func New(opts *Options) (*Lifecycle, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+
+	return &Lifecycle{
+		cf:        opts.Codefresh,
+		client:    opts.KubeClient,
+		namespace: opts.Namespace,
+		log:       opts.Logger,
+	}, nil
+}
+
+// Init creates the agent and its runtime environment, and stores the agent token in a secret.
+// Running it again is safe, existing entities are reused
+func (l *Lifecycle) Init(ctx context.Context, opts InitOptions) error {
+	if opts.AgentToken != "" {
+		l.log.Info("runtime and agent are already initialized")
+		return l.Attach(ctx, opts.AgentName, opts.RuntimeName)
+	}
+
+	if opts.ExistingAgentToken != "" {
+		l.log.Info("using existing agent token")
+		if err := l.ensureAgentSecret(ctx, opts.SecretName, opts.OwnerName, opts.ExistingAgentToken); err != nil {
+			return err
+		}
+
+		if opts.SkipAttach {
+			l.log.Warn("skipping attach without a codefresh token", "runtime", opts.RuntimeName)
+			return nil
+		}
+
+		return l.Attach(ctx, opts.AgentName, opts.RuntimeName)
+	}
+
+	agent, err := l.cf.GetAgent(ctx, opts.AgentName)
+	if err != nil {
+		return err
+	}
+
+	if agent != nil {
+		return fmt.Errorf("agent \"%s\" already exists, but its token is not available, provide the existing agent token", opts.AgentName)
+	}
+
+	agent, err = l.cf.CreateAgent(ctx, opts.AgentName)
+	if err != nil {
+		return err
+	}
+
+	l.log.Info("created agent", "agent", agent.Name)
+	// the token can't be retrieved again, save it before anything else might fail
+	if err := l.ensureAgentSecret(ctx, opts.SecretName, opts.OwnerName, agent.Token); err != nil {
+		return err
+	}
+
+	err = l.cf.CreateRuntimeEnvironment(ctx, codefresh.CreateRuntimeEnvironmentRequest{
+		RuntimeEnvironmentName: opts.RuntimeName,
+		ClusterName:            opts.ClusterName,
+		Namespace:              l.namespace,
+		Agent:                  true,
+	})
+	switch {
+	case codefresh.IsConflict(err):
+		l.log.Info("runtime environment already exists", "runtime", opts.RuntimeName)
+	case err != nil:
+		return err
+	default:
+		l.log.Info("created runtime environment", "runtime", opts.RuntimeName)
+	}
+
+	return l.Attach(ctx, opts.AgentName, opts.RuntimeName)
+}
+
+// Attach adds the runtime environment to the runtimes handled by the agent
+func (l *Lifecycle) Attach(ctx context.Context, agentName, runtimeName string) error {
+	agent, err := l.cf.GetAgent(ctx, agentName)
+	if err != nil {
+		return err
+	}
+
+	if agent == nil {
+		return fmt.Errorf("agent \"%s\" not found", agentName)
+	}
+
+	for _, re := range agent.Runtimes {
+		if re == runtimeName {
+			l.log.Info("runtime environment is already attached", "agent", agentName, "runtime", runtimeName)
+			return nil
+		}
+	}
+
+	err = l.cf.UpdateAgent(ctx, agent.ID, codefresh.UpdateAgentRequest{
+		Runtimes: append(agent.Runtimes, runtimeName),
+	})
+	if err != nil {
+		return err
+	}
+
+	l.log.Info("attached runtime environment", "agent", agentName, "runtime", runtimeName)
+	return nil
+}
+
+// Cleanup removes the runtime environment, the agent and the agent secrets.
+// Entities that do not exist are skipped, other failures are returned after all the steps ran
+func (l *Lifecycle) Cleanup(ctx context.Context, opts CleanupOptions) error {
+	var errs []error
+	if opts.RuntimeName != "" {
+		err := l.cf.DeleteRuntimeEnvironment(ctx, opts.RuntimeName, !opts.Agent)
+		switch {
+		case codefresh.IsNotFound(err):
+			l.log.Info("runtime environment does not exist", "runtime", opts.RuntimeName)
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			l.log.Info("removed runtime environment", "runtime", opts.RuntimeName)
+		}
+	}
+
+	if opts.AgentName != "" {
+		if err := l.deleteAgent(ctx, opts.AgentName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := l.removeSecretFinalizers(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, name := range opts.SecretNames {
+		err := l.client.CoreV1().Secrets(l.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			l.log.Info("secret does not exist", "secret", name)
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed deleting secret \"%s\": %w", name, err))
+		default:
+			l.log.Info("removed secret", "secret", name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l *Lifecycle) ensureAgentSecret(ctx context.Context, name, ownerName, token string) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  l.namespace,
+			Labels:     map[string]string{internalLabel: "true"},
+			Finalizers: []string{"kubernetes"},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			AgentTokenKey: []byte(token),
+		},
+	}
+	if ownerName != "" {
+		owner, err := l.client.AppsV1().Deployments(l.namespace).Get(ctx, ownerName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed getting owner deployment \"%s\": %w", ownerName, err)
+		}
+
+		secret.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       owner.Name,
+				UID:        owner.UID,
+			},
+		}
+	}
+
+	secrets := l.client.CoreV1().Secrets(l.namespace)
+	existing, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed creating secret \"%s\": %w", name, err)
+		}
+
+		l.log.Info("created agent secret", "secret", name)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed getting secret \"%s\": %w", name, err)
+	}
+
+	secret.ResourceVersion = existing.ResourceVersion
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed updating secret \"%s\": %w", name, err)
+	}
+
+	l.log.Info("updated agent secret", "secret", name)
+	return nil
+}
+
+func (l *Lifecycle) deleteAgent(ctx context.Context, name string) error {
+	agent, err := l.cf.GetAgent(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if agent == nil {
+		l.log.Info("agent does not exist", "agent", name)
+		return nil
+	}
+
+	if err := l.cf.DeleteAgent(ctx, agent.ID); err != nil && !codefresh.IsNotFound(err) {
+		return err
+	}
+
+	l.log.Info("removed agent", "agent", name)
+	return nil
+}
+
+// removeSecretFinalizers allows the internal secrets to be deleted with the release
+func (l *Lifecycle) removeSecretFinalizers(ctx context.Context) error {
+	secrets := l.client.CoreV1().Secrets(l.namespace)
+	list, err := secrets.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", internalLabel),
+	})
+	if err != nil {
+		return fmt.Errorf("failed listing internal secrets: %w", err)
+	}
+
+	var errs []error
+	for _, s := range list.Items {
+		if len(s.Finalizers) == 0 {
+			continue
+		}
+
+		_, err := secrets.Patch(ctx, s.Name, types.MergePatchType, []byte(`{"metadata":{"finalizers":null}}`), metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed removing finalizers from secret \"%s\": %w", s.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkOptions(opts *Options) error {
+	if opts == nil {
+		return errOptionsRequired
+	}
+
+	if opts.Codefresh == nil {
+		return errCodefreshRequired
+	}
+
+	if opts.KubeClient == nil {
+		return errKubeClientRequired
+	}
+
+	if opts.Namespace == "" {
+		return errNamespaceRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	namespace  = "some-namespace"
+	secretName = "cf-runner"
+)
+
+func newLifecycle(t *testing.T, cf codefresh.Admin, objects ...runtime.Object) (*Lifecycle, *fake.Clientset) {
+	client := fake.NewClientset(objects...)
+	l, err := New(&Options{
+		Codefresh:  cf,
+		KubeClient: client,
+		Namespace:  namespace,
+		Logger:     logger.New(logger.Options{}),
+	})
+	assert.NoError(t, err)
+	return l, client
+}
+
+func getAgentToken(t *testing.T, client *fake.Clientset) string {
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return string(secret.Data[AgentTokenKey])
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		opts    *Options
+		wantErr error
+	}{
+		"should fail without options": {
+			wantErr: errOptionsRequired,
+		},
+		"should fail without codefresh": {
+			opts:    &Options{},
+			wantErr: errCodefreshRequired,
+		},
+		"should fail without namespace": {
+			opts: &Options{
+				Codefresh:  &codefresh.MockAdmin{},
+				KubeClient: fake.NewClientset(),
+			},
+			wantErr: errNamespaceRequired,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestLifecycle_Init(t *testing.T) {
+	owner := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cf-runner",
+			Namespace: namespace,
+			UID:       "some-uid",
+		},
+	}
+	opts := InitOptions{
+		AgentName:   "some-agent",
+		RuntimeName: "some-cluster/some-namespace",
+		ClusterName: "some-cluster",
+		OwnerName:   "cf-runner",
+		SecretName:  secretName,
+	}
+	tests := map[string]struct {
+		opts      func(InitOptions) InitOptions
+		mockCf    func(*codefresh.MockAdmin)
+		wantToken string
+		wantErr   string
+	}{
+		"should create the agent, the secret and the runtime environment": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(nil, nil).Once()
+				cf.EXPECT().CreateAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent", Token: "some-agent-token"}, nil)
+				cf.EXPECT().CreateRuntimeEnvironment(mock.Anything, codefresh.CreateRuntimeEnvironmentRequest{
+					RuntimeEnvironmentName: "some-cluster/some-namespace",
+					ClusterName:            "some-cluster",
+					Namespace:              namespace,
+					Agent:                  true,
+				}).Return(nil)
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent"}, nil).Once()
+				cf.EXPECT().UpdateAgent(mock.Anything, "1", codefresh.UpdateAgentRequest{Runtimes: []string{"some-cluster/some-namespace"}}).Return(nil)
+			},
+			wantToken: "some-agent-token",
+		},
+		"should reuse an existing runtime environment": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(nil, nil).Once()
+				cf.EXPECT().CreateAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent", Token: "some-agent-token"}, nil)
+				cf.EXPECT().CreateRuntimeEnvironment(mock.Anything, mock.Anything).Return(codefresh.Error{APIStatusCode: 409})
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent", Runtimes: []string{"some-cluster/some-namespace"}}, nil).Once()
+			},
+			wantToken: "some-agent-token",
+		},
+		"should only attach when the agent secret exists": {
+			opts: func(o InitOptions) InitOptions {
+				o.AgentToken = "some-agent-token"
+				return o
+			},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent", Runtimes: []string{"other"}}, nil)
+				cf.EXPECT().UpdateAgent(mock.Anything, "1", codefresh.UpdateAgentRequest{Runtimes: []string{"other", "some-cluster/some-namespace"}}).Return(nil)
+			},
+		},
+		"should save the existing agent token": {
+			opts: func(o InitOptions) InitOptions {
+				o.ExistingAgentToken = "some-existing-token"
+				return o
+			},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent", Runtimes: []string{"some-cluster/some-namespace"}}, nil)
+			},
+			wantToken: "some-existing-token",
+		},
+		"should save the existing agent token without attaching": {
+			opts: func(o InitOptions) InitOptions {
+				o.ExistingAgentToken = "some-existing-token"
+				o.SkipAttach = true
+				return o
+			},
+			mockCf:    func(*codefresh.MockAdmin) {},
+			wantToken: "some-existing-token",
+		},
+		"should fail when the agent exists without a token": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Name: "some-agent"}, nil)
+			},
+			wantErr: "agent \"some-agent\" already exists, but its token is not available, provide the existing agent token",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cf := codefresh.NewMockAdmin(t)
+			tt.mockCf(cf)
+			l, client := newLifecycle(t, cf, owner)
+			o := opts
+			if tt.opts != nil {
+				o = tt.opts(o)
+			}
+
+			err := l.Init(context.Background(), o)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.wantToken != "" {
+				assert.Equal(t, tt.wantToken, getAgentToken(t, client))
+				secret, _ := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+				assert.Equal(t, "some-uid", string(secret.OwnerReferences[0].UID))
+			}
+		})
+	}
+}
+
+func TestLifecycle_Init_updatesSecret(t *testing.T) {
+	cf := codefresh.NewMockAdmin(t)
+	cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1", Runtimes: []string{"some-runtime"}}, nil)
+	l, client := newLifecycle(t, cf, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Data:       map[string][]byte{AgentTokenKey: []byte("old-token")},
+	})
+
+	err := l.Init(context.Background(), InitOptions{
+		AgentName:          "some-agent",
+		RuntimeName:        "some-runtime",
+		SecretName:         secretName,
+		ExistingAgentToken: "new-token",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "new-token", getAgentToken(t, client))
+}
+
+func TestLifecycle_Cleanup(t *testing.T) {
+	internal := func(name string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  namespace,
+				Labels:     map[string]string{internalLabel: "true"},
+				Finalizers: []string{"kubernetes"},
+			},
+		}
+	}
+	tests := map[string]struct {
+		mockCf  func(*codefresh.MockAdmin)
+		wantErr string
+	}{
+		"should remove everything": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().DeleteRuntimeEnvironment(mock.Anything, "some-runtime", false).Return(nil)
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1"}, nil)
+				cf.EXPECT().DeleteAgent(mock.Anything, "1").Return(nil)
+			},
+		},
+		"should skip entities that do not exist": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().DeleteRuntimeEnvironment(mock.Anything, "some-runtime", false).Return(codefresh.Error{APIStatusCode: 404})
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(nil, nil)
+			},
+		},
+		"should continue after a failure": {
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().DeleteRuntimeEnvironment(mock.Anything, "some-runtime", false).Return(codefresh.Error{APIStatusCode: 500, Message: "oops"})
+				cf.EXPECT().GetAgent(mock.Anything, "some-agent").Return(&codefresh.Agent{ID: "1"}, nil)
+				cf.EXPECT().DeleteAgent(mock.Anything, "1").Return(nil)
+			},
+			wantErr: "HTTP request to Codefresh API rejected. Status-Code: 500. Message: oops",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cf := codefresh.NewMockAdmin(t)
+			tt.mockCf(cf)
+			l, client := newLifecycle(t, cf, internal(secretName), internal("other-internal"))
+
+			err := l.Cleanup(context.Background(), CleanupOptions{
+				AgentName:   "some-agent",
+				RuntimeName: "some-runtime",
+				Agent:       true,
+				SecretNames: []string{secretName, "codefresh-certs-server"},
+			})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			secrets, _ := client.CoreV1().Secrets(namespace).List(context.Background(), metav1.ListOptions{})
+			assert.Len(t, secrets.Items, 1)
+			assert.Empty(t, secrets.Items[0].Finalizers)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PatchStatusUnchanged means the runtime environment already matches the spec
+	PatchStatusUnchanged PatchStatus = "unchanged"
+	// PatchStatusPatched means the runtime environment was updated
+	PatchStatusPatched PatchStatus = "patched"
+	// PatchStatusChanged means the runtime environment differs from the spec, and was not updated because of dry-run
+	PatchStatusChanged PatchStatus = "changed (dry-run)"
+	// PatchStatusFailed means the spec could not be applied, see PatchResult.Err
+	PatchStatusFailed PatchStatus = "failed"
+
+	systemDir = "runtime.d/system"
+)
+
+type (
+	// PatchStatus is the outcome of applying a single spec file
+	PatchStatus string
+
+	// PatchOptions for updating runtime environment specs
+	PatchOptions struct {
+		// Dir holds the *.yaml runtime environment specs, and the system ones under runtime.d/system
+		Dir string
+		// Agent is true when the specs in Dir belong to runtime environments of an agent,
+		// otherwise they are patched as system runtime environments
+		Agent bool
+		// DryRun only computes the diffs, nothing is updated
+		DryRun bool
+	}
+
+	// PatchResult of a single spec file
+	PatchResult struct {
+		File    string
+		Runtime string
+		System  bool
+		Status  PatchStatus
+		// Diff between the current runtime environment and the spec, empty when unchanged
+		Diff string
+		Err  error
+	}
+
+	runtimeSpec struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Accounts []string `json:"accounts"`
+	}
+)
+
+// Patch updates the runtime environments from the specs in opts.Dir, only when they differ.
+// Every file is handled even if a previous one failed, and has a result.
+// The returned error joins the errors of all the failed files
+func (l *Lifecycle) Patch(ctx context.Context, opts PatchOptions) ([]PatchResult, error) {
+	files, err := filepath.Glob(filepath.Join(opts.Dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	systemFiles, err := filepath.Glob(filepath.Join(opts.Dir, systemDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []PatchResult
+	var errs []error
+	apply := func(file string, system bool) {
+		res := l.patchFile(ctx, file, system, opts.DryRun)
+		if res.Err != nil {
+			l.log.Error("failed patching runtime environment", "file", file, "runtime", res.Runtime, "error", res.Err)
+			errs = append(errs, res.Err)
+		}
+
+		results = append(results, res)
+	}
+
+	for _, file := range files {
+		apply(file, !opts.Agent)
+	}
+
+	for _, file := range systemFiles {
+		apply(file, true)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+func (l *Lifecycle) patchFile(ctx context.Context, file string, system bool, dryRun bool) PatchResult {
+	res := PatchResult{
+		File:   file,
+		System: system,
+		Status: PatchStatusFailed,
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		res.Err = fmt.Errorf("failed reading \"%s\": %w", file, err)
+		return res
+	}
+
+	spec := map[string]interface{}{}
+	meta := runtimeSpec{}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		res.Err = fmt.Errorf("failed parsing \"%s\": %w", file, err)
+		return res
+	}
+
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		res.Err = fmt.Errorf("failed parsing \"%s\": %w", file, err)
+		return res
+	}
+
+	name := meta.Metadata.Name
+	res.Runtime = name
+	if name == "" {
+		res.Err = fmt.Errorf("missing metadata.name in \"%s\"", file)
+		return res
+	}
+
+	current, err := l.cf.GetRuntimeEnvironment(ctx, name, system)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	// the accounts are not part of the spec update, they are modified on their own
+	delete(spec, "accounts")
+	specChanges, err := specDiff(current, spec)
+	if err != nil {
+		res.Err = fmt.Errorf("failed comparing runtime environment \"%s\": %w", name, err)
+		return res
+	}
+
+	var accountChanges string
+	if system && len(meta.Accounts) > 0 {
+		sort.Strings(meta.Accounts)
+		accountChanges = accountsDiff(current, meta.Accounts)
+	}
+
+	res.Diff = specChanges + accountChanges
+	if res.Diff == "" {
+		res.Status = PatchStatusUnchanged
+		l.log.Debug("runtime environment is up to date", "runtime", name, "file", file)
+		return res
+	}
+
+	if dryRun {
+		res.Status = PatchStatusChanged
+		return res
+	}
+
+	if specChanges != "" {
+		if err := l.cf.PatchRuntimeEnvironment(ctx, name, spec, system); err != nil {
+			res.Err = fmt.Errorf("failed patching runtime environment \"%s\" from \"%s\": %w", name, file, err)
+			return res
+		}
+
+		l.log.Info("patched runtime environment", "runtime", name, "file", file, "system", system)
+	}
+
+	if accountChanges != "" {
+		if err := l.cf.ModifyRuntimeEnvironmentAccounts(ctx, name, meta.Accounts); err != nil {
+			res.Err = fmt.Errorf("failed modifying accounts of runtime environment \"%s\": %w", name, err)
+			return res
+		}
+
+		l.log.Info("modified runtime environment accounts", "runtime", name, "accounts", len(meta.Accounts))
+	}
+
+	res.Status = PatchStatusPatched
+	return res
+}
+
+// specDiff compares only the fields that are set in the spec, the platform adds fields of its own
+func specDiff(current, spec map[string]interface{}) (string, error) {
+	to, err := yaml.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+
+	if current == nil {
+		// a new runtime environment, everything is added
+		return diffLines(nil, splitLines(string(to))), nil
+	}
+
+	from, err := yaml.Marshal(project(current, spec))
+	if err != nil {
+		return "", err
+	}
+
+	if string(from) == string(to) {
+		return "", nil
+	}
+
+	return diffLines(splitLines(string(from)), splitLines(string(to))), nil
+}
+
+// accountsDiff compares the accounts of the runtime environment to the sorted spec ones, in any order
+func accountsDiff(current map[string]interface{}, accounts []string) string {
+	var from []string
+	if list, ok := current["accounts"].([]interface{}); ok {
+		for _, a := range list {
+			if s, ok := a.(string); ok {
+				from = append(from, s)
+			}
+		}
+	}
+
+	sort.Strings(from)
+	if slices.Equal(from, accounts) {
+		return ""
+	}
+
+	return diffLines(accountLines(from), accountLines(accounts))
+}
+
+func accountLines(accounts []string) []string {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	lines := []string{"accounts:"}
+	for _, a := range accounts {
+		lines = append(lines, "- "+a)
+	}
+
+	return lines
+}
+
+// project keeps only the keys of current that also exist in spec, recursively through objects,
+// and through the items of lists that have as many items as in spec
+func project(current, spec interface{}) interface{} {
+	if currentList, ok := current.([]interface{}); ok {
+		specList, ok := spec.([]interface{})
+		if !ok || len(specList) != len(currentList) {
+			return current
+		}
+
+		res := make([]interface{}, len(currentList))
+		for i := range currentList {
+			res[i] = project(currentList[i], specList[i])
+		}
+
+		return res
+	}
+
+	currentMap, ok := current.(map[string]interface{})
+	if !ok {
+		return current
+	}
+
+	specMap, ok := spec.(map[string]interface{})
+	if !ok {
+		return current
+	}
+
+	res := map[string]interface{}{}
+	for k, v := range specMap {
+		if c, ok := currentMap[k]; ok {
+			res[k] = project(c, v)
+		}
+	}
+
+	return res
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns a line diff of a and b, lines are prefixed with "-", "+" or " "
+func diffLines(a, b []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	sb := strings.Builder{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeSpecs(t *testing.T, specs map[string]string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, systemDir), 0755))
+	for name, content := range specs {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	return dir
+}
+
+func TestLifecycle_Patch(t *testing.T) {
+	agentSpec := "metadata:\n  name: some-cluster/ns\nruntimeScheduler:\n  cluster:\n    namespace: ns\n"
+	systemSpec := "metadata:\n  name: system/ns\naccounts:\n- b\n- a\n"
+	current := map[string]interface{}{
+		"_id":              "some-id",
+		"metadata":         map[string]interface{}{"name": "some-cluster/ns", "agent": true},
+		"runtimeScheduler": map[string]interface{}{"cluster": map[string]interface{}{"namespace": "old-ns"}},
+	}
+	tests := map[string]struct {
+		specs   map[string]string
+		dryRun  bool
+		mockCf  func(*codefresh.MockAdmin)
+		want    []PatchResult
+		wantErr []string
+	}{
+		"should patch changed specs and skip unchanged ones": {
+			specs: map[string]string{
+				"runtime.yaml":                       agentSpec,
+				filepath.Join(systemDir, "sys.yaml"): systemSpec,
+			},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "some-cluster/ns", false).Return(current, nil)
+				cf.EXPECT().PatchRuntimeEnvironment(mock.Anything, "some-cluster/ns", map[string]interface{}{
+					"metadata":         map[string]interface{}{"name": "some-cluster/ns"},
+					"runtimeScheduler": map[string]interface{}{"cluster": map[string]interface{}{"namespace": "ns"}},
+				}, false).Return(nil)
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "system/ns", true).Return(map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "system/ns"},
+					"accounts": []interface{}{"b", "a"},
+				}, nil)
+			},
+			want: []PatchResult{
+				{
+					File:    "runtime.yaml",
+					Runtime: "some-cluster/ns",
+					Status:  PatchStatusPatched,
+					Diff:    "  metadata:\n    name: some-cluster/ns\n  runtimeScheduler:\n    cluster:\n-     namespace: old-ns\n+     namespace: ns\n",
+				},
+				{
+					File:    filepath.Join(systemDir, "sys.yaml"),
+					Runtime: "system/ns",
+					System:  true,
+					Status:  PatchStatusUnchanged,
+				},
+			},
+		},
+		"should only compute the diff on dry-run": {
+			specs:  map[string]string{"runtime.yaml": agentSpec},
+			dryRun: true,
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "some-cluster/ns", false).Return(current, nil)
+			},
+			want: []PatchResult{
+				{
+					File:    "runtime.yaml",
+					Runtime: "some-cluster/ns",
+					Status:  PatchStatusChanged,
+					Diff:    "  metadata:\n    name: some-cluster/ns\n  runtimeScheduler:\n    cluster:\n-     namespace: old-ns\n+     namespace: ns\n",
+				},
+			},
+		},
+		"should modify the accounts of system runtime environments": {
+			specs: map[string]string{filepath.Join(systemDir, "sys.yaml"): systemSpec},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "system/ns", true).Return(nil, nil)
+				cf.EXPECT().PatchRuntimeEnvironment(mock.Anything, "system/ns", map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "system/ns"},
+				}, true).Return(nil)
+				cf.EXPECT().ModifyRuntimeEnvironmentAccounts(mock.Anything, "system/ns", []string{"a", "b"}).Return(nil)
+			},
+			want: []PatchResult{
+				{
+					File:    filepath.Join(systemDir, "sys.yaml"),
+					Runtime: "system/ns",
+					System:  true,
+					Status:  PatchStatusPatched,
+					Diff:    "+ metadata:\n+   name: system/ns\n+ accounts:\n+ - a\n+ - b\n",
+				},
+			},
+		},
+		"should only modify the accounts when the rest of the spec is unchanged": {
+			specs: map[string]string{filepath.Join(systemDir, "sys.yaml"): systemSpec},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "system/ns", true).Return(map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "system/ns"},
+					"accounts": []interface{}{"a"},
+				}, nil)
+				cf.EXPECT().ModifyRuntimeEnvironmentAccounts(mock.Anything, "system/ns", []string{"a", "b"}).Return(nil)
+			},
+			want: []PatchResult{
+				{
+					File:    filepath.Join(systemDir, "sys.yaml"),
+					Runtime: "system/ns",
+					System:  true,
+					Status:  PatchStatusPatched,
+					Diff:    "  accounts:\n  - a\n+ - b\n",
+				},
+			},
+		},
+		"should ignore the fields the server adds to list items": {
+			specs: map[string]string{"runtime.yaml": "metadata:\n  name: other\ntolerations:\n- key: builds\n  operator: Exists\n"},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "other", false).Return(map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "other"},
+					"tolerations": []interface{}{
+						map[string]interface{}{"key": "builds", "operator": "Exists", "effect": ""},
+					},
+				}, nil)
+			},
+			want: []PatchResult{
+				{File: "runtime.yaml", Runtime: "other", Status: PatchStatusUnchanged},
+			},
+		},
+		"should report each failed file and continue": {
+			specs: map[string]string{
+				"a-broken.yaml": "runtimeScheduler: {}\n",
+				"b-failed.yaml": agentSpec,
+				"c-ok.yaml":     "metadata:\n  name: other\n",
+			},
+			mockCf: func(cf *codefresh.MockAdmin) {
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "some-cluster/ns", false).Return(nil, errors.New("some error"))
+				cf.EXPECT().GetRuntimeEnvironment(mock.Anything, "other", false).Return(map[string]interface{}{"metadata": map[string]interface{}{"name": "other"}}, nil)
+			},
+			want: []PatchResult{
+				{File: "a-broken.yaml", Status: PatchStatusFailed},
+				{File: "b-failed.yaml", Runtime: "some-cluster/ns", Status: PatchStatusFailed},
+				{File: "c-ok.yaml", Runtime: "other", Status: PatchStatusUnchanged},
+			},
+			wantErr: []string{"missing metadata.name in", "a-broken.yaml", "some error"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cf := codefresh.NewMockAdmin(t)
+			tt.mockCf(cf)
+			l, _ := newLifecycle(t, cf)
+			dir := writeSpecs(t, tt.specs)
+
+			got, err := l.Patch(context.Background(), PatchOptions{Dir: dir, Agent: true, DryRun: tt.dryRun})
+			for i := range got {
+				got[i].File, _ = filepath.Rel(dir, got[i].File)
+				got[i].Err = nil
+			}
+
+			if tt.wantErr != nil {
+				for _, want := range tt.wantErr {
+					assert.ErrorContains(t, err, want)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_diffLines(t *testing.T) {
+	got := diffLines([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+	assert.Equal(t, "  a\n- b\n  c\n+ d\n", got)
+}
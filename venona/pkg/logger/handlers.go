@@ -0,0 +1,171 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/redact"
+
+	log "github.com/inconshreveable/log15"
+)
+
+const (
+	moduleKey = "module"
+)
+
+type (
+	// moduleLevelHandler filters records by level, using the level of the record's module if it has one
+	moduleLevelHandler struct {
+		lvl          log.Lvl
+		moduleLevels map[string]log.Lvl
+		next         log.Handler
+	}
+
+	// samplingHandler drops repetitive records, keeping only the first ones of each interval
+	samplingHandler struct {
+		initial     int
+		interval    time.Duration
+		next        log.Handler
+		mutex       sync.Mutex
+		windowStart time.Time
+		counts      map[sampleKey]int
+		now         func() time.Time
+	}
+
+	sampleKey struct {
+		lvl log.Lvl
+		msg string
+	}
+
+	// redactHandler masks the values of sensitive context keys
+	redactHandler struct {
+		keys []string
+		next log.Handler
+	}
+)
+
+func newModuleLevelHandler(lvl log.Lvl, moduleLevels map[string]string, next log.Handler) log.Handler {
+	levels := map[string]log.Lvl{}
+	for module, l := range moduleLevels {
+		if parsed, err := log.LvlFromString(l); err == nil {
+			levels[module] = parsed
+		}
+	}
+
+	return &moduleLevelHandler{
+		lvl:          lvl,
+		moduleLevels: levels,
+		next:         next,
+	}
+}
+
+func (h *moduleLevelHandler) Log(r *log.Record) error {
+	lvl := h.lvl
+	if len(h.moduleLevels) > 0 {
+		// nested loggers may override the module, the last one wins
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			if key, ok := r.Ctx[i].(string); ok && key == moduleKey {
+				if module, ok := r.Ctx[i+1].(string); ok {
+					if l, ok := h.moduleLevels[module]; ok {
+						lvl = l
+					} else {
+						lvl = h.lvl
+					}
+				}
+			}
+		}
+	}
+
+	if r.Lvl > lvl {
+		return nil
+	}
+
+	return h.next.Log(r)
+}
+
+func newSamplingHandler(initial int, interval time.Duration, next log.Handler) *samplingHandler {
+	return &samplingHandler{
+		initial:  initial,
+		interval: interval,
+		next:     next,
+		counts:   map[sampleKey]int{},
+		now:      time.Now,
+	}
+}
+
+func (h *samplingHandler) Log(r *log.Record) error {
+	h.mutex.Lock()
+	now := h.now()
+	if now.Sub(h.windowStart) >= h.interval {
+		// the counters are reset on every window, so memory is bounded by the distinct messages in a single interval
+		h.windowStart = now
+		h.counts = map[sampleKey]int{}
+	}
+
+	key := sampleKey{r.Lvl, r.Msg}
+	h.counts[key]++
+	n := h.counts[key]
+	h.mutex.Unlock()
+
+	if n > h.initial {
+		return nil
+	}
+
+	return h.next.Log(r)
+}
+
+func newRedactHandler(keys []string, next log.Handler) log.Handler {
+	lowered := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lowered = append(lowered, strings.ToLower(k))
+	}
+
+	return &redactHandler{
+		keys: lowered,
+		next: next,
+	}
+}
+
+func (h *redactHandler) Log(r *log.Record) error {
+	// the context slice might be shared with the parent logger, never modify it in place
+	ctx := make([]interface{}, len(r.Ctx))
+	copy(ctx, r.Ctx)
+	for i := 0; i+1 < len(ctx); i += 2 {
+		if key, ok := ctx[i].(string); ok && h.shouldRedact(key) {
+			ctx[i+1] = redact.Mask
+		} else {
+			ctx[i+1] = redact.Value(ctx[i+1])
+		}
+	}
+
+	redacted := *r
+	redacted.Msg = redact.String(r.Msg)
+	redacted.Ctx = ctx
+	return h.next.Log(&redacted)
+}
+
+func (h *redactHandler) shouldRedact(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range h.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
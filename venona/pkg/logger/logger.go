@@ -15,9 +15,24 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/pairs"
+
 	log "github.com/inconshreveable/log15"
 )
 
+const (
+	// FormatLogfmt writes key=value lines
+	FormatLogfmt = "logfmt"
+	// FormatJSON writes JSON lines
+	FormatJSON = "json"
+)
+
 type (
 	// Logger interface
 	Logger interface {
@@ -26,21 +41,140 @@ type (
 
 	// Options for logger
 	Options struct {
+		// Verbose forces the debug level, regardless of Level
 		Verbose bool
+		// Level is one of debug, info, warn, error, crit (default info)
+		Level string
+		// ModuleLevels overrides Level for loggers created with a specific "module" key, e.g. {"k8s": "debug"}
+		ModuleLevels map[string]string
+		// Format is either logfmt (default) or json
+		Format string
+		// File is an optional path to write the logs to, in addition to stdout
+		File string
+		// FileMaxSizeMB is the size of the log file before it is rotated
+		FileMaxSizeMB int
+		// FileMaxBackups is the number of rotated log files to keep
+		FileMaxBackups int
+		// SampleInitial is the number of identical lines (same level and message) that are logged in each
+		// SampleInterval, the rest are dropped. 0 disables sampling
+		SampleInitial  int
+		SampleInterval time.Duration
+		// RedactKeys are the context keys whose values are masked, matched as case-insensitive substrings
+		RedactKeys []string
+		// Output is where the logs are written to (default stdout)
+		Output io.Writer
 	}
 )
 
+const (
+	defaultFileMaxSizeMB  = 100
+	defaultFileMaxBackups = 3
+	defaultSampleInterval = time.Second
+)
+
+var (
+	stdout io.Writer = os.Stdout
+
+	// DefaultRedactKeys are always redacted
+	DefaultRedactKeys = []string{"token", "license-key", "licensekey", "password", "secret", "authorization"}
+)
+
 // New creates new logger
 func New(o Options) Logger {
 	l := log.New(log.Ctx{})
-	handlers := []log.Handler{}
-	lvl := log.LvlInfo
-	if o.Verbose {
-		lvl = log.LvlDebug
+	l.SetHandler(buildHandler(o))
+	return l
+}
+
+// Validate checks that the options can be used to build a logger
+func (o Options) Validate() error {
+	if o.Level != "" {
+		if _, err := log.LvlFromString(o.Level); err != nil {
+			return fmt.Errorf("invalid log level \"%s\"", o.Level)
+		}
 	}
 
-	verboseHandler := log.LvlFilterHandler(lvl, log.StdoutHandler)
-	handlers = append(handlers, verboseHandler)
-	l.SetHandler(log.MultiHandler(handlers...))
-	return l
+	for module, lvl := range o.ModuleLevels {
+		if _, err := log.LvlFromString(lvl); err != nil {
+			return fmt.Errorf("invalid log level \"%s\" for module \"%s\"", lvl, module)
+		}
+	}
+
+	switch o.Format {
+	case "", FormatLogfmt, FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format \"%s\"", o.Format)
+	}
+
+	if o.SampleInitial < 0 {
+		return fmt.Errorf("log sample initial must not be negative")
+	}
+
+	return nil
+}
+
+// ParseModuleLevels parses a comma separated list of module=level pairs, e.g. "k8s=debug,agent=warn"
+func ParseModuleLevels(s string) (map[string]string, error) {
+	res := map[string]string{}
+	err := pairs.Parse(s, "module level", "module=level", func(module, lvl string) error {
+		if lvl == "" {
+			return errors.New("expected module=level")
+		}
+
+		res[module] = lvl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func buildHandler(o Options) log.Handler {
+	format := log.LogfmtFormat()
+	if o.Format == FormatJSON {
+		format = log.JsonFormat()
+	}
+
+	out := o.Output
+	if out == nil {
+		out = stdout
+	}
+
+	sinks := []log.Handler{log.StreamHandler(out, format)}
+	if o.File != "" {
+		w, err := NewRotatingFile(o.File, o.FileMaxSizeMB, o.FileMaxBackups)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed opening log file \"%s\", logging to stdout only: %s\n", o.File, err)
+		} else {
+			sinks = append(sinks, log.StreamHandler(w, format))
+		}
+	}
+
+	var h log.Handler = log.MultiHandler(sinks...)
+	redactKeys := append([]string{}, DefaultRedactKeys...)
+	h = newRedactHandler(append(redactKeys, o.RedactKeys...), h)
+	if o.SampleInitial > 0 {
+		interval := o.SampleInterval
+		if interval <= 0 {
+			interval = defaultSampleInterval
+		}
+
+		h = newSamplingHandler(o.SampleInitial, interval, h)
+	}
+
+	return newModuleLevelHandler(parseLevel(o.Level, o.Verbose), o.ModuleLevels, h)
+}
+
+func parseLevel(lvl string, verbose bool) log.Lvl {
+	if verbose {
+		return log.LvlDebug
+	}
+
+	if l, err := log.LvlFromString(lvl); err == nil {
+		return l
+	}
+
+	return log.LvlInfo
 }
@@ -0,0 +1,210 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/redact"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureStdout(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	orig := stdout
+	stdout = buf
+	t.Cleanup(func() { stdout = orig })
+	return buf
+}
+
+func lines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestNew_jsonFormat(t *testing.T) {
+	buf := captureStdout(t)
+	log := New(Options{Format: FormatJSON}).New("module", "agent")
+	log.Info("handling workflow", "workflow", "some-wf", "handlerId", 3)
+
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "handling workflow", line["msg"])
+	assert.Equal(t, "agent", line["module"])
+	assert.Equal(t, "some-wf", line["workflow"])
+	assert.Equal(t, float64(3), line["handlerId"])
+}
+
+func TestNew_moduleLevels(t *testing.T) {
+	buf := captureStdout(t)
+	log := New(Options{
+		Level:        "info",
+		ModuleLevels: map[string]string{"k8s": "debug", "agent": "error"},
+	})
+	log.Debug("root debug")
+	log.New("module", "k8s").Debug("k8s debug")
+	log.New("module", "k8s").New("module", "config-loader").Debug("nested debug")
+	log.New("module", "agent").Warn("agent warn")
+	log.New("module", "agent").Error("agent error")
+
+	out := buf.String()
+	assert.NotContains(t, out, "root debug")
+	assert.Contains(t, out, "k8s debug")
+	assert.NotContains(t, out, "nested debug")
+	assert.NotContains(t, out, "agent warn")
+	assert.Contains(t, out, "agent error")
+}
+
+func TestNew_redact(t *testing.T) {
+	buf := captureStdout(t)
+	log := New(Options{RedactKeys: []string{"api-key"}})
+	child := log.New("codefresh-token", "parent-secret-value")
+	child.Info("first", "license-key", "some-license", "my-api-key", "some-key", "workflow", "some-wf")
+	child.Info("second")
+
+	out := buf.String()
+	assert.NotContains(t, out, "parent-secret-value")
+	assert.NotContains(t, out, "some-license")
+	assert.NotContains(t, out, "some-key")
+	assert.Contains(t, out, "workflow=some-wf")
+	assert.Equal(t, 4, strings.Count(out, redact.Mask))
+}
+
+func TestNew_redactSecrets(t *testing.T) {
+	redact.AddSecret("known-runtime-token")
+	buf := &bytes.Buffer{}
+	log := New(Options{Output: buf, Format: FormatJSON})
+	log.Info("using known-runtime-token", "err", errors.New("unauthorized: known-runtime-token"), "body", `{"token": "eyJhbGciOi"}`)
+
+	out := buf.String()
+	assert.NotContains(t, out, "known-runtime-token")
+	assert.NotContains(t, out, "eyJhbGciOi")
+	assert.Contains(t, out, "unauthorized: [REDACTED]")
+}
+
+func TestNew_sampling(t *testing.T) {
+	buf := captureStdout(t)
+	log := New(Options{SampleInitial: 2, SampleInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		log.Info("repetitive", "i", i)
+		log.Info("other")
+	}
+
+	assert.Equal(t, 4, len(lines(buf)))
+}
+
+func Test_samplingHandler_window(t *testing.T) {
+	buf := captureStdout(t)
+	now := time.Now()
+	l := New(Options{})
+	h := newSamplingHandler(1, time.Second, l.GetHandler())
+	h.now = func() time.Time { return now }
+	l.SetHandler(h)
+
+	l.Info("repetitive")
+	l.Info("repetitive")
+	now = now.Add(time.Second)
+	l.Info("repetitive")
+
+	assert.Equal(t, 2, len(lines(buf)))
+}
+
+func Test_rotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "venona.log")
+	w, err := newRotatingFile(path, 10, 2)
+	assert.NoError(t, err)
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		_, err := w.Write([]byte(s))
+		assert.NoError(t, err)
+	}
+
+	read := func(p string) string {
+		b, _ := os.ReadFile(p)
+		return string(b)
+	}
+	assert.Equal(t, "dddddddd\n", read(path))
+	assert.Equal(t, "cccccccc\n", read(path+".1"))
+	assert.Equal(t, "bbbbbbbb\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
+
+func Test_rotatingFile_renameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "venona.log")
+	w, err := newRotatingFile(path, 10, 1)
+	assert.NoError(t, err)
+	// a non-empty directory in place of the backup can't be removed or replaced
+	assert.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700))
+
+	_, err = w.Write([]byte("aaaaaaaa\n"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("bbbbbbbb\n"))
+	assert.Error(t, err)
+
+	// the current file is still open, rotating succeeds once the backup can be replaced
+	assert.NoError(t, os.RemoveAll(path+".1"))
+	_, err = w.Write([]byte("cccccccc\n"))
+	assert.NoError(t, err)
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "cccccccc\n", string(b))
+	b, _ = os.ReadFile(path + ".1")
+	assert.Equal(t, "aaaaaaaa\n", string(b))
+}
+
+func TestParseModuleLevels(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		"should parse an empty string": {
+			input: "",
+			want:  map[string]string{},
+		},
+		"should parse several modules": {
+			input: "k8s=debug, agent=warn",
+			want:  map[string]string{"k8s": "debug", "agent": "warn"},
+		},
+		"should fail on a missing level": {
+			input:   "k8s",
+			wantErr: "invalid module level \"k8s\", expected module=level",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseModuleLevels(tt.input)
+			if err != nil || tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{Level: "debug", Format: FormatJSON, ModuleLevels: map[string]string{"k8s": "warn"}}.Validate())
+	assert.EqualError(t, Options{Level: "verbose"}.Validate(), "invalid log level \"verbose\"")
+	assert.EqualError(t, Options{ModuleLevels: map[string]string{"k8s": "loud"}}.Validate(), "invalid log level \"loud\" for module \"k8s\"")
+	assert.EqualError(t, Options{Format: "xml"}.Validate(), "invalid log format \"xml\"")
+}
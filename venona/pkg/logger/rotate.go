@@ -0,0 +1,126 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// rotatingFile is an io.Writer that rotates the file once it reaches maxSize,
+// keeping up to maxBackups old files named <path>.1 (newest) to <path>.<maxBackups> (oldest)
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	mutex      sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile opens a file for appending, that is rotated once it reaches maxSizeMB,
+// keeping maxBackups old files. Zero values use the defaults of the log file
+func NewRotatingFile(path string, maxSizeMB int, maxBackups int) (io.WriteCloser, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultFileMaxSizeMB
+	}
+
+	if maxBackups <= 0 {
+		maxBackups = defaultFileMaxBackups
+	}
+
+	w, err := newRotatingFile(path, int64(maxSizeMB)*1024*1024, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	w := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingFile) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.file.Close()
+}
+
+func (w *rotatingFile) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingFile) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	_ = os.Remove(w.backupName(w.maxBackups))
+	for i := w.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(w.backupName(i), w.backupName(i+1))
+	}
+
+	if err := os.Rename(w.path, w.backupName(1)); err != nil {
+		// keep the current file open, the next write tries to rotate again
+		return errors.Join(err, w.open())
+	}
+
+	return w.open()
+}
+
+func (w *rotatingFile) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
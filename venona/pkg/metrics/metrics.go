@@ -16,6 +16,7 @@ package metrics
 
 import (
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/codefresh-io/go/venona/pkg/task"
@@ -95,6 +96,80 @@ var (
 		Help:      "Net time to process each workflow batch in the runner",
 		Buckets:   []float64{0.5, 1, 1.5, 2, 3, 6, 12},
 	}, []string{"workflow_type"})
+	wfCoalescedTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Subsystem: wfSubsystem,
+		Name:      "coalesced_tasks",
+		Help:      "Workflow tasks that were skipped, since the resource they create is deleted in the same poll",
+	}, []string{"task_type"})
+	wfCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Subsystem: wfSubsystem,
+		Name:      "cancelled",
+		Help:      "Workflow cancellations, found is false when the workflow was neither queued nor being handled",
+	}, []string{"found"})
+	wfDeadlineExceededTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Subsystem: wfSubsystem,
+		Name:      "deadline_exceeded_tasks",
+		Help:      "Workflow tasks that failed since they hit their deadline, the deadline is either of the task or of the workflow",
+	}, []string{"task_type", "deadline"})
+	wfCapacityWaits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Subsystem: wfSubsystem,
+		Name:      "capacity_waits",
+		Help:      "Workflow tasks that waited for capacity after exceeding a ResourceQuota, by how the wait ended: admitted, failed, timeout or cancelled",
+	}, []string{"task_type", "result"})
+	admissionViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "admission_violations",
+		Help:      "Violations of admission policy rules by the resources the agent creates, by the mode of the policy",
+	}, []string{"rule", "mode"})
+	expiredTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "expired_tasks",
+		Help:      "Pulled tasks that were older than the TTL of their type and were not handled",
+	}, []string{"task_type"})
+	invalidTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "invalid_tasks",
+		Help:      "Pulled tasks whose spec did not match the schema of their type and were not handled",
+	}, []string{"task_type"})
+	duplicateTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "duplicate_tasks",
+		Help:      "Pulled tasks that were already delivered to the agent and were not handled again",
+	}, []string{"task_type"})
+	pvcPoolAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: runnerNamespace,
+		Name:      "pvc_pool_available",
+		Help:      "Available PVCs of each PVC pool class, as of the last sync of the pool",
+	}, []string{"pool"})
+	pvcPoolClaims = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "pvc_pool_claims",
+		Help:      "CreatePvc tasks that matched a PVC pool class, hit is false when the class had no available PVC",
+	}, []string{"pool", "hit"})
+	pvcPoolProvisioned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "pvc_pool_provisioned",
+		Help:      "PVCs that were created to replenish a PVC pool class",
+	}, []string{"pool"})
+	pvcPoolCollected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "pvc_pool_collected",
+		Help:      "Pooled PVCs that were garbage-collected: beyond the count, lost, of a removed class, or claimed for longer than the claim TTL",
+	}, []string{"pool"})
+	k8sDeletionEscalations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "k8s_deletion_escalations",
+		Help:      "Escalations of deleted resources that were stuck terminating, either force (grace period 0) or finalizers (removed)",
+	}, []string{"k8s_type", "escalation"})
+	k8sStuckDeletions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: runnerNamespace,
+		Name:      "k8s_stuck_deletions",
+		Help:      "Deleted resources that were still terminating once the deletion wait timed out",
+	}, []string{"k8s_type"})
 	k8sProcessingTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: runnerNamespace,
 		Name:      "k8s_processing_sec",
@@ -117,6 +192,20 @@ func Register(reg *prometheus.Registry) {
 		agentProcessingTime,
 		wfProcessingTime,
 		k8sProcessingTime,
+		expiredTasks,
+		duplicateTasks,
+		invalidTasks,
+		wfCoalescedTasks,
+		wfCancelled,
+		wfDeadlineExceededTasks,
+		wfCapacityWaits,
+		admissionViolations,
+		pvcPoolAvailable,
+		pvcPoolClaims,
+		pvcPoolProvisioned,
+		pvcPoolCollected,
+		k8sDeletionEscalations,
+		k8sStuckDeletions,
 	}...)
 }
 
@@ -171,3 +260,59 @@ func ObserveK8sMetrics(taskType task.Type, processed time.Duration) {
 	labels := prometheus.Labels{"k8s_type": string(taskType)}
 	k8sProcessingTime.With(labels).Observe(processed.Seconds())
 }
+
+func IncExpiredTasks(taskType task.Type) {
+	expiredTasks.With(prometheus.Labels{"task_type": string(taskType)}).Inc()
+}
+
+func IncDuplicateTasks(taskType task.Type) {
+	duplicateTasks.With(prometheus.Labels{"task_type": string(taskType)}).Inc()
+}
+
+func IncCoalescedTasks(taskType task.Type) {
+	wfCoalescedTasks.With(prometheus.Labels{"task_type": string(taskType)}).Inc()
+}
+
+func IncInvalidTasks(taskType task.Type) {
+	invalidTasks.With(prometheus.Labels{"task_type": string(taskType)}).Inc()
+}
+
+func IncCancelledWorkflows(found bool) {
+	wfCancelled.With(prometheus.Labels{"found": strconv.FormatBool(found)}).Inc()
+}
+
+func IncDeadlineExceededTasks(taskType task.Type, deadline string) {
+	wfDeadlineExceededTasks.With(prometheus.Labels{"task_type": string(taskType), "deadline": deadline}).Inc()
+}
+
+func IncCapacityWaits(taskType task.Type, result string) {
+	wfCapacityWaits.With(prometheus.Labels{"task_type": string(taskType), "result": result}).Inc()
+}
+
+func IncAdmissionViolations(rule string, mode string) {
+	admissionViolations.With(prometheus.Labels{"rule": rule, "mode": mode}).Inc()
+}
+
+func SetPVCPoolAvailable(pool string, available int) {
+	pvcPoolAvailable.With(prometheus.Labels{"pool": pool}).Set(float64(available))
+}
+
+func IncPVCPoolClaims(pool string, hit bool) {
+	pvcPoolClaims.With(prometheus.Labels{"pool": pool, "hit": strconv.FormatBool(hit)}).Inc()
+}
+
+func IncPVCPoolProvisioned(pool string) {
+	pvcPoolProvisioned.With(prometheus.Labels{"pool": pool}).Inc()
+}
+
+func IncPVCPoolCollected(pool string) {
+	pvcPoolCollected.With(prometheus.Labels{"pool": pool}).Inc()
+}
+
+func IncDeletionEscalations(taskType task.Type, escalation string) {
+	k8sDeletionEscalations.With(prometheus.Labels{"k8s_type": string(taskType), "escalation": escalation}).Inc()
+}
+
+func IncStuckDeletions(taskType task.Type) {
+	k8sStuckDeletions.With(prometheus.Labels{"k8s_type": string(taskType)}).Inc()
+}
@@ -25,8 +25,16 @@ import (
 type Monitor interface {
 	NewTransaction(name string) Transaction
 	NewTransactionFromContext(ctx context.Context) Transaction
+
+	// NewTransactionWithTraceContext starts a transaction that continues the trace
+	// described by the W3C trace-context carrier (traceparent, tracestate)
+	NewTransactionWithTraceContext(name string, carrier map[string]string) Transaction
+
 	NewRoundTripper(rt http.RoundTripper) http.RoundTripper
 	NewGorillaMiddleware() gorillamux.MiddlewareFunc
+
+	// Shutdown flushes all the pending data and stops the monitor
+	Shutdown(ctx context.Context) error
 }
 
 // Transaction instruments one logical unit of work: either an inbound web request
@@ -49,6 +57,13 @@ type Transaction interface {
 	NewSegmentByName(name string) Segment
 
 	NoticeError(err error)
+
+	// NewContext returns a copy of ctx that carries the transaction,
+	// so it can be continued with Monitor.NewTransactionFromContext()
+	NewContext(ctx context.Context) context.Context
+
+	// InjectTraceContext writes the W3C trace-context of the transaction into the carrier
+	InjectTraceContext(carrier map[string]string)
 }
 
 // Segment is used to instrument functions, methods, and blocks of code
@@ -58,9 +73,16 @@ type Segment interface {
 
 // Empty implementation
 type monitor struct{}
-type transaction struct{}
 type segment struct{}
 
+// transaction keeps the trace-context it was created with, so it is passed
+// through as is even when no monitor is configured
+type transaction struct {
+	traceContext map[string]string
+}
+
+type transactionKey struct{}
+
 // NewEmpty a noop monitor implementation
 func NewEmpty() Monitor {
 	return &monitor{}
@@ -72,9 +94,17 @@ func (m *monitor) NewTransaction(name string) Transaction {
 }
 
 func (m *monitor) NewTransactionFromContext(ctx context.Context) Transaction {
+	if t, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		return t
+	}
+
 	return &transaction{}
 }
 
+func (m *monitor) NewTransactionWithTraceContext(name string, carrier map[string]string) Transaction {
+	return &transaction{carrier}
+}
+
 func (m *monitor) NewRoundTripper(rt http.RoundTripper) http.RoundTripper {
 	return rt
 }
@@ -85,6 +115,10 @@ func (m *monitor) NewGorillaMiddleware() gorillamux.MiddlewareFunc {
 	}
 }
 
+func (m *monitor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
 // Transaction
 func (t *transaction) NewSegment(r *http.Request) Segment {
 	return &segment{}
@@ -100,5 +134,15 @@ func (t *transaction) End() {}
 
 func (t *transaction) NoticeError(err error) {}
 
+func (t *transaction) NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionKey{}, t)
+}
+
+func (t *transaction) InjectTraceContext(carrier map[string]string) {
+	for k, v := range t.traceContext {
+		carrier[k] = v
+	}
+}
+
 // Segment
 func (s *segment) End() {}
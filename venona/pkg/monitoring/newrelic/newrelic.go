@@ -17,6 +17,8 @@ package newrelic
 import (
 	"context"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/codefresh-io/go/venona/pkg/monitoring"
 
@@ -43,6 +45,8 @@ type (
 	}
 )
 
+const defaultShutdownTimeout = 5 * time.Second
+
 // New creates a new newrelic monitor
 func New(conf ...nr.ConfigOption) (monitoring.Monitor, error) {
 	app, err := nr.NewApplication(conf...)
@@ -62,6 +66,17 @@ func (m *monitor) NewTransactionFromContext(ctx context.Context) monitoring.Tran
 	return &transaction{nr.FromContext(ctx)}
 }
 
+func (m *monitor) NewTransactionWithTraceContext(name string, carrier map[string]string) monitoring.Transaction {
+	txn := m.app.StartTransaction(name)
+	hdrs := http.Header{}
+	for k, v := range carrier {
+		hdrs.Set(k, v)
+	}
+
+	txn.AcceptDistributedTraceHeaders(nr.TransportOther, hdrs)
+	return &transaction{txn}
+}
+
 func (m *monitor) NewRoundTripper(rt http.RoundTripper) http.RoundTripper {
 	return nr.NewRoundTripper(rt)
 }
@@ -70,6 +85,16 @@ func (m *monitor) NewGorillaMiddleware() gorillamux.MiddlewareFunc {
 	return nrgorilla.Middleware(m.app)
 }
 
+func (m *monitor) Shutdown(ctx context.Context) error {
+	timeout := defaultShutdownTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	m.app.Shutdown(timeout)
+	return nil
+}
+
 // Transaction
 func (t *transaction) NewSegment(r *http.Request) monitoring.Segment {
 	return &externalSegment{nr.StartExternalSegment(t.t, r)}
@@ -91,6 +116,18 @@ func (t *transaction) NoticeError(err error) {
 	t.t.NoticeError(err)
 }
 
+func (t *transaction) NewContext(ctx context.Context) context.Context {
+	return nr.NewContext(ctx, t.t)
+}
+
+func (t *transaction) InjectTraceContext(carrier map[string]string) {
+	hdrs := http.Header{}
+	t.t.InsertDistributedTraceHeaders(hdrs)
+	for k := range hdrs {
+		carrier[strings.ToLower(k)] = hdrs.Get(k)
+	}
+}
+
 // Segment
 func (s *segment) End() {
 	s.s.End()
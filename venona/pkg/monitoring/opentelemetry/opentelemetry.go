@@ -0,0 +1,293 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/codefresh-io/go/venona/pkg/monitoring"
+
+	gorillamux "github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ProtocolGRPC exports spans with OTLP over gRPC
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP exports spans with OTLP over HTTP (protobuf)
+	ProtocolHTTP = "http/protobuf"
+
+	instrumentationName = "github.com/codefresh-io/go/venona"
+)
+
+type (
+	// Options for the OpenTelemetry monitor
+	Options struct {
+		// Protocol is either "grpc" or "http/protobuf"
+		Protocol string
+		// Endpoint is the OTLP collector URL, when empty the OTEL_EXPORTER_OTLP_* env vars are used
+		Endpoint string
+		// Headers are added to each export request
+		Headers        map[string]string
+		ServiceName    string
+		ServiceVersion string
+		// SampleRatio of the root spans that are sampled, between 0 and 1
+		SampleRatio float64
+	}
+
+	monitor struct {
+		tp         *sdktrace.TracerProvider
+		tracer     trace.Tracer
+		propagator propagation.TextMapPropagator
+	}
+
+	transaction struct {
+		ctx  context.Context
+		span trace.Span
+		m    *monitor
+	}
+
+	segment struct {
+		span trace.Span
+	}
+
+	roundTripper struct {
+		base http.RoundTripper
+		m    *monitor
+	}
+)
+
+// New creates a new OpenTelemetry monitor that exports spans with OTLP
+func New(ctx context.Context, opts Options) (monitoring.Monitor, error) {
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating OTLP exporter: %w", err)
+	}
+
+	return newMonitor(sdktrace.NewBatchSpanProcessor(exporter), opts), nil
+}
+
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Protocol {
+	case ProtocolGRPC, "":
+		grpcOpts := []otlptracegrpc.Option{}
+		if opts.Endpoint != "" {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+		}
+
+		if len(opts.Headers) > 0 {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithHeaders(opts.Headers))
+		}
+
+		return otlptracegrpc.New(ctx, grpcOpts...)
+	case ProtocolHTTP, "http":
+		httpOpts := []otlptracehttp.Option{}
+		if opts.Endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+
+		if len(opts.Headers) > 0 {
+			httpOpts = append(httpOpts, otlptracehttp.WithHeaders(opts.Headers))
+		}
+
+		return otlptracehttp.New(ctx, httpOpts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol \"%s\"", opts.Protocol)
+	}
+}
+
+func newMonitor(processor sdktrace.SpanProcessor, opts Options) *monitor {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(opts.ServiceName),
+		semconv.ServiceVersion(opts.ServiceVersion),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	sampleRatio := opts.SampleRatio
+	if sampleRatio <= 0 || sampleRatio > 1 {
+		sampleRatio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	return &monitor{
+		tp:         tp,
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagator,
+	}
+}
+
+// Monitor
+func (m *monitor) NewTransaction(name string) monitoring.Transaction {
+	ctx, span := m.tracer.Start(context.Background(), name)
+	return &transaction{ctx, span, m}
+}
+
+func (m *monitor) NewTransactionFromContext(ctx context.Context) monitoring.Transaction {
+	return &transaction{ctx, trace.SpanFromContext(ctx), m}
+}
+
+func (m *monitor) NewTransactionWithTraceContext(name string, carrier map[string]string) monitoring.Transaction {
+	ctx := m.propagator.Extract(context.Background(), propagation.MapCarrier(carrier))
+	ctx, span := m.tracer.Start(ctx, name)
+	return &transaction{ctx, span, m}
+}
+
+func (m *monitor) NewRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return &roundTripper{rt, m}
+}
+
+func (m *monitor) NewGorillaMiddleware() gorillamux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.URL.Path
+			if route := gorillamux.CurrentRoute(r); route != nil {
+				if tmpl, err := route.GetPathTemplate(); err == nil {
+					name = tmpl
+				}
+			}
+
+			ctx := m.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := m.tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, name),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(name),
+				),
+			)
+			defer span.End()
+
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (m *monitor) Shutdown(ctx context.Context) error {
+	return m.tp.Shutdown(ctx)
+}
+
+// Transaction
+func (t *transaction) NewSegment(r *http.Request) monitoring.Segment {
+	_, span := t.m.startClientSpan(t.ctx, r)
+	return &segment{span}
+}
+
+func (t *transaction) NewSegmentByName(name string) monitoring.Segment {
+	_, span := t.m.tracer.Start(t.ctx, name)
+	return &segment{span}
+}
+
+func (t *transaction) AddAttribute(key string, val interface{}) {
+	t.span.SetAttributes(toAttribute(key, val))
+}
+
+func (t *transaction) End() {
+	t.span.End()
+}
+
+func (t *transaction) NoticeError(err error) {
+	t.span.RecordError(err)
+	t.span.SetStatus(codes.Error, err.Error())
+}
+
+func (t *transaction) NewContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, t.span)
+}
+
+func (t *transaction) InjectTraceContext(carrier map[string]string) {
+	t.m.propagator.Inject(t.ctx, propagation.MapCarrier(carrier))
+}
+
+// Segment
+func (s *segment) End() {
+	s.span.End()
+}
+
+// RoundTripper
+func (rt *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := rt.m.startClientSpan(r.Context(), r)
+	defer span.End()
+
+	r = r.Clone(ctx)
+	rt.m.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	resp, err := rt.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (m *monitor) startClientSpan(ctx context.Context, r *http.Request) (context.Context, trace.Span) {
+	// the query is omitted on purpose, it might contain secrets
+	u := *r.URL
+	u.RawQuery = ""
+	u.User = nil
+	return m.tracer.Start(ctx, fmt.Sprintf("HTTP %s", r.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLFull(u.String()),
+			semconv.ServerAddress(r.URL.Hostname()),
+		),
+	)
+}
+
+func toAttribute(key string, val interface{}) attribute.KeyValue {
+	switch v := val.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestMonitor() (*monitor, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return newMonitor(sdktrace.NewSimpleSpanProcessor(exporter), Options{ServiceName: "test"}), exporter
+}
+
+func spanByName(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+
+	return nil
+}
+
+func Test_transaction(t *testing.T) {
+	m, exporter := newTestMonitor()
+	txn := m.NewTransaction("runner-tasks-execution")
+	txn.AddAttribute("tid", "some-workflow")
+	ctx := txn.NewContext(context.Background())
+	m.NewTransactionFromContext(ctx).NewSegmentByName("some-segment").End()
+	txn.NoticeError(errors.New("some error"))
+	txn.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	parent := spanByName(spans, "runner-tasks-execution")
+	child := spanByName(spans, "some-segment")
+	assert.NotNil(t, parent)
+	assert.NotNil(t, child)
+	assert.Equal(t, parent.SpanContext.SpanID(), child.Parent.SpanID())
+	assert.Equal(t, parent.SpanContext.TraceID(), child.SpanContext.TraceID())
+	assert.Equal(t, codes.Error, parent.Status.Code)
+	assert.Contains(t, parent.Attributes, toAttribute("tid", "some-workflow"))
+}
+
+func Test_traceContext(t *testing.T) {
+	m, exporter := newTestMonitor()
+	parent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	txn := m.NewTransactionWithTraceContext("runner-tasks-execution", map[string]string{"traceparent": parent})
+	carrier := map[string]string{}
+	m.NewTransactionFromContext(txn.NewContext(context.Background())).InjectTraceContext(carrier)
+	txn.End()
+
+	span := spanByName(exporter.GetSpans(), "runner-tasks-execution")
+	assert.NotNil(t, span)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext.TraceID().String())
+	assert.Equal(t, "b7ad6b7169203331", span.Parent.SpanID().String())
+	assert.True(t, span.Parent.IsRemote())
+	assert.Equal(t, fmt.Sprintf("00-%s-%s-01", span.SpanContext.TraceID(), span.SpanContext.SpanID()), carrier["traceparent"])
+}
+
+func Test_roundTripper(t *testing.T) {
+	tests := map[string]struct {
+		status     int
+		wantStatus codes.Code
+	}{
+		"should record a successful request": {
+			status:     http.StatusOK,
+			wantStatus: codes.Unset,
+		},
+		"should record a failed request": {
+			status:     http.StatusInternalServerError,
+			wantStatus: codes.Error,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, exporter := newTestMonitor()
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			txn := m.NewTransaction("runner-tasks-pull")
+			ctx := txn.NewContext(context.Background())
+			req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/agent/some-agent/tasks?token=secret", nil)
+			client := http.Client{Transport: m.NewRoundTripper(nil)}
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			_ = resp.Body.Close()
+			txn.End()
+
+			span := spanByName(exporter.GetSpans(), "HTTP GET")
+			assert.NotNil(t, span)
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+			assert.Equal(t, tt.wantStatus, span.Status.Code)
+			assert.Contains(t, span.Attributes, semconv.URLFull(srv.URL+"/api/agent/some-agent/tasks"))
+			assert.Contains(t, traceparent, span.SpanContext.TraceID().String())
+			assert.Contains(t, traceparent, span.SpanContext.SpanID().String())
+		})
+	}
+}
+
+func Test_newExporter(t *testing.T) {
+	_, err := newExporter(context.Background(), Options{Protocol: "some-protocol"})
+	assert.EqualError(t, err, "unsupported OTLP protocol \"some-protocol\"")
+}
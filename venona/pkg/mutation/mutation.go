@@ -0,0 +1,257 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/codefresh-io/go/venona/pkg/registry"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	v1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	KindPod                   = "Pod"
+	KindPersistentVolumeClaim = "PersistentVolumeClaim"
+)
+
+type (
+	// Policy holds the rules that mutate the pods and PVCs of a runtime before they are created
+	Policy struct {
+		Rules []Rule `json:"rules"`
+	}
+
+	// Rule patches the objects it matches, with either a JSON patch or a strategic merge patch
+	Rule struct {
+		Name  string `json:"name"`
+		Match Match  `json:"match"`
+		// JSONPatch is a list of RFC 6902 operations
+		JSONPatch json.RawMessage `json:"jsonPatch,omitempty"`
+		// StrategicMerge is a partial object, merged like kubectl patch --type strategic
+		StrategicMerge json.RawMessage `json:"strategicMerge,omitempty"`
+
+		jsonPatch jsonpatch.Patch
+	}
+
+	// Match selects the objects of a rule, an empty field matches any object
+	Match struct {
+		// Kinds are Pod and PersistentVolumeClaim
+		Kinds      []string `json:"kinds,omitempty"`
+		Namespaces []string `json:"namespaces,omitempty"`
+		// Labels must all be set on the object, with the same values
+		Labels map[string]string `json:"labels,omitempty"`
+		// Images are path.Match patterns, e.g. docker.io/codefresh/*, one of the containers of a pod must match one of them,
+		// either as it is or with its registry, see registry.Normalize.
+		// Only pods can match images
+		Images []string `json:"images,omitempty"`
+	}
+)
+
+var errNoPatch = errors.New("exactly one of jsonPatch or strategicMerge is required")
+
+// Load reads a YAML or JSON policy file, and validates its rules
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("failed parsing mutation policy \"%s\": %w", file, err)
+	}
+
+	p, err = New(p.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mutation policy \"%s\": %w", file, err)
+	}
+
+	return p, nil
+}
+
+// New creates a policy of the rules, after validating them
+func New(rules []Rule) (*Policy, error) {
+	p := &Policy{Rules: rules}
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d (%s): %w", i, p.Rules[i].Name, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Apply patches the object with the rules that match it, one after the other, and returns the names of the applied rules.
+// Every rule matches the object as patched by the rules before it
+func (p *Policy) Apply(obj k8sruntime.Object) ([]string, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	applied := []string{}
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if !r.Match.matches(obj) {
+			continue
+		}
+
+		if err := r.apply(obj); err != nil {
+			return applied, fmt.Errorf("failed applying mutation rule \"%s\": %w", r.Name, err)
+		}
+
+		applied = append(applied, r.Name)
+	}
+
+	return applied, nil
+}
+
+func (r *Rule) compile() error {
+	if (len(r.JSONPatch) == 0) == (len(r.StrategicMerge) == 0) {
+		return errNoPatch
+	}
+
+	for _, kind := range r.Match.Kinds {
+		if kind != KindPod && kind != KindPersistentVolumeClaim {
+			return fmt.Errorf("unknown kind \"%s\"", kind)
+		}
+	}
+
+	for _, pattern := range r.Match.Images {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid image pattern \"%s\": %w", pattern, err)
+		}
+	}
+
+	if len(r.JSONPatch) > 0 {
+		patch, err := jsonpatch.DecodePatch(r.JSONPatch)
+		if err != nil {
+			return fmt.Errorf("invalid jsonPatch: %w", err)
+		}
+
+		r.jsonPatch = patch
+	}
+
+	return nil
+}
+
+// apply patches the JSON of the object, and decodes the result back into it
+func (r *Rule) apply(obj k8sruntime.Object) error {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch obj := obj.(type) {
+	case *v1.Pod:
+		patched, err = r.patch(original, &v1.Pod{})
+		if err == nil {
+			*obj = v1.Pod{}
+		}
+	case *v1.PersistentVolumeClaim:
+		patched, err = r.patch(original, &v1.PersistentVolumeClaim{})
+		if err == nil {
+			*obj = v1.PersistentVolumeClaim{}
+		}
+	default:
+		return fmt.Errorf("unsupported object %s", obj.GetObjectKind().GroupVersionKind())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(patched, obj)
+}
+
+func (r *Rule) patch(original []byte, dataStruct interface{}) ([]byte, error) {
+	if r.jsonPatch != nil {
+		return r.jsonPatch.Apply(original)
+	}
+
+	return strategicpatch.StrategicMergePatch(original, r.StrategicMerge, dataStruct)
+}
+
+func (m *Match) matches(obj k8sruntime.Object) bool {
+	var kind, namespace string
+	var labels map[string]string
+	var images []string
+	switch obj := obj.(type) {
+	case *v1.Pod:
+		kind, namespace, labels = KindPod, obj.Namespace, obj.Labels
+		for _, c := range obj.Spec.InitContainers {
+			images = append(images, c.Image)
+		}
+
+		for _, c := range obj.Spec.Containers {
+			images = append(images, c.Image)
+		}
+	case *v1.PersistentVolumeClaim:
+		kind, namespace, labels = KindPersistentVolumeClaim, obj.Namespace, obj.Labels
+	default:
+		return false
+	}
+
+	if len(m.Kinds) > 0 && !contains(m.Kinds, kind) {
+		return false
+	}
+
+	if len(m.Namespaces) > 0 && !contains(m.Namespaces, namespace) {
+		return false
+	}
+
+	for key, value := range m.Labels {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return len(m.Images) == 0 || matchesImage(m.Images, images)
+}
+
+// matchesImage returns true when one of the images, or one of the images with its registry, matches one of the patterns
+func matchesImage(patterns []string, images []string) bool {
+	for _, pattern := range patterns {
+		for _, image := range images {
+			if ok, _ := path.Match(pattern, image); ok {
+				return true
+			}
+
+			if ok, _ := path.Match(pattern, registry.Normalize(image)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const policyFile = `
+rules:
+- name: builds-pool
+  match:
+    kinds: [Pod]
+    namespaces: [codefresh]
+    images: ["docker.io/codefresh/*"]
+  strategicMerge:
+    metadata:
+      labels:
+        pool: builds
+    spec:
+      nodeSelector:
+        pool: builds
+      tolerations:
+      - key: builds
+        operator: Exists
+      containers:
+      - name: engine
+        resources:
+          requests:
+            cpu: 500m
+- name: fast-storage
+  match:
+    kinds: [PersistentVolumeClaim]
+  jsonPatch:
+  - op: add
+    path: /spec/storageClassName
+    value: fast
+- name: pooled
+  match:
+    labels:
+      pool: builds
+  jsonPatch:
+  - op: add
+    path: /spec/priorityClassName
+    value: builds
+`
+
+func newPod(namespace string, image string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "engine", Namespace: namespace},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "engine", Image: image}},
+		},
+	}
+}
+
+func loadPolicy(t *testing.T, data string) (*Policy, error) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return Load(file)
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	p, err := loadPolicy(t, policyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("should patch a matching pod, and match the next rules on the patched pod", func(t *testing.T) {
+		pod := newPod("codefresh", "docker.io/codefresh/engine:1.0")
+		applied, err := p.Apply(pod)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"builds-pool", "pooled"}, applied)
+		assert.Equal(t, map[string]string{"pool": "builds"}, pod.Spec.NodeSelector)
+		assert.Equal(t, []v1.Toleration{{Key: "builds", Operator: v1.TolerationOpExists}}, pod.Spec.Tolerations)
+		assert.Equal(t, "builds", pod.Spec.PriorityClassName)
+		// containers are merged by name
+		assert.Len(t, pod.Spec.Containers, 1)
+		assert.Equal(t, "docker.io/codefresh/engine:1.0", pod.Spec.Containers[0].Image)
+		assert.Equal(t, resource.MustParse("500m"), pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU])
+	})
+
+	t.Run("should match short image names with their registry", func(t *testing.T) {
+		pod := newPod("codefresh", "codefresh/engine:1.0")
+		applied, err := p.Apply(pod)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"builds-pool", "pooled"}, applied)
+	})
+
+	t.Run("should not patch a pod that does not match", func(t *testing.T) {
+		for _, pod := range []*v1.Pod{newPod("other", "docker.io/codefresh/engine:1.0"), newPod("codefresh", "quay.io/engine:1.0"), newPod("codefresh", "engine:1.0")} {
+			applied, err := p.Apply(pod)
+			assert.NoError(t, err)
+			assert.Empty(t, applied)
+			assert.Nil(t, pod.Spec.NodeSelector)
+		}
+	})
+
+	t.Run("should patch a pvc", func(t *testing.T) {
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "codefresh"}}
+		applied, err := p.Apply(pvc)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fast-storage"}, applied)
+		assert.Equal(t, "fast", *pvc.Spec.StorageClassName)
+	})
+}
+
+func TestPolicy_Apply_failure(t *testing.T) {
+	p, err := New([]Rule{{
+		Name:      "node-name",
+		JSONPatch: []byte(`[{"op":"test","path":"/spec/nodeName","value":"node"}]`),
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = p.Apply(newPod("codefresh", "alpine"))
+	assert.ErrorContains(t, err, "failed applying mutation rule \"node-name\"")
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"should fail on a rule without a patch": {
+			data:    "rules:\n- name: empty\n",
+			wantErr: "invalid rule 0 (empty): exactly one of jsonPatch or strategicMerge is required",
+		},
+		"should fail on a rule with both patches": {
+			data:    "rules:\n- name: both\n  jsonPatch: []\n  strategicMerge: {}\n",
+			wantErr: "invalid rule 0 (both): exactly one of jsonPatch or strategicMerge is required",
+		},
+		"should fail on an unknown kind": {
+			data:    "rules:\n- name: jobs\n  match:\n    kinds: [Job]\n  strategicMerge: {}\n",
+			wantErr: "invalid rule 0 (jobs): unknown kind \"Job\"",
+		},
+		"should fail on an invalid image pattern": {
+			data:    "rules:\n- name: images\n  match:\n    images: [\"[\"]\n  strategicMerge: {}\n",
+			wantErr: "invalid rule 0 (images): invalid image pattern \"[\"",
+		},
+		"should fail on an unknown field": {
+			data:    "rules:\n- name: typo\n  strategicMerg: {}\n",
+			wantErr: "failed parsing mutation policy",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadPolicy(t, tt.data)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,490 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/config"
+	"github.com/codefresh-io/go/venona/pkg/lifecycle"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	defaultResyncPeriod = time.Minute
+
+	reasonInvalidCredentials = "InvalidCredentials"
+	reasonConflict           = "Conflict"
+	reasonUnreachable        = "Unreachable"
+	reasonConnected          = "Connected"
+	reasonForbidden          = "Forbidden"
+	reasonAllowed            = "Allowed"
+)
+
+type (
+	// Options for creating a new Operator
+	Options struct {
+		// DynamicClient watches the RemoteRuntime resources and updates their status
+		DynamicClient dynamic.Interface
+		// KubeClient reads the credentials secrets
+		KubeClient kubernetes.Interface
+		// Namespace of the RemoteRuntime resources, all namespaces when empty
+		Namespace string
+		// Registry the runtimes are added to and removed from
+		Registry *runtime.Registry
+		// NewRuntime builds the runtime of a remote cluster, and the client used to check its connectivity and RBAC.
+		// ctx is done when the runtime is replaced or removed, its background work stops with it
+		NewRuntime func(ctx context.Context, cnf config.Config) (runtime.Runtime, kubernetes.Interface, error)
+		Logger     logger.Logger
+		// ResyncPeriod is how often every RemoteRuntime is reconciled again (default 1m),
+		// credentials rotated in the secrets are picked up on resync
+		ResyncPeriod time.Duration
+	}
+
+	// Operator reconciles RemoteRuntime resources into the runtimes of the agent
+	Operator struct {
+		resource   dynamic.NamespaceableResourceInterface
+		informer   cache.SharedIndexInformer
+		client     kubernetes.Interface
+		registry   *runtime.Registry
+		newRuntime func(ctx context.Context, cnf config.Config) (runtime.Runtime, kubernetes.Interface, error)
+		log        logger.Logger
+		queue      workqueue.TypedRateLimitingInterface[string]
+		mutex      sync.Mutex
+		// applied runtimes by RemoteRuntime key
+		applied map[string]*appliedRuntime
+	}
+
+	appliedRuntime struct {
+		runtimeName string
+		config      config.Config
+		client      kubernetes.Interface
+		cancel      context.CancelFunc
+	}
+)
+
+var (
+	errOptionsRequired       = errors.New("Options are required")
+	errDynamicClientRequired = errors.New("DynamicClient options is required")
+	errKubeClientRequired    = errors.New("KubeClient options is required")
+	errRegistryRequired      = errors.New("Registry options is required")
+	errNewRuntimeRequired    = errors.New("NewRuntime options is required")
+	errLoggerRequired        = errors.New("Logger options is required")
+	errCacheNotSynced        = errors.New("failed waiting for the remote runtimes cache to sync")
+	errSecretNameRequired    = errors.New("spec.credentialsSecret.name is required")
+)
+
+// New creates a new Operator
+func New(opts *Options) (*Operator, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+
+	resync := opts.ResyncPeriod
+	if resync <= 0 {
+		resync = defaultResyncPeriod
+	}
+
+	o := &Operator{
+		resource:   opts.DynamicClient.Resource(RemoteRuntimeResource),
+		informer:   dynamicinformer.NewFilteredDynamicInformer(opts.DynamicClient, RemoteRuntimeResource, opts.Namespace, resync, cache.Indexers{}, nil).Informer(),
+		client:     opts.KubeClient,
+		registry:   opts.Registry,
+		newRuntime: opts.NewRuntime,
+		log:        opts.Logger,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "remoteruntimes"},
+		),
+		applied: map[string]*appliedRuntime{},
+	}
+	_, err := o.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: o.enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldU, okOld := oldObj.(*unstructured.Unstructured)
+			newU, okNew := newObj.(*unstructured.Unstructured)
+			// status updates are ignored, a resync has the same resource version
+			if okOld && okNew && oldU.GetGeneration() == newU.GetGeneration() && oldU.GetResourceVersion() != newU.GetResourceVersion() {
+				return
+			}
+
+			o.enqueue(newObj)
+		},
+		DeleteFunc: o.enqueue,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Start watches the RemoteRuntime resources and reconciles them until ctx is done.
+// It returns once the existing resources are reconciled
+func (o *Operator) Start(ctx context.Context) error {
+	o.log.Info("starting remote runtimes operator")
+	go o.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), o.informer.HasSynced) {
+		return errCacheNotSynced
+	}
+
+	// the existing runtimes are added before returning, so they are ready when the agent pulls tasks
+	for _, key := range o.informer.GetStore().ListKeys() {
+		if err := o.Reconcile(ctx, key); err != nil {
+			o.log.Error("failed reconciling remote runtime", "remoteRuntime", key, "error", err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		o.queue.ShutDown()
+	}()
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for o.processNext(ctx) {
+		}
+	}, time.Second)
+
+	return nil
+}
+
+// Reconcile adds, replaces or removes the runtime of the RemoteRuntime with the given namespace/name key,
+// and writes its status conditions
+func (o *Operator) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	u, err := o.resource.Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		o.remove(key)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed getting remote runtime \"%s\": %w", key, err)
+	}
+
+	rr := &RemoteRuntime{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rr); err != nil {
+		return fmt.Errorf("failed converting remote runtime \"%s\": %w", key, err)
+	}
+
+	if rr.DeletionTimestamp != nil {
+		o.remove(key)
+		return nil
+	}
+
+	before := rr.Status.deepCopy()
+	runtimeName := rr.Spec.RuntimeName
+	if runtimeName == "" {
+		runtimeName = rr.Name
+	}
+
+	reconcileErr := o.reconcileRuntime(ctx, key, runtimeName, rr)
+	rr.Status.ObservedGeneration = rr.Generation
+	if applied := o.get(key); applied != nil {
+		if last := o.registry.LastTaskAt(applied.runtimeName); !last.IsZero() {
+			lastTaskAt := metav1.NewTime(last).Rfc3339Copy()
+			rr.Status.LastTaskAt = &lastTaskAt
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(*before, rr.Status) {
+		if err := o.updateStatus(ctx, rr); err != nil {
+			return errors.Join(reconcileErr, err)
+		}
+	}
+
+	return reconcileErr
+}
+
+func (o *Operator) reconcileRuntime(ctx context.Context, key, runtimeName string, rr *RemoteRuntime) error {
+	if owner := o.owner(key, runtimeName); owner != "" {
+		msg := fmt.Sprintf("runtime \"%s\" is already defined by %s", runtimeName, owner)
+		setCondition(rr, ConditionConnected, metav1.ConditionFalse, reasonConflict, msg)
+		setCondition(rr, ConditionRBACOk, metav1.ConditionUnknown, reasonConflict, msg)
+		o.log.Error("skipping remote runtime", "remoteRuntime", key, "error", msg)
+		return nil
+	}
+
+	cnf, err := o.loadConfig(ctx, runtimeName, rr)
+	if err == nil {
+		err = o.apply(ctx, key, cnf)
+	}
+
+	if err != nil {
+		o.remove(key)
+		setCondition(rr, ConditionConnected, metav1.ConditionFalse, reasonInvalidCredentials, redact.String(err.Error()))
+		setCondition(rr, ConditionRBACOk, metav1.ConditionUnknown, reasonInvalidCredentials, "")
+		return err
+	}
+
+	client := o.get(key).client
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		msg := redact.String(err.Error())
+		setCondition(rr, ConditionConnected, metav1.ConditionFalse, reasonUnreachable, msg)
+		setCondition(rr, ConditionRBACOk, metav1.ConditionUnknown, reasonUnreachable, "")
+		return fmt.Errorf("failed connecting to \"%s\": %s", cnf.Host, msg)
+	}
+
+	setCondition(rr, ConditionConnected, metav1.ConditionTrue, reasonConnected, fmt.Sprintf("connected to %s", cnf.Host))
+	if err := lifecycle.CheckAccess(ctx, client, rr.Spec.Namespace); err != nil {
+		// retrying does not fix missing permissions, they are checked again on resync
+		setCondition(rr, ConditionRBACOk, metav1.ConditionFalse, reasonForbidden, redact.String(err.Error()))
+		o.log.Warn("remote runtime is missing permissions", "remoteRuntime", key, "error", err)
+		return nil
+	}
+
+	setCondition(rr, ConditionRBACOk, metav1.ConditionTrue, reasonAllowed, "")
+	return nil
+}
+
+// loadConfig builds the runtime config from the spec and the credentials secret
+func (o *Operator) loadConfig(ctx context.Context, runtimeName string, rr *RemoteRuntime) (config.Config, error) {
+	ref := rr.Spec.CredentialsSecret
+	if ref.Name == "" {
+		return config.Config{}, errSecretNameRequired
+	}
+
+	secret, err := o.client.CoreV1().Secrets(rr.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return config.Config{}, fmt.Errorf("failed getting credentials secret \"%s\": %w", ref.Name, err)
+	}
+
+	tokenKey := valueOrDefault(ref.TokenKey, defaultTokenKey)
+	token := string(secret.Data[tokenKey])
+	if token == "" {
+		return config.Config{}, fmt.Errorf("credentials secret \"%s\" has no \"%s\" key", ref.Name, tokenKey)
+	}
+
+	hostKey := valueOrDefault(ref.HostKey, defaultHostKey)
+	host := rr.Spec.Host
+	if host == "" {
+		host = string(secret.Data[hostKey])
+	}
+
+	if host == "" {
+		return config.Config{}, fmt.Errorf("spec.host is required when credentials secret \"%s\" has no \"%s\" key", ref.Name, hostKey)
+	}
+
+	return config.Config{
+		Type:            "runtime",
+		Name:            runtimeName,
+		Host:            host,
+		Cert:            string(secret.Data[valueOrDefault(ref.CAKey, defaultCAKey)]),
+		Token:           token,
+		MutationPolicy:  rr.Spec.MutationPolicy,
+		AdmissionPolicy: rr.Spec.AdmissionPolicy,
+		Registry:        rr.Spec.Registry,
+		PVCPool:         rr.Spec.PVCPool.config(),
+		Deletion:        rr.Spec.Deletion.config(),
+		Client:          rr.Spec.Client.config(),
+	}, nil
+}
+
+// apply adds the runtime to the registry, it is only rebuilt when the config changed
+func (o *Operator) apply(ctx context.Context, key string, cnf config.Config) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	prev, ok := o.applied[key]
+	if ok && equality.Semantic.DeepEqual(prev.config, cnf) {
+		return nil
+	}
+
+	redact.AddSecret(cnf.Token)
+	runtimeCtx, cancel := context.WithCancel(ctx)
+	rt, client, err := o.newRuntime(runtimeCtx, cnf)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed creating runtime \"%s\": %w", cnf.Name, err)
+	}
+
+	if ok {
+		prev.cancel()
+		if prev.runtimeName != cnf.Name {
+			o.registry.Delete(prev.runtimeName)
+		}
+	}
+
+	o.registry.Set(cnf.Name, rt)
+	o.applied[key] = &appliedRuntime{
+		runtimeName: cnf.Name,
+		config:      cnf,
+		client:      client,
+		cancel:      cancel,
+	}
+	o.log.Info("added remote runtime", "remoteRuntime", key, "runtime", cnf.Name, "host", cnf.Host)
+	return nil
+}
+
+func (o *Operator) remove(key string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	applied, ok := o.applied[key]
+	if !ok {
+		return
+	}
+
+	applied.cancel()
+	o.registry.Delete(applied.runtimeName)
+	delete(o.applied, key)
+	o.log.Info("removed remote runtime", "remoteRuntime", key, "runtime", applied.runtimeName)
+}
+
+func (o *Operator) get(key string) *appliedRuntime {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.applied[key]
+}
+
+// owner returns what else defines the runtime name, empty when it is free to use by the given key
+func (o *Operator) owner(key, runtimeName string) string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	for k, applied := range o.applied {
+		if applied.runtimeName == runtimeName {
+			if k == key {
+				return ""
+			}
+
+			return fmt.Sprintf("remote runtime \"%s\"", k)
+		}
+	}
+
+	if _, ok := o.registry.Get(runtimeName); ok {
+		return "the runner config"
+	}
+
+	return ""
+}
+
+func (o *Operator) updateStatus(ctx context.Context, rr *RemoteRuntime) error {
+	obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(rr)
+	if err != nil {
+		return fmt.Errorf("failed converting remote runtime \"%s\": %w", rr.Name, err)
+	}
+
+	_, err = o.resource.Namespace(rr.Namespace).UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed updating status of remote runtime \"%s\": %w", rr.Name, err)
+	}
+
+	return nil
+}
+
+func (o *Operator) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		o.log.Error("failed getting remote runtime key", "error", err)
+		return
+	}
+
+	o.queue.Add(key)
+}
+
+func (o *Operator) processNext(ctx context.Context) bool {
+	key, shutdown := o.queue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer o.queue.Done(key)
+	if err := o.Reconcile(ctx, key); err != nil {
+		o.log.Error("failed reconciling remote runtime", "remoteRuntime", key, "error", err)
+		o.queue.AddRateLimited(key)
+		return true
+	}
+
+	o.queue.Forget(key)
+	return true
+}
+
+func setCondition(rr *RemoteRuntime, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&rr.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: rr.Generation,
+	})
+}
+
+func (s RemoteRuntimeStatus) deepCopy() *RemoteRuntimeStatus {
+	res := s
+	res.Conditions = append([]metav1.Condition(nil), s.Conditions...)
+	if s.LastTaskAt != nil {
+		lastTaskAt := *s.LastTaskAt
+		res.LastTaskAt = &lastTaskAt
+	}
+
+	return &res
+}
+
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+
+	return value
+}
+
+func checkOptions(opts *Options) error {
+	if opts == nil {
+		return errOptionsRequired
+	}
+
+	if opts.DynamicClient == nil {
+		return errDynamicClientRequired
+	}
+
+	if opts.KubeClient == nil {
+		return errKubeClientRequired
+	}
+
+	if opts.Registry == nil {
+		return errRegistryRequired
+	}
+
+	if opts.NewRuntime == nil {
+		return errNewRuntimeRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	return nil
+}
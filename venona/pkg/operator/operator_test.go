@@ -0,0 +1,364 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/config"
+	kube "github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/pvcpool"
+	"github.com/codefresh-io/go/venona/pkg/registry"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const namespace = "some-namespace"
+
+type remoteCluster struct {
+	unreachable bool
+	denied      bool
+	configs     []config.Config
+	contexts    []context.Context
+}
+
+// newRuntime returns fake runtimes, with a client that answers the connectivity and RBAC checks
+func (c *remoteCluster) newRuntime(ctx context.Context, cnf config.Config) (runtime.Runtime, kubernetes.Interface, error) {
+	c.configs = append(c.configs, cnf)
+	c.contexts = append(c.contexts, ctx)
+	client := fake.NewClientset()
+	client.PrependReactor("get", "version", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		if c.unreachable {
+			return true, nil, errors.New("connection refused")
+		}
+
+		return false, nil, nil
+	})
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = !c.denied
+		return true, review, nil
+	})
+	return runtime.New(runtime.Options{}), client, nil
+}
+
+func remoteRuntime(name string, spec RemoteRuntimeSpec) *unstructured.Unstructured {
+	obj, _ := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(&RemoteRuntime{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: Group + "/" + Version,
+			Kind:       Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+		},
+		Spec: spec,
+	})
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func credentials(name string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data: map[string][]byte{
+			"token":  []byte("some-token"),
+			"ca.crt": []byte("some-ca"),
+		},
+	}
+}
+
+func newOperator(t *testing.T, cluster *remoteCluster, registry *runtime.Registry, objects ...k8sruntime.Object) (*Operator, *dynamicfake.FakeDynamicClient) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[schema.GroupVersionResource]string{
+		RemoteRuntimeResource: Kind + "List",
+	}, objects...)
+	o, err := New(&Options{
+		DynamicClient: dynamicClient,
+		KubeClient:    fake.NewClientset(credentials("some-credentials")),
+		Namespace:     namespace,
+		Registry:      registry,
+		NewRuntime:    cluster.newRuntime,
+		Logger:        logger.New(logger.Options{}),
+		ResyncPeriod:  time.Hour,
+	})
+	assert.NoError(t, err)
+	return o, dynamicClient
+}
+
+func getStatus(t *testing.T, client *dynamicfake.FakeDynamicClient, name string) RemoteRuntimeStatus {
+	u, err := client.Resource(RemoteRuntimeResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	rr := &RemoteRuntime{}
+	assert.NoError(t, k8sruntime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rr))
+	return rr.Status
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		opts    *Options
+		wantErr error
+	}{
+		"should fail without options": {
+			wantErr: errOptionsRequired,
+		},
+		"should fail without dynamic client": {
+			opts:    &Options{},
+			wantErr: errDynamicClientRequired,
+		},
+		"should fail without registry": {
+			opts: &Options{
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(k8sruntime.NewScheme()),
+				KubeClient:    fake.NewClientset(),
+			},
+			wantErr: errRegistryRequired,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestOperator_Reconcile(t *testing.T) {
+	spec := RemoteRuntimeSpec{
+		RuntimeName:       "some-cluster/some-namespace",
+		Host:              "https://some-host",
+		Namespace:         "workflows",
+		CredentialsSecret: SecretReference{Name: "some-credentials"},
+	}
+	tests := map[string]struct {
+		cluster        remoteCluster
+		spec           func(RemoteRuntimeSpec) RemoteRuntimeSpec
+		static         []string
+		wantErr        string
+		wantRuntime    bool
+		wantConditions map[string]metav1.Condition
+	}{
+		"should add the runtime": {
+			wantRuntime: true,
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionTrue, Reason: reasonConnected},
+				ConditionRBACOk:    {Status: metav1.ConditionTrue, Reason: reasonAllowed},
+			},
+		},
+		"should report missing permissions": {
+			cluster:     remoteCluster{denied: true},
+			wantRuntime: true,
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionTrue, Reason: reasonConnected},
+				ConditionRBACOk:    {Status: metav1.ConditionFalse, Reason: reasonForbidden},
+			},
+		},
+		"should report an unreachable cluster": {
+			cluster:     remoteCluster{unreachable: true},
+			wantErr:     "failed connecting to \"https://some-host\": connection refused",
+			wantRuntime: true,
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionFalse, Reason: reasonUnreachable},
+				ConditionRBACOk:    {Status: metav1.ConditionUnknown, Reason: reasonUnreachable},
+			},
+		},
+		"should fail without the credentials secret": {
+			spec: func(s RemoteRuntimeSpec) RemoteRuntimeSpec {
+				s.CredentialsSecret.Name = "missing"
+				return s
+			},
+			wantErr: "failed getting credentials secret \"missing\": secrets \"missing\" not found",
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionFalse, Reason: reasonInvalidCredentials},
+				ConditionRBACOk:    {Status: metav1.ConditionUnknown, Reason: reasonInvalidCredentials},
+			},
+		},
+		"should fail without a host": {
+			spec: func(s RemoteRuntimeSpec) RemoteRuntimeSpec {
+				s.Host = ""
+				return s
+			},
+			wantErr: "spec.host is required when credentials secret \"some-credentials\" has no \"host\" key",
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionFalse, Reason: reasonInvalidCredentials},
+			},
+		},
+		"should not replace a runtime from the config dir": {
+			static: []string{"some-cluster/some-namespace"},
+			wantConditions: map[string]metav1.Condition{
+				ConditionConnected: {Status: metav1.ConditionFalse, Reason: reasonConflict},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := spec
+			if tt.spec != nil {
+				s = tt.spec(s)
+			}
+
+			registry := runtime.NewRegistry(nil)
+			for _, name := range tt.static {
+				registry.Set(name, runtime.New(runtime.Options{}))
+			}
+
+			cluster := tt.cluster
+			o, client := newOperator(t, &cluster, registry, remoteRuntime("some-rr", s))
+			err := o.Reconcile(context.Background(), namespace+"/some-rr")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.wantRuntime {
+				assert.Equal(t, []string{"some-cluster/some-namespace"}, registry.Names())
+				assert.Equal(t, config.Config{
+					Type:  "runtime",
+					Name:  "some-cluster/some-namespace",
+					Host:  "https://some-host",
+					Cert:  "some-ca",
+					Token: "some-token",
+				}, cluster.configs[0])
+			} else {
+				assert.Empty(t, cluster.configs)
+			}
+
+			status := getStatus(t, client, "some-rr")
+			assert.Equal(t, int64(1), status.ObservedGeneration)
+			for conditionType, want := range tt.wantConditions {
+				got := meta.FindStatusCondition(status.Conditions, conditionType)
+				if assert.NotNil(t, got, conditionType) {
+					assert.Equal(t, want.Status, got.Status, conditionType)
+					assert.Equal(t, want.Reason, got.Reason, conditionType)
+				}
+			}
+		})
+	}
+}
+
+func TestOperator_Reconcile_lifecycle(t *testing.T) {
+	cluster := &remoteCluster{}
+	registry := runtime.NewRegistry(nil)
+	rr := remoteRuntime("some-rr", RemoteRuntimeSpec{
+		Host:              "https://some-host",
+		CredentialsSecret: SecretReference{Name: "some-credentials"},
+	})
+	o, client := newOperator(t, cluster, registry, rr)
+	resource := client.Resource(RemoteRuntimeResource).Namespace(namespace)
+	key := namespace + "/some-rr"
+	ctx := context.Background()
+
+	// the resource name is the default runtime name
+	assert.NoError(t, o.Reconcile(ctx, key))
+	assert.Equal(t, []string{"some-rr"}, registry.Names())
+
+	// an unchanged config does not rebuild the runtime
+	assert.NoError(t, o.Reconcile(ctx, key))
+	assert.Len(t, cluster.configs, 1)
+
+	// the last task is written to the status
+	rt, _ := registry.Get("some-rr")
+	_ = rt.HandleTask(ctx, &task.Task{})
+	assert.NoError(t, o.Reconcile(ctx, key))
+	assert.NotNil(t, getStatus(t, client, "some-rr").LastTaskAt)
+
+	// renaming replaces the runtime
+	u, _ := resource.Get(ctx, "some-rr", metav1.GetOptions{})
+	assert.NoError(t, unstructured.SetNestedField(u.Object, "renamed", "spec", "runtimeName"))
+	_, err := resource.Update(ctx, u, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, o.Reconcile(ctx, key))
+	assert.Equal(t, []string{"renamed"}, registry.Names())
+	assert.Len(t, cluster.configs, 2)
+	assert.Error(t, cluster.contexts[0].Err(), "the replaced runtime is stopped")
+
+	// deleting removes the runtime
+	assert.NoError(t, resource.Delete(ctx, "some-rr", metav1.DeleteOptions{}))
+	assert.NoError(t, o.Reconcile(ctx, key))
+	assert.Empty(t, registry.Names())
+	assert.Error(t, cluster.contexts[1].Err(), "the removed runtime is stopped")
+}
+
+func TestOperator_Reconcile_settings(t *testing.T) {
+	cluster := &remoteCluster{}
+	reject := true
+	o, _ := newOperator(t, cluster, runtime.NewRegistry(nil), remoteRuntime("some-rr", RemoteRuntimeSpec{
+		Host:              "https://some-host",
+		CredentialsSecret: SecretReference{Name: "some-credentials"},
+		MutationPolicy:    "/etc/policies/mutation.yaml",
+		Registry:          &registry.Config{PullSecrets: []string{"some-pull-secret"}},
+		PVCPool: &PVCPoolSpec{
+			Namespace: "workflows",
+			Classes:   []pvcpool.Class{{StorageClass: "fast", Size: "10Gi", Count: 2}},
+			Interval:  metav1.Duration{Duration: 30 * time.Second},
+		},
+		Deletion: &DeletionSpec{WaitTimeout: metav1.Duration{Duration: 20 * time.Second}},
+		Client: &ClientSpec{
+			QPS:                   10,
+			Burst:                 20,
+			RejectTLSUnauthorized: &reject,
+			Timeout:               metav1.Duration{Duration: 5 * time.Second},
+		},
+	}))
+	key := namespace + "/some-rr"
+
+	assert.NoError(t, o.Reconcile(context.Background(), key))
+	assert.NoError(t, o.Reconcile(context.Background(), key))
+	// settings behind pointers do not rebuild an unchanged runtime
+	assert.Len(t, cluster.configs, 1)
+	cnf := cluster.configs[0]
+	assert.Equal(t, "/etc/policies/mutation.yaml", cnf.MutationPolicy)
+	assert.Equal(t, &registry.Config{PullSecrets: []string{"some-pull-secret"}}, cnf.Registry)
+	assert.Equal(t, &pvcpool.Config{
+		Namespace: "workflows",
+		Classes:   []pvcpool.Class{{StorageClass: "fast", Size: "10Gi", Count: 2}},
+		Interval:  30 * time.Second,
+	}, cnf.PVCPool)
+	assert.Equal(t, &kube.DeletionConfig{WaitTimeout: 20 * time.Second}, cnf.Deletion)
+	assert.Equal(t, &kube.ClientConfig{QPS: 10, Burst: 20, RejectTLSUnauthorized: &reject, Timeout: 5 * time.Second}, cnf.Client)
+}
+
+func TestOperator_Start(t *testing.T) {
+	registry := runtime.NewRegistry(nil)
+	o, client := newOperator(t, &remoteCluster{}, registry)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, o.Start(ctx))
+
+	_, err := client.Resource(RemoteRuntimeResource).Namespace(namespace).Create(ctx, remoteRuntime("some-rr", RemoteRuntimeSpec{
+		Host:              "https://some-host",
+		CredentialsSecret: SecretReference{Name: "some-credentials"},
+	}), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(registry.Names()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/pvcpool"
+	"github.com/codefresh-io/go/venona/pkg/registry"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// Group of the RemoteRuntime resource
+	Group = "codefresh.io"
+	// Version of the RemoteRuntime resource
+	Version = "v1alpha1"
+	// Kind of the RemoteRuntime resource
+	Kind = "RemoteRuntime"
+
+	// ConditionConnected is true when the API server of the remote cluster is reachable with the credentials
+	ConditionConnected = "Connected"
+	// ConditionRBACOk is true when the credentials are allowed everything the runner does in the namespace
+	ConditionRBACOk = "RBACOk"
+
+	defaultTokenKey = "token"
+	defaultCAKey    = "ca.crt"
+	defaultHostKey  = "host"
+)
+
+// RemoteRuntimeResource of the RemoteRuntime CRD, see remoteruntime-crd.yaml
+var RemoteRuntimeResource = schema.GroupVersionResource{
+	Group:    Group,
+	Version:  Version,
+	Resource: "remoteruntimes",
+}
+
+type (
+	// RemoteRuntime defines a runtime environment that runs workflows on a remote cluster,
+	// it replaces a *.runtime.yaml file in the config dir
+	RemoteRuntime struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+
+		Spec   RemoteRuntimeSpec   `json:"spec"`
+		Status RemoteRuntimeStatus `json:"status,omitempty"`
+	}
+
+	// RemoteRuntimeSpec is the desired state of a RemoteRuntime
+	RemoteRuntimeSpec struct {
+		// RuntimeName is the runtime environment name in Codefresh, defaults to the resource name
+		RuntimeName string `json:"runtimeName,omitempty"`
+		// Host of the remote cluster API server, defaults to the host key of the credentials secret
+		Host string `json:"host,omitempty"`
+		// Namespace in the remote cluster where the workflows run, the RBAC is verified in it
+		Namespace string `json:"namespace,omitempty"`
+		// CredentialsSecret in the namespace of the RemoteRuntime, holding the token and CA of the remote cluster
+		CredentialsSecret SecretReference `json:"credentialsSecret"`
+
+		// The settings below match the sections of a runtime config file. File paths are in the runner pod
+
+		// MutationPolicy is the path of the mutation policy file of the runtime
+		MutationPolicy string `json:"mutationPolicy,omitempty"`
+		// AdmissionPolicy is the path of the admission policy file of the runtime
+		AdmissionPolicy string `json:"admissionPolicy,omitempty"`
+		// Registry rewrites the images of the pods of the runtime
+		Registry *registry.Config `json:"registry,omitempty"`
+		// PVCPool keeps pre-provisioned PVCs for the CreatePvc tasks of the runtime
+		PVCPool *PVCPoolSpec `json:"pvcPool,omitempty"`
+		// Deletion waits until the deleted pods and PVCs of the runtime are gone, and escalates the stuck ones
+		Deletion *DeletionSpec `json:"deletion,omitempty"`
+		// Client tunes the Kubernetes client of the runtime, over the client flags of the agent
+		Client *ClientSpec `json:"client,omitempty"`
+	}
+
+	// PVCPoolSpec is the pvcpool.Config of a RemoteRuntime, with durations like "1m"
+	PVCPoolSpec struct {
+		Namespace string          `json:"namespace"`
+		Classes   []pvcpool.Class `json:"classes"`
+		Interval  metav1.Duration `json:"interval,omitempty"`
+		ClaimTTL  metav1.Duration `json:"claimTTL,omitempty"`
+	}
+
+	// DeletionSpec is the kubernetes.DeletionConfig of a RemoteRuntime, with durations like "1m"
+	DeletionSpec struct {
+		WaitTimeout           metav1.Duration `json:"waitTimeout,omitempty"`
+		ForceAfter            metav1.Duration `json:"forceAfter,omitempty"`
+		RemoveFinalizersAfter metav1.Duration `json:"removeFinalizersAfter,omitempty"`
+	}
+
+	// ClientSpec is the kubernetes.ClientConfig of a RemoteRuntime, with durations like "1m"
+	ClientSpec struct {
+		QPS                   float32                 `json:"qps,omitempty"`
+		Burst                 int                     `json:"burst,omitempty"`
+		ForceDeletePvc        *bool                   `json:"forceDeletePvc,omitempty"`
+		RejectTLSUnauthorized *bool                   `json:"rejectTLSUnauthorized,omitempty"`
+		Timeout               metav1.Duration         `json:"timeout,omitempty"`
+		UserAgent             string                  `json:"userAgent,omitempty"`
+		ProxyURL              string                  `json:"proxyURL,omitempty"`
+		Impersonate           *kubernetes.Impersonate `json:"impersonate,omitempty"`
+	}
+
+	// SecretReference to the credentials of a remote cluster.
+	// A service account token secret can be referenced as is
+	SecretReference struct {
+		Name string `json:"name"`
+		// TokenKey defaults to "token"
+		TokenKey string `json:"tokenKey,omitempty"`
+		// CAKey defaults to "ca.crt"
+		CAKey string `json:"caKey,omitempty"`
+		// HostKey defaults to "host", only used when spec.host is not set
+		HostKey string `json:"hostKey,omitempty"`
+	}
+
+	// RemoteRuntimeStatus is the observed state of a RemoteRuntime
+	RemoteRuntimeStatus struct {
+		ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+		Conditions         []metav1.Condition `json:"conditions,omitempty"`
+		// LastTaskAt is when the runtime last handled a task, updated on every resync
+		LastTaskAt *metav1.Time `json:"lastTaskAt,omitempty"`
+	}
+)
+
+func (s *PVCPoolSpec) config() *pvcpool.Config {
+	if s == nil {
+		return nil
+	}
+
+	return &pvcpool.Config{
+		Namespace: s.Namespace,
+		Classes:   s.Classes,
+		Interval:  s.Interval.Duration,
+		ClaimTTL:  s.ClaimTTL.Duration,
+	}
+}
+
+func (s *DeletionSpec) config() *kubernetes.DeletionConfig {
+	if s == nil {
+		return nil
+	}
+
+	return &kubernetes.DeletionConfig{
+		WaitTimeout:           s.WaitTimeout.Duration,
+		ForceAfter:            s.ForceAfter.Duration,
+		RemoveFinalizersAfter: s.RemoveFinalizersAfter.Duration,
+	}
+}
+
+func (s *ClientSpec) config() *kubernetes.ClientConfig {
+	if s == nil {
+		return nil
+	}
+
+	return &kubernetes.ClientConfig{
+		QPS:                   s.QPS,
+		Burst:                 s.Burst,
+		ForceDeletePvc:        s.ForceDeletePvc,
+		RejectTLSUnauthorized: s.RejectTLSUnauthorized,
+		Timeout:               s.Timeout.Duration,
+		UserAgent:             s.UserAgent,
+		ProxyURL:              s.ProxyURL,
+		Impersonate:           s.Impersonate,
+	}
+}
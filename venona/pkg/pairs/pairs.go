@@ -0,0 +1,44 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pairs parses the comma separated key=value lists of the flags, e.g. "k8s=debug,agent=warn".
+package pairs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse calls fn with the trimmed key and value of each pair of a comma separated list, empty items are skipped.
+// name and format describe a pair in the errors, e.g. "invalid module level \"k8s\", expected module=level"
+func Parse(s, name, format string, fn func(key, value string) error) error {
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid %s \"%s\", expected %s", name, pair, format)
+		}
+
+		if err := fn(key, value); err != nil {
+			return fmt.Errorf("invalid %s \"%s\", %w", name, pair, err)
+		}
+	}
+
+	return nil
+}
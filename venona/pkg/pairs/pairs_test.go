@@ -0,0 +1,66 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		"should skip empty items and trim the pairs": {
+			input: " a = 1 ,, b=2,",
+			want:  map[string]string{"a": "1", "b": "2"},
+		},
+		"should fail on a missing value separator": {
+			input:   "a=1,b",
+			wantErr: "invalid some pair \"b\", expected key=value",
+		},
+		"should fail on an empty key": {
+			input:   "=1",
+			wantErr: "invalid some pair \"=1\", expected key=value",
+		},
+		"should wrap the error of the pair": {
+			input:   "a=bad",
+			wantErr: "invalid some pair \"a=bad\", some error",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := map[string]string{}
+			err := Parse(tt.input, "some pair", "key=value", func(key, value string) error {
+				if value == "bad" {
+					return errors.New("some error")
+				}
+
+				got[key] = value
+				return nil
+			})
+			if err != nil || tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,462 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pvcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/metrics"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LabelPool is the name of the pool class of a pooled PVC
+	LabelPool = "codefresh.io/pvc-pool"
+	// LabelState is either StateAvailable or StateClaimed
+	LabelState = "codefresh.io/pvc-pool-state"
+	// AnnotationClaim is the name of the PVC of the CreatePvc task a claimed PVC serves
+	AnnotationClaim = "codefresh.io/pvc-pool-claim"
+	// AnnotationWorkflow is the workflow a claimed PVC serves
+	AnnotationWorkflow = "codefresh.io/pvc-pool-workflow"
+	// AnnotationClaimedAt is when the PVC was claimed, in RFC3339
+	AnnotationClaimedAt = "codefresh.io/pvc-pool-claimed-at"
+
+	StateAvailable = "available"
+	StateClaimed   = "claimed"
+
+	defaultInterval = time.Minute
+)
+
+type (
+	// Config of the PVC pool of a runtime, in the pvcPool section of the runtime config
+	Config struct {
+		// Namespace of the pooled PVCs, only the CreatePvc tasks of this namespace are served from the pool
+		Namespace string  `yaml:"namespace" json:"namespace"`
+		Classes   []Class `yaml:"classes" json:"classes"`
+		// Interval of replenishing and garbage-collecting the pool, 1m when empty
+		Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+		// ClaimTTL deletes the claimed PVCs that were not deleted by their workflow after it, 0 never deletes them
+		ClaimTTL time.Duration `yaml:"claimTTL,omitempty" json:"claimTTL,omitempty"`
+	}
+
+	// Class keeps Count available PVCs of a storage class and size. A CreatePvc task matches the class when it has
+	// the same storage class, requests at most Size, and only requests access modes of the class
+	Class struct {
+		// Name of the class in the labels of its PVCs, the storage class when empty
+		Name         string                          `yaml:"name,omitempty" json:"name,omitempty"`
+		StorageClass string                          `yaml:"storageClass" json:"storageClass"`
+		Size         string                          `yaml:"size" json:"size"`
+		Count        int                             `yaml:"count" json:"count"`
+		AccessModes  []v1.PersistentVolumeAccessMode `yaml:"accessModes,omitempty" json:"accessModes,omitempty"`
+	}
+
+	// Pool binds pre-provisioned PVCs to the CreatePvc tasks that match them, and keeps replenishing them
+	Pool struct {
+		client    kubernetes.Interface
+		log       logger.Logger
+		namespace string
+		classes   []class
+		interval  time.Duration
+		claimTTL  time.Duration
+		mutex     sync.Mutex
+		// claims maps the names of the PVCs of CreatePvc tasks to the pooled PVCs that serve them
+		claims    map[string]string
+		replenish chan struct{}
+	}
+
+	class struct {
+		name         string
+		storageClass string
+		size         resource.Quantity
+		count        int
+		accessModes  []v1.PersistentVolumeAccessMode
+	}
+)
+
+var (
+	errNamespaceRequired    = errors.New("pvc pool namespace is required")
+	errStorageClassRequired = errors.New("pvc pool classes require a storage class")
+	errCountRequired        = errors.New("pvc pool classes require a positive count")
+)
+
+// LoadConfig reads a YAML file of a pool config
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed parsing pvc pool config \"%s\": %w", file, err)
+	}
+
+	return cfg, nil
+}
+
+// New creates the pool of the config, it is only replenished once Run is called
+func New(client kubernetes.Interface, cfg *Config, log logger.Logger) (*Pool, error) {
+	if cfg.Namespace == "" {
+		return nil, errNamespaceRequired
+	}
+
+	p := &Pool{
+		client:    client,
+		log:       log,
+		namespace: cfg.Namespace,
+		interval:  cfg.Interval,
+		claimTTL:  cfg.ClaimTTL,
+		claims:    map[string]string{},
+		replenish: make(chan struct{}, 1),
+	}
+	if p.interval <= 0 {
+		p.interval = defaultInterval
+	}
+
+	names := map[string]struct{}{}
+	for _, c := range cfg.Classes {
+		if c.StorageClass == "" {
+			return nil, errStorageClassRequired
+		}
+
+		if c.Count <= 0 {
+			return nil, errCountRequired
+		}
+
+		size, err := resource.ParseQuantity(c.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing size of pvc pool class \"%s\": %w", c.StorageClass, err)
+		}
+
+		cl := class{
+			name:         c.Name,
+			storageClass: c.StorageClass,
+			size:         size,
+			count:        c.Count,
+			accessModes:  c.AccessModes,
+		}
+		if cl.name == "" {
+			cl.name = c.StorageClass
+		}
+
+		if len(cl.accessModes) == 0 {
+			cl.accessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+		}
+
+		if _, ok := names[cl.name]; ok {
+			return nil, fmt.Errorf("duplicate pvc pool class \"%s\"", cl.name)
+		}
+
+		names[cl.name] = struct{}{}
+		p.classes = append(p.classes, cl)
+	}
+
+	return p, nil
+}
+
+// Run replenishes and garbage-collects the pool every interval, and right after a PVC is claimed, until ctx is done
+func (p *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Sync(ctx); err != nil {
+			p.log.Error("Failed syncing pvc pool", "namespace", p.namespace, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.replenish:
+		}
+	}
+}
+
+// Sync creates the missing available PVCs of every class, and deletes the ones that are not needed: available PVCs
+// beyond the count or of a class that is no longer configured, lost PVCs, and claimed PVCs older than the claim TTL.
+// It also recovers the claims of the claimed PVCs, e.g. after a restart
+func (p *Pool) Sync(ctx context.Context) error {
+	list, err := p.client.CoreV1().PersistentVolumeClaims(p.namespace).List(ctx, metav1.ListOptions{LabelSelector: LabelPool})
+	if err != nil {
+		return fmt.Errorf("failed listing pooled PVCs: %w", err)
+	}
+
+	available := map[string][]*v1.PersistentVolumeClaim{}
+	claims := map[string]string{}
+	var collect []*v1.PersistentVolumeClaim
+	for i := range list.Items {
+		pvc := &list.Items[i]
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch {
+		case pvc.Status.Phase == v1.ClaimLost:
+			collect = append(collect, pvc)
+		case pvc.Labels[LabelState] == StateClaimed:
+			if p.claimExpired(pvc) {
+				collect = append(collect, pvc)
+			} else if name := pvc.Annotations[AnnotationClaim]; name != "" {
+				claims[name] = pvc.Name
+			}
+		case p.class(pvc.Labels[LabelPool]) == nil:
+			collect = append(collect, pvc)
+		default:
+			available[pvc.Labels[LabelPool]] = append(available[pvc.Labels[LabelPool]], pvc)
+		}
+	}
+
+	p.mutex.Lock()
+	for name, pooled := range claims {
+		p.claims[name] = pooled
+	}
+	p.mutex.Unlock()
+
+	for _, c := range p.classes {
+		pvcs := available[c.name]
+		for i := len(pvcs); i < c.count; i++ {
+			if err := p.provision(ctx, c); err != nil {
+				return err
+			}
+		}
+
+		if len(pvcs) > c.count {
+			sortByPreference(pvcs)
+			collect = append(collect, pvcs[c.count:]...)
+		}
+
+		metrics.SetPVCPoolAvailable(c.name, min(len(pvcs), c.count))
+	}
+
+	for _, pvc := range collect {
+		err := p.client.CoreV1().PersistentVolumeClaims(p.namespace).Delete(ctx, pvc.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed deleting pooled PVC \"%s\": %w", pvc.Name, err)
+		}
+
+		p.log.Info("Deleted pooled PVC", "namespace", p.namespace, "name", pvc.Name, "pool", pvc.Labels[LabelPool], "state", pvc.Labels[LabelState], "phase", pvc.Status.Phase)
+		metrics.IncPVCPoolCollected(pvc.Labels[LabelPool])
+	}
+
+	return nil
+}
+
+// Claim binds an available PVC of the class that matches the PVC of a CreatePvc task, instead of creating it.
+// It returns the name of the pooled PVC, or an empty name when the PVC does not match a class or the class has
+// no available PVC
+func (p *Pool) Claim(ctx context.Context, pvc *v1.PersistentVolumeClaim, workflow string) (string, error) {
+	if p == nil || pvc.Namespace != p.namespace {
+		return "", nil
+	}
+
+	c := p.match(pvc)
+	if c == nil {
+		return "", nil
+	}
+
+	selector := labels.Set{LabelPool: c.name, LabelState: StateAvailable}.String()
+	list, err := p.client.CoreV1().PersistentVolumeClaims(p.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", fmt.Errorf("failed listing pooled PVCs: %w", err)
+	}
+
+	candidates := make([]*v1.PersistentVolumeClaim, 0, len(list.Items))
+	for i := range list.Items {
+		if list.Items[i].DeletionTimestamp == nil && list.Items[i].Status.Phase != v1.ClaimLost {
+			candidates = append(candidates, &list.Items[i])
+		}
+	}
+
+	sortByPreference(candidates)
+	for _, pooled := range candidates {
+		claimed := pooled.DeepCopy()
+		for k, v := range pvc.Labels {
+			if _, ok := claimed.Labels[k]; !ok {
+				claimed.Labels[k] = v
+			}
+		}
+
+		if claimed.Annotations == nil {
+			claimed.Annotations = map[string]string{}
+		}
+
+		for k, v := range pvc.Annotations {
+			claimed.Annotations[k] = v
+		}
+
+		claimed.Labels[LabelState] = StateClaimed
+		claimed.Annotations[AnnotationClaim] = pvc.Name
+		claimed.Annotations[AnnotationWorkflow] = workflow
+		claimed.Annotations[AnnotationClaimedAt] = time.Now().UTC().Format(time.RFC3339)
+		// the resource version of the list makes concurrent claims of the same PVC conflict
+		_, err := p.client.CoreV1().PersistentVolumeClaims(p.namespace).Update(ctx, claimed, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("failed claiming pooled PVC \"%s\": %w", pooled.Name, err)
+		}
+
+		p.mutex.Lock()
+		p.claims[pvc.Name] = pooled.Name
+		p.mutex.Unlock()
+		p.triggerReplenish()
+		metrics.IncPVCPoolClaims(c.name, true)
+		return pooled.Name, nil
+	}
+
+	metrics.IncPVCPoolClaims(c.name, false)
+	p.triggerReplenish()
+	return "", nil
+}
+
+// Resolve returns the name of the pooled PVC that serves the PVC of a CreatePvc task, false when it is not pooled
+func (p *Pool) Resolve(namespace string, name string) (string, bool) {
+	if p == nil || namespace != p.namespace {
+		return "", false
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	pooled, ok := p.claims[name]
+	return pooled, ok
+}
+
+// Release forgets the pooled PVC that served the PVC of a CreatePvc task, once it is deleted
+func (p *Pool) Release(namespace string, name string) {
+	if p == nil || namespace != p.namespace {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.claims, name)
+}
+
+func (p *Pool) provision(ctx context.Context, c class) error {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("pvc-pool-%s-", c.name),
+			Namespace:    p.namespace,
+			Labels: map[string]string{
+				LabelPool:  c.name,
+				LabelState: StateAvailable,
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &c.storageClass,
+			AccessModes:      c.accessModes,
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: c.size},
+			},
+		},
+	}
+	created, err := p.client.CoreV1().PersistentVolumeClaims(p.namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed provisioning pooled PVC of class \"%s\": %w", c.name, err)
+	}
+
+	p.log.Info("Provisioned pooled PVC", "namespace", p.namespace, "name", created.Name, "pool", c.name)
+	metrics.IncPVCPoolProvisioned(c.name)
+	return nil
+}
+
+// match returns the first class that can serve the PVC, or nil
+func (p *Pool) match(pvc *v1.PersistentVolumeClaim) *class {
+	if pvc.Spec.StorageClassName == nil {
+		return nil
+	}
+
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	for i := range p.classes {
+		c := &p.classes[i]
+		if c.storageClass == *pvc.Spec.StorageClassName && requested.Cmp(c.size) <= 0 && containsAll(c.accessModes, pvc.Spec.AccessModes) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) class(name string) *class {
+	for i := range p.classes {
+		if p.classes[i].name == name {
+			return &p.classes[i]
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) claimExpired(pvc *v1.PersistentVolumeClaim) bool {
+	if p.claimTTL <= 0 {
+		return false
+	}
+
+	claimedAt, err := time.Parse(time.RFC3339, pvc.Annotations[AnnotationClaimedAt])
+	return err == nil && time.Since(claimedAt) > p.claimTTL
+}
+
+func (p *Pool) triggerReplenish() {
+	select {
+	case p.replenish <- struct{}{}:
+	default:
+	}
+}
+
+// sortByPreference puts the bound PVCs first, and the older PVCs first among them
+func sortByPreference(pvcs []*v1.PersistentVolumeClaim) {
+	sort.SliceStable(pvcs, func(i, j int) bool {
+		iBound, jBound := pvcs[i].Status.Phase == v1.ClaimBound, pvcs[j].Status.Phase == v1.ClaimBound
+		if iBound != jBound {
+			return iBound
+		}
+
+		return pvcs[i].CreationTimestamp.Before(&pvcs[j].CreationTimestamp)
+	})
+}
+
+func containsAll(modes []v1.PersistentVolumeAccessMode, requested []v1.PersistentVolumeAccessMode) bool {
+	for _, r := range requested {
+		found := false
+		for _, m := range modes {
+			if m == r {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,235 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pvcpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newClient(objects ...k8sruntime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	// the fake client does not generate names
+	generated := 0
+	client.PrependReactor("create", "persistentvolumeclaims", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		pvc := action.(k8stesting.CreateAction).GetObject().(*v1.PersistentVolumeClaim)
+		if pvc.Name == "" {
+			generated++
+			pvc.Name = fmt.Sprintf("%s%d", pvc.GenerateName, generated)
+		}
+
+		return false, nil, nil
+	})
+	return client
+}
+
+func pooledPVC(name string, pool string, state string, phase v1.PersistentVolumeClaimPhase, annotations map[string]string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "codefresh",
+			Labels:      map[string]string{LabelPool: pool, LabelState: state},
+			Annotations: annotations,
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func requestedPVC(namespace string, storageClass string, size string, modes ...v1.PersistentVolumeAccessMode) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "dind-vol", Namespace: namespace, Labels: map[string]string{"codefresh-app": "dind"}},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			AccessModes:      modes,
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+func pvcNames(t *testing.T, client *fake.Clientset) []string {
+	list, err := client.CoreV1().PersistentVolumeClaims("codefresh").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, pvc := range list.Items {
+		names = append(names, pvc.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		cfg     Config
+		wantErr string
+	}{
+		"should create a pool": {
+			cfg: Config{Namespace: "codefresh", Classes: []Class{{StorageClass: "ssd", Size: "20Gi", Count: 2}}},
+		},
+		"should fail without a namespace": {
+			cfg:     Config{Classes: []Class{{StorageClass: "ssd", Size: "20Gi", Count: 2}}},
+			wantErr: "pvc pool namespace is required",
+		},
+		"should fail without a storage class": {
+			cfg:     Config{Namespace: "codefresh", Classes: []Class{{Size: "20Gi", Count: 2}}},
+			wantErr: "pvc pool classes require a storage class",
+		},
+		"should fail without a count": {
+			cfg:     Config{Namespace: "codefresh", Classes: []Class{{StorageClass: "ssd", Size: "20Gi"}}},
+			wantErr: "pvc pool classes require a positive count",
+		},
+		"should fail with an invalid size": {
+			cfg:     Config{Namespace: "codefresh", Classes: []Class{{StorageClass: "ssd", Size: "big", Count: 2}}},
+			wantErr: "failed parsing size of pvc pool class \"ssd\": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+		"should fail with duplicate classes": {
+			cfg: Config{Namespace: "codefresh", Classes: []Class{
+				{StorageClass: "ssd", Size: "20Gi", Count: 2},
+				{StorageClass: "ssd", Size: "50Gi", Count: 1},
+			}},
+			wantErr: "duplicate pvc pool class \"ssd\"",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(fake.NewSimpleClientset(), &tt.cfg, logger.New(logger.Options{}))
+			if err != nil || tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPool_Sync(t *testing.T) {
+	expired := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	fresh := time.Now().UTC().Format(time.RFC3339)
+	client := newClient(
+		pooledPVC("ssd-available", "ssd", StateAvailable, v1.ClaimBound, nil),
+		pooledPVC("ssd-lost", "ssd", StateAvailable, v1.ClaimLost, nil),
+		pooledPVC("hdd-available-1", "hdd", StateAvailable, v1.ClaimBound, nil),
+		pooledPVC("hdd-available-2", "hdd", StateAvailable, v1.ClaimPending, nil),
+		pooledPVC("removed-available", "removed", StateAvailable, v1.ClaimBound, nil),
+		pooledPVC("ssd-claimed-expired", "ssd", StateClaimed, v1.ClaimBound, map[string]string{AnnotationClaim: "old-vol", AnnotationClaimedAt: expired}),
+		pooledPVC("ssd-claimed", "ssd", StateClaimed, v1.ClaimBound, map[string]string{AnnotationClaim: "dind-vol", AnnotationClaimedAt: fresh}),
+	)
+	p, err := New(client, &Config{
+		Namespace: "codefresh",
+		ClaimTTL:  time.Hour,
+		Classes: []Class{
+			{StorageClass: "ssd", Size: "20Gi", Count: 2},
+			{StorageClass: "hdd", Size: "50Gi", Count: 1},
+		},
+	}, logger.New(logger.Options{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, p.Sync(context.Background()))
+	assert.Equal(t, []string{"hdd-available-1", "pvc-pool-ssd-1", "ssd-available", "ssd-claimed"}, pvcNames(t, client))
+	pooled, ok := p.Resolve("codefresh", "dind-vol")
+	assert.True(t, ok)
+	assert.Equal(t, "ssd-claimed", pooled)
+	_, ok = p.Resolve("codefresh", "old-vol")
+	assert.False(t, ok)
+}
+
+func TestPool_Claim(t *testing.T) {
+	tests := map[string]struct {
+		pvc      *v1.PersistentVolumeClaim
+		existing []k8sruntime.Object
+		want     string
+	}{
+		"should claim the bound PVC of the matching class": {
+			pvc: requestedPVC("codefresh", "ssd", "10Gi", v1.ReadWriteOnce),
+			existing: []k8sruntime.Object{
+				pooledPVC("ssd-pending", "ssd", StateAvailable, v1.ClaimPending, nil),
+				pooledPVC("ssd-bound", "ssd", StateAvailable, v1.ClaimBound, nil),
+			},
+			want: "ssd-bound",
+		},
+		"should not claim a PVC of another namespace": {
+			pvc:      requestedPVC("other", "ssd", "10Gi"),
+			existing: []k8sruntime.Object{pooledPVC("ssd-bound", "ssd", StateAvailable, v1.ClaimBound, nil)},
+		},
+		"should not claim a PVC of another storage class": {
+			pvc:      requestedPVC("codefresh", "hdd", "10Gi"),
+			existing: []k8sruntime.Object{pooledPVC("ssd-bound", "ssd", StateAvailable, v1.ClaimBound, nil)},
+		},
+		"should not claim a PVC that is larger than the class": {
+			pvc:      requestedPVC("codefresh", "ssd", "30Gi"),
+			existing: []k8sruntime.Object{pooledPVC("ssd-bound", "ssd", StateAvailable, v1.ClaimBound, nil)},
+		},
+		"should not claim a PVC with other access modes": {
+			pvc:      requestedPVC("codefresh", "ssd", "10Gi", v1.ReadWriteMany),
+			existing: []k8sruntime.Object{pooledPVC("ssd-bound", "ssd", StateAvailable, v1.ClaimBound, nil)},
+		},
+		"should not claim when the class has no available PVC": {
+			pvc:      requestedPVC("codefresh", "ssd", "10Gi"),
+			existing: []k8sruntime.Object{pooledPVC("ssd-claimed", "ssd", StateClaimed, v1.ClaimBound, nil)},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newClient(tt.existing...)
+			p, err := New(client, &Config{
+				Namespace: "codefresh",
+				Classes:   []Class{{StorageClass: "ssd", Size: "20Gi", Count: 2}},
+			}, logger.New(logger.Options{}))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := p.Claim(context.Background(), tt.pvc, "wf1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			pooled, ok := p.Resolve(tt.pvc.Namespace, tt.pvc.Name)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, pooled)
+			if tt.want == "" {
+				return
+			}
+
+			claimed, err := client.CoreV1().PersistentVolumeClaims("codefresh").Get(context.Background(), tt.want, metav1.GetOptions{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, map[string]string{LabelPool: "ssd", LabelState: StateClaimed, "codefresh-app": "dind"}, claimed.Labels)
+			assert.Equal(t, "dind-vol", claimed.Annotations[AnnotationClaim])
+			assert.Equal(t, "wf1", claimed.Annotations[AnnotationWorkflow])
+			p.Release(tt.pvc.Namespace, tt.pvc.Name)
+			_, ok = p.Resolve(tt.pvc.Namespace, tt.pvc.Name)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -17,6 +17,7 @@ package queue
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -35,36 +36,82 @@ type (
 	WorkflowQueue interface {
 		Start(ctx context.Context)
 		Stop()
+		Drain() []*workflow.Workflow
 		Size() int
 		Enqueue(wf *workflow.Workflow)
+		Cancel(workflowID string) bool
 	}
 
 	// Options to create a new WorkflowQueue
 	Options struct {
-		Runtimes    map[string]runtime.Runtime
+		Runtimes    *runtime.Registry
 		Log         logger.Logger
 		WG          *sync.WaitGroup
 		Monitor     monitoring.Monitor
 		Concurrency int
 		BufferSize  int
 		Codefresh   codefresh.Codefresh
+		// WorkflowTimeout is the deadline of handling all the tasks of a workflow batch, 0 means no deadline
+		WorkflowTimeout time.Duration
+		// TaskTimeouts are the deadlines of handling a single task by type, a type without one has no deadline
+		TaskTimeouts map[task.Type]time.Duration
+		// CapacityWaitTimeout is how long a task that exceeded a ResourceQuota waits for capacity before it fails,
+		// 0 means it fails right away
+		CapacityWaitTimeout time.Duration
 	}
 
 	wfQueueImpl struct {
-		runtimes        map[string]runtime.Runtime
+		runtimes        *runtime.Registry
 		log             logger.Logger
 		wg              *sync.WaitGroup
 		monitor         monitoring.Monitor
 		queue           chan *workflow.Workflow
 		concurrency     int
 		stop            []chan bool
-		activeWorkflows map[string]struct{}
+		activeWorkflows map[string]context.CancelCauseFunc
+		// queued counts the batches of each workflow that are waiting in the queue,
+		// cancelled counts the ones that were waiting when the workflow was cancelled
+		queued          map[string]int
+		cancelled       map[string]int
 		mutex           sync.Mutex
 		cf              codefresh.Codefresh
+		draining        chan struct{}
+		drainOnce       sync.Once
+		workflowTimeout time.Duration
+		taskTimeouts    map[task.Type]time.Duration
+		capacityWait    time.Duration
+		// requeueing counts the workflows that are being put back into the queue, leftovers are the ones
+		// that were put back once the queue was draining
+		requeueing sync.WaitGroup
+		leftovers  []*workflow.Workflow
 	}
+
+	// activeState is the result of marking a workflow as active
+	activeState int
+)
+
+const (
+	// activeMarked means the workflow is registered as active
+	activeMarked activeState = iota
+	// activeBusy means another handler handles the workflow, it has to be put back into the queue
+	activeBusy
+	// activeUnregistered means the context was cancelled while waiting for another handler, the workflow is handled
+	// without being registered as active
+	activeUnregistered
 )
 
-var errRuntimeNotFound = errors.New("Runtime environment not found")
+const (
+	// the operations of the timeout errors of the workflow and task deadlines
+	workflowOperation = "workflow"
+	taskOperation     = "task"
+	capacityOperation = "capacity wait"
+)
+
+var (
+	errRuntimeNotFound = errors.New("Runtime environment not found")
+	// errWorkflowCancelled is the cause of the context of a cancelled workflow
+	errWorkflowCancelled = errors.New("workflow cancelled")
+)
 
 // New creates a new TaskQueue instance
 func New(opts *Options) WorkflowQueue {
@@ -76,8 +123,14 @@ func New(opts *Options) WorkflowQueue {
 		queue:           make(chan *workflow.Workflow, opts.BufferSize),
 		concurrency:     opts.Concurrency,
 		stop:            make([]chan bool, opts.Concurrency),
-		activeWorkflows: make(map[string]struct{}),
+		activeWorkflows: make(map[string]context.CancelCauseFunc),
+		queued:          make(map[string]int),
+		cancelled:       make(map[string]int),
 		cf:              opts.Codefresh,
+		draining:        make(chan struct{}),
+		workflowTimeout: opts.WorkflowTimeout,
+		taskTimeouts:    opts.TaskTimeouts,
+		capacityWait:    opts.CapacityWaitTimeout,
 	}
 }
 
@@ -100,6 +153,32 @@ func (wfq *wfQueueImpl) Stop() {
 	}
 }
 
+// Drain notifies the handlers to stop picking up new workflows once their current one is done,
+// and returns all the workflows that are still waiting in the queue, or were being put back into it
+func (wfq *wfQueueImpl) Drain() []*workflow.Workflow {
+	wfq.mutex.Lock()
+	wfq.drainOnce.Do(func() {
+		close(wfq.draining)
+	})
+	wfq.mutex.Unlock()
+	wfq.requeueing.Wait()
+
+	wfq.mutex.Lock()
+	defer wfq.mutex.Unlock()
+	leftovers := append([]*workflow.Workflow{}, wfq.leftovers...)
+	wfq.leftovers = nil
+	for {
+		select {
+		case wf := <-wfq.queue:
+			leftovers = append(leftovers, wf)
+		default:
+			wfq.queued = make(map[string]int)
+			wfq.cancelled = make(map[string]int)
+			return leftovers
+		}
+	}
+}
+
 // Size returns the current size of the queue (used for logs)
 func (wfq *wfQueueImpl) Size() int {
 	return len(wfq.queue)
@@ -107,9 +186,54 @@ func (wfq *wfQueueImpl) Size() int {
 
 // Enqueue adds another task to be handled, internally using or creating a channel for the task's workflow
 func (wfq *wfQueueImpl) Enqueue(wf *workflow.Workflow) {
+	wfq.mutex.Lock()
+	wfq.queued[wf.Metadata.WorkflowId]++
+	wfq.mutex.Unlock()
 	wfq.queue <- wf
 }
 
+// Cancel cancels a workflow: its batches that are waiting in the queue, and the one that is being handled.
+// The tasks that create resources are skipped, or cancelled while they run, and are reported as cancelled.
+// The tasks that delete resources are still handled, so a cancellation never leaves resources behind.
+// It returns false when the workflow is neither queued nor being handled
+func (wfq *wfQueueImpl) Cancel(workflowID string) bool {
+	wfq.mutex.Lock()
+	defer wfq.mutex.Unlock()
+	found := false
+	if n := wfq.queued[workflowID]; n > 0 {
+		wfq.cancelled[workflowID] = n
+		found = true
+	}
+
+	if cancel, ok := wfq.activeWorkflows[workflowID]; ok {
+		cancel(errWorkflowCancelled)
+		found = true
+	}
+
+	return found
+}
+
+// dequeued updates the counts of a workflow that was taken out of the queue,
+// and returns true when it was cancelled while it was waiting
+func (wfq *wfQueueImpl) dequeued(wf *workflow.Workflow) bool {
+	wfq.mutex.Lock()
+	defer wfq.mutex.Unlock()
+	id := wf.Metadata.WorkflowId
+	if wfq.queued[id]--; wfq.queued[id] <= 0 {
+		delete(wfq.queued, id)
+	}
+
+	if wfq.cancelled[id] <= 0 {
+		return false
+	}
+
+	if wfq.cancelled[id]--; wfq.cancelled[id] == 0 {
+		delete(wfq.cancelled, id)
+	}
+
+	return true
+}
+
 func (wfq *wfQueueImpl) handleChannel(ctx context.Context, stopChan chan bool, id int) {
 	ctxCancelled := false
 
@@ -119,25 +243,35 @@ func (wfq *wfQueueImpl) handleChannel(ctx context.Context, stopChan chan bool, i
 		case <-stopChan:
 			wfq.log.Info("stopping workflow handler", "handlerId", id)
 			ctxCancelled = true
+		case <-wfq.draining:
+			wfq.log.Info("stopped workflow handler, queue is draining", "handlerId", id)
+			return
 		case wf := <-wfq.queue:
-			wfq.mutex.Lock()
-			if _, ok := wfq.activeWorkflows[wf.Metadata.WorkflowId]; ok {
+			wfCtx, cancel := context.WithCancelCause(ctx)
+			state := wfq.markActive(ctx, wf, cancel)
+			if state == activeBusy {
 				// Workflow is already being handled, enqueue it again and skip processing
-				wfq.mutex.Unlock()
-				wfq.log.Info("Workflow", wf.Metadata.WorkflowId, " is already being handled, enqueue it again and skip processing")
+				cancel(nil)
+				wfq.log.Info("workflow is already being handled, enqueue it again and skip processing", "handlerId", id, "workflow", wf.Metadata.WorkflowId)
 				time.Sleep(100 * time.Millisecond)
-				wfq.Enqueue(wf)
+				wfq.requeue(wf)
 				continue
 			}
-			// Mark the workflow as active
-			wfq.activeWorkflows[wf.Metadata.WorkflowId] = struct{}{}
-			wfq.mutex.Unlock()
+
+			if wfq.dequeued(wf) {
+				wfq.log.Info("workflow was cancelled while it was queued", "handlerId", id, "workflow", wf.Metadata.WorkflowId)
+				cancel(errWorkflowCancelled)
+			}
 
 			wfq.log.Info("handling workflow", "handlerId", id, "workflow", wf.Metadata.WorkflowId)
-			wfq.handleWorkflow(ctx, wf)
-			wfq.mutex.Lock()
-			delete(wfq.activeWorkflows, wf.Metadata.WorkflowId)
-			wfq.mutex.Unlock()
+			wfq.handleWorkflow(ctx, wfCtx, wf)
+			if state == activeMarked {
+				wfq.mutex.Lock()
+				delete(wfq.activeWorkflows, wf.Metadata.WorkflowId)
+				wfq.mutex.Unlock()
+			}
+
+			cancel(nil)
 		default:
 			if ctxCancelled {
 				wfq.log.Info("stopped workflow handler", "handlerId", id)
@@ -149,24 +283,77 @@ func (wfq *wfQueueImpl) handleChannel(ctx context.Context, stopChan chan bool, i
 	}
 }
 
-func (wfq *wfQueueImpl) handleWorkflow(ctx context.Context, wf *workflow.Workflow) {
+// markActive marks the workflow as active, returns activeBusy if it is already being handled by another handler,
+// the workflow is then counted as being put back into the queue until requeue is called.
+// While draining, the queue is no longer consumed, so instead of enqueueing the workflow again
+// it waits for the other handler to finish, and keeps the workflow as in-flight
+func (wfq *wfQueueImpl) markActive(ctx context.Context, wf *workflow.Workflow, cancel context.CancelCauseFunc) activeState {
+	for {
+		wfq.mutex.Lock()
+		if _, ok := wfq.activeWorkflows[wf.Metadata.WorkflowId]; !ok {
+			wfq.activeWorkflows[wf.Metadata.WorkflowId] = cancel
+			wfq.mutex.Unlock()
+			return activeMarked
+		}
+
+		select {
+		case <-wfq.draining:
+			wfq.mutex.Unlock()
+		default:
+			// Drain closes draining under the lock, so it waits for this workflow to be put back
+			wfq.requeueing.Add(1)
+			wfq.mutex.Unlock()
+			return activeBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			// let the handler fail the workflow with the cancelled context, the other handler keeps it registered
+			return activeUnregistered
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// handleWorkflow handles the tasks of the workflow one after the other. The tasks that create resources are handled
+// with wfCtx, which is cancelled when the workflow is cancelled, the ones that delete resources are always handled with ctx.
+// Both are bound by the workflow deadline
+func (wfq *wfQueueImpl) handleWorkflow(ctx context.Context, wfCtx context.Context, wf *workflow.Workflow) {
 	wf.Timeline.Started = time.Now()
 	txn := task.NewTaskTransaction(wfq.monitor, wf.Metadata)
 	defer txn.End()
+	ctx = task.WithMetadata(txn.NewContext(ctx), wf.Metadata)
+	wfCtx = task.WithMetadata(txn.NewContext(wfCtx), wf.Metadata)
+	if wfq.workflowTimeout > 0 {
+		deadline := wf.Timeline.Started.Add(wfq.workflowTimeout)
+		cause := &ierrors.TimeoutError{Operation: workflowOperation, Timeout: wfq.workflowTimeout}
+		var cancel, wfCancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, deadline, cause)
+		defer cancel()
+		wfCtx, wfCancel = context.WithDeadlineCause(wfCtx, deadline, cause)
+		defer wfCancel()
+	}
 
 	workflow := wf.Metadata.WorkflowId
 	reName := wf.Metadata.ReName
-	runtime, ok := wfq.runtimes[reName]
+	runtime, ok := wfq.runtimes.Get(reName)
 	if !ok {
-		wfq.log.Error("failed handling task", "error", errRuntimeNotFound, "workflow", workflow)
+		wfq.log.Error("failed handling task", "error", errRuntimeNotFound, "workflow", workflow, "runtime", reName)
 		txn.NoticeError(errRuntimeNotFound)
 		return
 	}
 
 	for i := range wf.Tasks {
 		taskDef := wf.Tasks[i]
-		err := runtime.HandleTask(ctx, taskDef)
-		if err != nil {
+		taskCtx := ctx
+		if taskDef.Type == task.TypeCreatePod || taskDef.Type == task.TypeCreatePVC {
+			taskCtx = wfCtx
+		}
+
+		err := wfq.handleTask(taskCtx, runtime, taskDef)
+		if errors.Is(err, errWorkflowCancelled) {
+			wfq.log.Info("cancelled task", "workflow", workflow, "task", taskDef.Id, "type", taskDef.Type)
+		} else if err != nil {
 			wfq.log.Error("failed handling task", "error", err, "workflow", workflow, "task", taskDef.Id)
 			txn.NoticeError(errRuntimeNotFound)
 		}
@@ -186,12 +373,109 @@ func (wfq *wfQueueImpl) handleWorkflow(ctx context.Context, wf *workflow.Workflo
 	metrics.ObserveWorkflowMetrics(wf.Type, sinceCreation, inRunner, processed)
 }
 
+// handleTask handles a single task. A task that exceeded a ResourceQuota waits for capacity, and is handled again
+// whenever there might be some, until the capacity wait timeout. The task deadline applies to every attempt on its own
+func (wfq *wfQueueImpl) handleTask(ctx context.Context, rt runtime.Runtime, t *task.Task) error {
+	var waitCtx context.Context
+	for {
+		err := wfq.handleAttempt(ctx, rt, t)
+		var waitable ierrors.WaitableError
+		if wfq.capacityWait <= 0 || !errors.As(err, &waitable) {
+			if waitCtx != nil {
+				result := "admitted"
+				if err != nil {
+					result = "failed"
+				}
+				metrics.IncCapacityWaits(t.Type, result)
+			}
+
+			return err
+		}
+
+		if waitCtx == nil {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeoutCause(ctx, wfq.capacityWait, &ierrors.TimeoutError{Operation: capacityOperation, Timeout: wfq.capacityWait})
+			defer cancel()
+			wfq.log.Info("waiting for capacity", "workflow", t.Metadata.WorkflowId, "task", t.Id, "type", t.Type, "reason", err)
+			if t.Metadata.ShouldReportStatus {
+				wfq.reportWaiting(ctx, t, err)
+			}
+		}
+
+		if waitable.Wait(waitCtx) != nil {
+			err = wfq.doneError(waitCtx, t, err)
+			result := "timeout"
+			if errors.Is(err, errWorkflowCancelled) {
+				result = "cancelled"
+			}
+			metrics.IncCapacityWaits(t.Type, result)
+			return err
+		}
+	}
+}
+
+// handleAttempt handles a single task under its deadline. A task whose context is done, before or while it is handled,
+// fails with the reason it is done: errWorkflowCancelled, or a TimeoutError of the task or of the workflow
+func (wfq *wfQueueImpl) handleAttempt(ctx context.Context, rt runtime.Runtime, t *task.Task) error {
+	if timeout := wfq.taskTimeouts[t.Type]; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, &ierrors.TimeoutError{Operation: taskOperation, Timeout: timeout})
+		defer cancel()
+	}
+
+	var err error
+	if ctx.Err() == nil {
+		if err = rt.HandleTask(ctx, t); err == nil || ctx.Err() == nil {
+			return err
+		}
+	}
+
+	return wfq.doneError(ctx, t, err)
+}
+
+// doneError returns the error of a task whose context is done, err is the error it failed with, if any
+func (wfq *wfQueueImpl) doneError(ctx context.Context, t *task.Task, err error) error {
+	cause := context.Cause(ctx)
+	var timeout *ierrors.TimeoutError
+	switch {
+	case errors.Is(cause, errWorkflowCancelled):
+		return errWorkflowCancelled
+	case errors.As(cause, &timeout):
+		metrics.IncDeadlineExceededTasks(t.Type, timeout.Operation)
+		return &ierrors.TimeoutError{Operation: timeout.Operation, Timeout: timeout.Timeout, Err: err}
+	case err != nil:
+		return err
+	default:
+		return cause
+	}
+}
+
+// reportWaiting reports that the task waits for capacity. The final status of the task is reported
+// with the next revision
+func (wfq *wfQueueImpl) reportWaiting(ctx context.Context, t *task.Task, err error) {
+	status := task.TaskStatus{
+		Status:         task.StatusWaiting,
+		OccurredAt:     time.Now(),
+		StatusRevision: t.Metadata.CurrentStatusRevision + 1,
+		Reason:         fmt.Sprintf("waiting for capacity: %s", err),
+	}
+	if statusErr := wfq.cf.ReportTaskStatus(context.WithoutCancel(ctx), t.Id, status); statusErr != nil {
+		wfq.log.Error("failed reporting task status", "error", statusErr, "task", t.Id, "workflow", t.Metadata.WorkflowId)
+		return
+	}
+
+	t.Metadata.CurrentStatusRevision++
+}
+
 func (wfq *wfQueueImpl) reportTaskStatus(ctx context.Context, taskDef task.Task, err error) {
 	status := task.TaskStatus{
 		OccurredAt:     time.Now(),
 		StatusRevision: taskDef.Metadata.CurrentStatusRevision + 1,
 	}
-	if err != nil {
+	if errors.Is(err, errWorkflowCancelled) {
+		status.Status = task.StatusCancelled
+		status.Reason = err.Error()
+	} else if err != nil {
 		status.Status = task.StatusError
 		status.Reason = err.Error()
 		status.IsRetriable = ierrors.IsRetriable(err)
@@ -199,8 +483,34 @@ func (wfq *wfQueueImpl) reportTaskStatus(ctx context.Context, taskDef task.Task,
 		status.Status = task.StatusSuccess
 	}
 
-	statusErr := wfq.cf.ReportTaskStatus(ctx, taskDef.Id, status)
+	// the status must be reported even if the workflow context was cancelled by a drain deadline
+	statusErr := wfq.cf.ReportTaskStatus(context.WithoutCancel(ctx), taskDef.Id, status)
 	if statusErr != nil {
 		wfq.log.Error("failed reporting task status", "error", statusErr, "task", taskDef.Id, "workflow", taskDef.Metadata.WorkflowId)
 	}
 }
+
+// requeue puts a workflow that was taken out of the queue back, without counting it as another batch.
+// Once the queue is draining it is no longer consumed, the workflow is kept as a leftover of Drain instead
+func (wfq *wfQueueImpl) requeue(wf *workflow.Workflow) {
+	defer wfq.requeueing.Done()
+	select {
+	case <-wfq.draining:
+	default:
+		select {
+		case wfq.queue <- wf:
+			return
+		case <-wfq.draining:
+		}
+	}
+
+	wfq.mutex.Lock()
+	wfq.leftovers = append(wfq.leftovers, wf)
+	wfq.mutex.Unlock()
+}
+
+// ParseTaskTimeouts parses a comma separated list of type=duration pairs, e.g. "CreatePod=1m,DeletePod=1m".
+// A duration of 0 disables the deadline of the type
+func ParseTaskTimeouts(s string) (map[task.Type]time.Duration, error) {
+	return task.ParseDurations(s, "task timeout", task.TypeCreatePod, task.TypeCreatePVC, task.TypeDeletePod, task.TypeDeletePVC)
+}
@@ -15,20 +15,31 @@
 package queue
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
 	"github.com/codefresh-io/go/venona/pkg/kubernetes"
 	"github.com/codefresh-io/go/venona/pkg/logger"
 	"github.com/codefresh-io/go/venona/pkg/monitoring"
+	"github.com/codefresh-io/go/venona/pkg/mutation"
+	"github.com/codefresh-io/go/venona/pkg/redact"
 	"github.com/codefresh-io/go/venona/pkg/runtime"
 	"github.com/codefresh-io/go/venona/pkg/task"
 	"github.com/codefresh-io/go/venona/pkg/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func makeWorkflow(wfID string, numOfTasks int) *workflow.Workflow {
@@ -115,11 +126,11 @@ func TestWorkflowQueue_Enqueue(t *testing.T) {
 				testLock.Unlock()
 				return nil
 			})
-			runtimes := map[string]runtime.Runtime{
+			runtimes := runtime.NewRegistry(map[string]runtime.Runtime{
 				"some-rt": runtime.New(runtime.Options{
 					Kubernetes: mockKubernetes,
 				}),
-			}
+			})
 			log := logger.New(logger.Options{})
 			wg := &sync.WaitGroup{}
 			opts := &Options{
@@ -146,3 +157,459 @@ func TestWorkflowQueue_Enqueue(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkflowQueue_Drain(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockKubernetes := kubernetes.NewMockKubernetes(t)
+	mockKubernetes.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, mock.AnythingOfType("string")).RunAndReturn(func(_ context.Context, _ task.Type, _ interface{}) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}).Once()
+	runtimes := runtime.NewRegistry(map[string]runtime.Runtime{
+		"some-rt": runtime.New(runtime.Options{
+			Kubernetes: mockKubernetes,
+		}),
+	})
+	wg := &sync.WaitGroup{}
+	tq := New(&Options{
+		Runtimes:    runtimes,
+		Log:         logger.New(logger.Options{}),
+		WG:          wg,
+		Monitor:     monitoring.NewEmpty(),
+		Concurrency: 1,
+		BufferSize:  10,
+	})
+	tq.Start(context.Background())
+	tq.Enqueue(makeWorkflow("wf1", 1))
+	<-started
+	tq.Enqueue(makeWorkflow("wf2", 1))
+	tq.Enqueue(makeWorkflow("wf3", 1))
+
+	leftovers := tq.Drain()
+	close(release)
+	wg.Wait()
+
+	ids := []string{}
+	for _, wf := range leftovers {
+		ids = append(ids, wf.Metadata.WorkflowId)
+	}
+	assert.Equal(t, []string{"wf2", "wf3"}, ids)
+	assert.Equal(t, 0, tq.Size())
+}
+
+func TestWorkflowQueue_Drain_requeued(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	tq := New(&Options{
+		Runtimes:    runtime.NewRegistry(map[string]runtime.Runtime{}),
+		Log:         logger.New(logger.Options{}),
+		WG:          wg,
+		Monitor:     monitoring.NewEmpty(),
+		Concurrency: 1,
+		BufferSize:  10,
+	}).(*wfQueueImpl)
+	// another handler handles wf1, the batch is put back into the queue after a while
+	tq.activeWorkflows["wf1"] = func(error) {}
+	tq.Start(context.Background())
+	tq.Enqueue(makeWorkflow("wf1", 1))
+	assert.Eventually(t, func() bool { return tq.Size() == 0 }, time.Second, time.Millisecond)
+
+	leftovers := tq.Drain()
+	wg.Wait()
+
+	assert.Len(t, leftovers, 1)
+	assert.Equal(t, 0, tq.Size())
+}
+
+func TestWorkflowQueue_markActive_cancelled(t *testing.T) {
+	tq := New(&Options{Log: logger.New(logger.Options{})}).(*wfQueueImpl)
+	tq.activeWorkflows["wf1"] = func(error) {}
+	tq.Drain()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state := tq.markActive(ctx, makeWorkflow("wf1", 1), func(error) {})
+
+	assert.Equal(t, activeUnregistered, state)
+	assert.Len(t, tq.activeWorkflows, 1)
+}
+
+func TestWorkflowQueue_redactsErrors(t *testing.T) {
+	redact.AddSecret("some-queue-runtime-token")
+	started := make(chan struct{})
+	mockKubernetes := kubernetes.NewMockKubernetes(t)
+	mockKubernetes.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, mock.AnythingOfType("string")).RunAndReturn(func(context.Context, task.Type, interface{}) error {
+		close(started)
+		return errors.New("failed creating pod with some-queue-runtime-token: Authorization: Bearer eyJhbGciOi")
+	}).Once()
+	runtimes := runtime.NewRegistry(map[string]runtime.Runtime{
+		"some-rt": runtime.New(runtime.Options{
+			Kubernetes: mockKubernetes,
+		}),
+	})
+	out := &bytes.Buffer{}
+	wg := &sync.WaitGroup{}
+	tq := New(&Options{
+		Runtimes:    runtimes,
+		Log:         logger.New(logger.Options{Output: out}),
+		WG:          wg,
+		Monitor:     monitoring.NewEmpty(),
+		Concurrency: 1,
+		BufferSize:  10,
+	})
+	tq.Start(context.Background())
+	tq.Enqueue(makeWorkflow("wf1", 1))
+	<-started
+	tq.Drain()
+	wg.Wait()
+
+	assert.Contains(t, out.String(), "failed handling task")
+	assert.NotContains(t, out.String(), "some-queue-runtime-token")
+	assert.NotContains(t, out.String(), "eyJhbGciOi")
+}
+
+func TestWorkflowQueue_Cancel(t *testing.T) {
+	started := make(chan struct{})
+	created := []string{}
+	statuses := map[string]task.Status{}
+	testLock := sync.Mutex{}
+	mockKubernetes := kubernetes.NewMockKubernetes(t)
+	mockKubernetes.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, _ task.Type, spec interface{}) error {
+		if spec == "wf1-0" {
+			// in-flight until the workflow is cancelled
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		testLock.Lock()
+		created = append(created, spec.(string))
+		testLock.Unlock()
+		return nil
+	})
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().ReportTaskStatus(mock.Anything, mock.AnythingOfType("string"), mock.Anything).RunAndReturn(func(_ context.Context, id string, status task.TaskStatus) error {
+		testLock.Lock()
+		statuses[id] = status.Status
+		testLock.Unlock()
+		return nil
+	})
+	runtimes := runtime.NewRegistry(map[string]runtime.Runtime{
+		"some-rt": runtime.New(runtime.Options{
+			Kubernetes: mockKubernetes,
+		}),
+	})
+	wg := &sync.WaitGroup{}
+	tq := New(&Options{
+		Runtimes:    runtimes,
+		Log:         logger.New(logger.Options{}),
+		WG:          wg,
+		Monitor:     monitoring.NewEmpty(),
+		Codefresh:   cf,
+		Concurrency: 1,
+		BufferSize:  10,
+	})
+	tq.Start(context.Background())
+	for _, id := range []string{"wf1", "wf2", "wf3"} {
+		wf := makeWorkflow(id, 1)
+		wf.Tasks[0].Id = id + "-0"
+		wf.Tasks[0].Metadata.ShouldReportStatus = true
+		tq.Enqueue(wf)
+		if id == "wf1" {
+			<-started
+		}
+	}
+
+	assert.True(t, tq.Cancel("wf2"))
+	assert.True(t, tq.Cancel("wf1"))
+	assert.False(t, tq.Cancel("wf4"))
+	tq.Stop()
+	wg.Wait()
+
+	assert.Equal(t, []string{"wf3-0"}, created)
+	assert.Equal(t, map[string]task.Status{
+		"wf1-0": task.StatusCancelled,
+		"wf2-0": task.StatusCancelled,
+		"wf3-0": task.StatusSuccess,
+	}, statuses)
+}
+
+func TestWorkflowQueue_deadlines(t *testing.T) {
+	tests := map[string]struct {
+		workflowTimeout time.Duration
+		taskTimeouts    map[task.Type]time.Duration
+		wantReasons     map[string]string
+	}{
+		"should fail a task that hits its deadline and handle the next one": {
+			taskTimeouts: map[task.Type]time.Duration{task.TypeCreatePod: 50 * time.Millisecond},
+			wantReasons: map[string]string{
+				"wf1-0": "task timed out after 50ms: failed creating resource: context deadline exceeded",
+				"wf1-1": "",
+			},
+		},
+		"should fail the tasks that are left once the workflow hits its deadline": {
+			workflowTimeout: 50 * time.Millisecond,
+			wantReasons: map[string]string{
+				"wf1-0": "workflow timed out after 50ms: failed creating resource: context deadline exceeded",
+				"wf1-1": "workflow timed out after 50ms",
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			reasons := map[string]string{}
+			testLock := sync.Mutex{}
+			mockKubernetes := kubernetes.NewMockKubernetes(t)
+			mockKubernetes.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, _ task.Type, spec interface{}) error {
+				if spec == "wf1-0" {
+					// a hung API server call
+					<-ctx.Done()
+					return ctx.Err()
+				}
+
+				return nil
+			}).Maybe()
+			cf := codefresh.NewMockCodefresh(t)
+			cf.EXPECT().ReportTaskStatus(mock.Anything, mock.AnythingOfType("string"), mock.Anything).RunAndReturn(func(_ context.Context, id string, status task.TaskStatus) error {
+				testLock.Lock()
+				reasons[id] = status.Reason
+				testLock.Unlock()
+				assert.Equal(t, status.Reason != "", status.IsRetriable)
+				return nil
+			})
+			wg := &sync.WaitGroup{}
+			tq := New(&Options{
+				Runtimes: runtime.NewRegistry(map[string]runtime.Runtime{
+					"some-rt": runtime.New(runtime.Options{
+						Kubernetes: mockKubernetes,
+					}),
+				}),
+				Log:             logger.New(logger.Options{}),
+				WG:              wg,
+				Monitor:         monitoring.NewEmpty(),
+				Codefresh:       cf,
+				Concurrency:     1,
+				BufferSize:      10,
+				WorkflowTimeout: tt.workflowTimeout,
+				TaskTimeouts:    tt.taskTimeouts,
+			})
+			tq.Start(context.Background())
+			wf := makeWorkflow("wf1", 2)
+			for i, tk := range wf.Tasks {
+				tk.Id = fmt.Sprintf("wf1-%d", i)
+				tk.Metadata.ShouldReportStatus = true
+			}
+
+			tq.Enqueue(wf)
+			tq.Stop()
+			wg.Wait()
+			assert.Equal(t, tt.wantReasons, reasons)
+		})
+	}
+}
+
+type quotaError struct {
+	wait func(ctx context.Context) error
+}
+
+func (e *quotaError) Error() string {
+	return "exceeded quota"
+}
+
+func (e *quotaError) Wait(ctx context.Context) error {
+	return e.wait(ctx)
+}
+
+func TestWorkflowQueue_capacityWait(t *testing.T) {
+	freed := func(context.Context) error { return nil }
+	full := func(ctx context.Context) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	}
+	tests := map[string]struct {
+		capacityWait time.Duration
+		wait         func(ctx context.Context) error
+		want         []task.TaskStatus
+	}{
+		"should report waiting and create the pod once there is capacity": {
+			capacityWait: time.Minute,
+			wait:         freed,
+			want: []task.TaskStatus{
+				{Status: task.StatusWaiting, StatusRevision: 1, Reason: "waiting for capacity: failed creating resource: exceeded quota"},
+				{Status: task.StatusSuccess, StatusRevision: 2},
+			},
+		},
+		"should fail with retry once the capacity wait times out": {
+			capacityWait: 50 * time.Millisecond,
+			wait:         full,
+			want: []task.TaskStatus{
+				{Status: task.StatusWaiting, StatusRevision: 1, Reason: "waiting for capacity: failed creating resource: exceeded quota"},
+				{Status: task.StatusError, StatusRevision: 2, IsRetriable: true, Reason: "capacity wait timed out after 50ms: failed creating resource: exceeded quota"},
+			},
+		},
+		"should fail right away without a capacity wait": {
+			wait: freed,
+			want: []task.TaskStatus{
+				{Status: task.StatusError, StatusRevision: 1, Reason: "failed creating resource: exceeded quota"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var statuses []task.TaskStatus
+			attempts := 0
+			mockKubernetes := kubernetes.NewMockKubernetes(t)
+			mockKubernetes.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, "wf1-0").RunAndReturn(func(context.Context, task.Type, interface{}) error {
+				attempts++
+				if attempts == 1 {
+					return &quotaError{wait: tt.wait}
+				}
+
+				return nil
+			})
+			cf := codefresh.NewMockCodefresh(t)
+			cf.EXPECT().ReportTaskStatus(mock.Anything, "wf1-0", mock.Anything).RunAndReturn(func(_ context.Context, _ string, status task.TaskStatus) error {
+				status.OccurredAt = time.Time{}
+				statuses = append(statuses, status)
+				return nil
+			})
+			wg := &sync.WaitGroup{}
+			tq := New(&Options{
+				Runtimes: runtime.NewRegistry(map[string]runtime.Runtime{
+					"some-rt": runtime.New(runtime.Options{
+						Kubernetes: mockKubernetes,
+					}),
+				}),
+				Log:                 logger.New(logger.Options{}),
+				WG:                  wg,
+				Monitor:             monitoring.NewEmpty(),
+				Codefresh:           cf,
+				Concurrency:         1,
+				BufferSize:          10,
+				CapacityWaitTimeout: tt.capacityWait,
+			})
+			tq.Start(context.Background())
+			wf := makeWorkflow("wf1", 1)
+			wf.Tasks[0].Id = "wf1-0"
+			wf.Tasks[0].Metadata.ShouldReportStatus = true
+			tq.Enqueue(wf)
+			tq.Stop()
+			wg.Wait()
+			assert.Equal(t, tt.want, statuses)
+		})
+	}
+}
+
+func TestParseTaskTimeouts(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    map[task.Type]time.Duration
+		wantErr string
+	}{
+		"should parse an empty string": {
+			input: "",
+			want:  map[task.Type]time.Duration{},
+		},
+		"should parse timeouts by type": {
+			input: "CreatePod=1m, DeletePvc = 30s,DeletePod=0",
+			want: map[task.Type]time.Duration{
+				task.TypeCreatePod: time.Minute,
+				task.TypeDeletePVC: 30 * time.Second,
+				task.TypeDeletePod: 0,
+			},
+		},
+		"should fail on a missing duration": {
+			input:   "CreatePod",
+			wantErr: "invalid task timeout \"CreatePod\", expected type=duration",
+		},
+		"should fail on a type that is not of workflow tasks": {
+			input:   "AgentTask=1m",
+			wantErr: "invalid task timeout \"AgentTask=1m\", unknown task type \"AgentTask\"",
+		},
+		"should fail on a negative duration": {
+			input:   "CreatePod=-1m",
+			wantErr: "invalid task timeout \"CreatePod=-1m\", expected a non-negative duration",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTaskTimeouts(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWorkflowQueue_capacityWait_mutatesOnce(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	attempts := 0
+	client.PrependReactor("create", "pods", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		attempts++
+		if attempts == 1 {
+			return true, nil, k8serrors.NewForbidden(v1.Resource("pods"), "some-pod", errors.New("exceeded quota: compute"))
+		}
+
+		return false, nil, nil
+	})
+	policy, err := mutation.New([]mutation.Rule{{
+		Name:      "add-toleration",
+		JSONPatch: []byte(`[{"op":"add","path":"/spec/tolerations/-","value":{"key":"some-key","operator":"Exists"}}]`),
+	}})
+	assert.NoError(t, err)
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "wf1-0", mock.Anything).Return(nil)
+	wg := &sync.WaitGroup{}
+	tq := New(&Options{
+		Runtimes: runtime.NewRegistry(map[string]runtime.Runtime{
+			"some-rt": runtime.New(runtime.Options{
+				Kubernetes: kubernetes.NewForClient(client, kubernetes.Options{
+					Logger:         logger.New(logger.Options{}),
+					MutationPolicy: policy,
+				}),
+			}),
+		}),
+		Log:                 logger.New(logger.Options{}),
+		WG:                  wg,
+		Monitor:             monitoring.NewEmpty(),
+		Codefresh:           cf,
+		Concurrency:         1,
+		BufferSize:          10,
+		CapacityWaitTimeout: 10 * time.Second,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tq.Start(ctx)
+	wf := makeWorkflow("wf1", 1)
+	wf.Tasks[0].Id = "wf1-0"
+	wf.Tasks[0].Metadata.ShouldReportStatus = true
+	wf.Tasks[0].Spec = &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-pod", Namespace: "some-ns"},
+		Spec:       v1.PodSpec{Tolerations: []v1.Toleration{{Key: "other-key", Operator: v1.TolerationOpExists}}},
+	}
+	tq.Enqueue(wf)
+
+	// the capacity wait ends on a change of a quota in the namespace
+	quota := &v1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "some-ns"}}
+	_, _ = client.CoreV1().ResourceQuotas("some-ns").Create(ctx, quota, metav1.CreateOptions{})
+	var pod *v1.Pod
+	assert.Eventually(t, func() bool {
+		quota.Labels = map[string]string{"update": time.Now().String()}
+		_, _ = client.CoreV1().ResourceQuotas("some-ns").Update(ctx, quota, metav1.UpdateOptions{})
+		pod, err = client.CoreV1().Pods("some-ns").Get(ctx, "some-pod", metav1.GetOptions{})
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	tq.Stop()
+	wg.Wait()
+
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []v1.Toleration{
+		{Key: "other-key", Operator: v1.TolerationOpExists},
+		{Key: "some-key", Operator: v1.TolerationOpExists},
+	}, pod.Spec.Tolerations)
+}
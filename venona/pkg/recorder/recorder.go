@@ -0,0 +1,220 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recorder writes the tasks the agent pulls and the statuses it reports to a JSONL file,
+// and replays such recordings in order to reproduce incidents
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/task"
+)
+
+const (
+	// RecordTypeTasks is a batch of tasks returned from Codefresh
+	RecordTypeTasks RecordType = "tasks"
+	// RecordTypeTaskStatus is a task status that was reported to Codefresh
+	RecordTypeTaskStatus RecordType = "status"
+)
+
+type (
+	// RecordType is the type of a recorded call
+	RecordType string
+
+	// Record is a single line of a recording
+	Record struct {
+		Time   time.Time        `json:"time"`
+		Type   RecordType       `json:"type"`
+		Tasks  task.Tasks       `json:"tasks,omitempty"`
+		TaskID string           `json:"taskId,omitempty"`
+		Status *task.TaskStatus `json:"status,omitempty"`
+		Error  string           `json:"error,omitempty"`
+	}
+
+	// Options for the recorder
+	Options struct {
+		Codefresh codefresh.Codefresh
+		// Output is where the records are written to, usually a file from logger.NewRotatingFile
+		Output io.Writer
+		Logger logger.Logger
+	}
+
+	// Recorder is a Codefresh client that records the task batches it pulls and the task statuses it reports,
+	// all other calls are passed to the wrapped client as is
+	Recorder struct {
+		codefresh.Codefresh
+		mutex sync.Mutex
+		out   io.Writer
+		log   logger.Logger
+	}
+)
+
+var (
+	errOptionsRequired   = errors.New("Options are required")
+	errCodefreshRequired = errors.New("Codefresh options is required")
+	errOutputRequired    = errors.New("Output options is required")
+	errLoggerRequired    = errors.New("Logger options is required")
+
+	now = time.Now
+)
+
+// New creates a new Recorder
+func New(opts *Options) (*Recorder, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		Codefresh: opts.Codefresh,
+		out:       opts.Output,
+		log:       opts.Logger,
+	}, nil
+}
+
+// Tasks pulls the tasks from Codefresh, and records the batch when it has tasks or failed
+func (r *Recorder) Tasks(ctx context.Context) (task.Tasks, error) {
+	tasks, err := r.Codefresh.Tasks(ctx)
+	if len(tasks) > 0 || err != nil {
+		r.write(Record{
+			Type:  RecordTypeTasks,
+			Tasks: tasks,
+			Error: errorString(err),
+		})
+	}
+
+	return tasks, err
+}
+
+// ReportTaskStatus reports the status to Codefresh and records it
+func (r *Recorder) ReportTaskStatus(ctx context.Context, id string, status task.TaskStatus) error {
+	err := r.Codefresh.ReportTaskStatus(ctx, id, status)
+	r.write(Record{
+		Type:   RecordTypeTaskStatus,
+		TaskID: id,
+		Status: &status,
+		Error:  errorString(err),
+	})
+
+	return err
+}
+
+// write never fails the call that is recorded, the agent keeps working when the recording does not
+func (r *Recorder) write(record Record) {
+	record.Time = now()
+	data, err := marshalRedacted(record)
+	if err != nil {
+		r.log.Error("Failed to marshal record", "type", record.Type, "error", err)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, err := r.out.Write(append(data, '\n')); err != nil {
+		r.log.Error("Failed to write record", "type", record.Type, "error", err)
+	}
+}
+
+// marshalRedacted masks the string values of the record, rather than the JSON document,
+// so masking never breaks the document structure
+func marshalRedacted(record Record) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(redactValue("", v))
+}
+
+// redactValue masks string values of sensitive keys, and known secrets in all other strings. The value of
+// a name/value pair, like a container env var, is masked when its name is sensitive
+func redactValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = redactValue(k, value)
+		}
+
+		if name, ok := v["name"].(string); ok && isSensitiveKey(name) {
+			if _, ok := v["value"].(string); ok {
+				v["value"] = redact.Mask
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(key, value)
+		}
+	case string:
+		if isSensitiveKey(key) {
+			return redact.Mask
+		}
+
+		return redact.String(v)
+	}
+
+	return v
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range logger.DefaultRedactKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkOptions(opts *Options) error {
+	if opts == nil {
+		return errOptionsRequired
+	}
+
+	if opts.Codefresh == nil {
+		return errCodefreshRequired
+	}
+
+	if opts.Output == nil {
+		return errOutputRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	return nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/codefresh"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/redact"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type taskAdder struct {
+	tasks task.Tasks
+}
+
+func (a *taskAdder) AddTasks(tasks ...task.Task) {
+	a.tasks = append(a.tasks, tasks...)
+}
+
+func TestRecorder(t *testing.T) {
+	redact.AddSecret("some-known-secret")
+	recordedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { return recordedAt }
+	defer func() { now = time.Now }()
+
+	tasks := task.Tasks{
+		{
+			Id:   "task-1",
+			Type: task.TypeCreatePod,
+			Metadata: task.Metadata{
+				WorkflowId: "wf1",
+				ReName:     "some-runtime",
+			},
+			Spec: map[string]interface{}{
+				"env": []interface{}{
+					map[string]interface{}{"name": "GREETING", "value": "uses some-known-secret"},
+					map[string]interface{}{"name": "DB_PASSWORD", "value": "some-unregistered-secret"},
+					map[string]interface{}{"name": "HOME", "value": "/tmp"},
+				},
+				"token":    "plain-value",
+				"replicas": float64(1),
+				"volumes": []interface{}{
+					map[string]interface{}{"secret": map[string]interface{}{"name": "not-sensitive"}},
+				},
+			},
+		},
+	}
+	status := task.TaskStatus{Status: task.StatusSuccess, StatusRevision: 1}
+	cf := codefresh.NewMockCodefresh(t)
+	cf.EXPECT().Tasks(mock.Anything).Return(nil, nil).Once()
+	cf.EXPECT().Tasks(mock.Anything).Return(nil, errors.New("some error")).Once()
+	cf.EXPECT().Tasks(mock.Anything).Return(tasks, nil).Once()
+	cf.EXPECT().ReportTaskStatus(mock.Anything, "task-1", status).Return(nil)
+	out := &bytes.Buffer{}
+	r, err := New(&Options{
+		Codefresh: cf,
+		Output:    out,
+		Logger:    logger.New(logger.Options{}),
+	})
+	assert.NoError(t, err)
+
+	for range 3 {
+		_, _ = r.Tasks(context.Background())
+	}
+
+	assert.NoError(t, r.ReportTaskStatus(context.Background(), "task-1", status))
+
+	records, err := ReadRecords(out)
+	assert.NoError(t, err)
+	// the empty batch is not recorded
+	assert.Len(t, records, 3)
+	assert.Equal(t, Record{Time: recordedAt, Type: RecordTypeTasks, Error: "some error"}, records[0])
+	assert.Equal(t, Record{Time: recordedAt, Type: RecordTypeTaskStatus, TaskID: "task-1", Status: &status}, records[2])
+
+	spec := records[1].Tasks[0].Spec.(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "GREETING", "value": "uses " + redact.Mask},
+		map[string]interface{}{"name": "DB_PASSWORD", "value": redact.Mask},
+		map[string]interface{}{"name": "HOME", "value": "/tmp"},
+	}, spec["env"])
+	assert.Equal(t, redact.Mask, spec["token"])
+	assert.Equal(t, float64(1), spec["replicas"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"secret": map[string]interface{}{"name": "not-sensitive"}},
+	}, spec["volumes"])
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		opts    *Options
+		wantErr error
+	}{
+		"should fail without options": {
+			wantErr: errOptionsRequired,
+		},
+		"should fail without a Codefresh client": {
+			opts:    &Options{Output: &bytes.Buffer{}},
+			wantErr: errCodefreshRequired,
+		},
+		"should fail without output": {
+			opts:    &Options{Codefresh: codefresh.NewMockCodefresh(t)},
+			wantErr: errOutputRequired,
+		},
+		"should fail without a logger": {
+			opts:    &Options{Codefresh: codefresh.NewMockCodefresh(t), Output: &bytes.Buffer{}},
+			wantErr: errLoggerRequired,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now()
+	records := []Record{
+		{Time: start, Type: RecordTypeTasks, Tasks: task.Tasks{{Id: "task-1"}}},
+		{Time: start.Add(time.Millisecond), Type: RecordTypeTasks, Error: "some error"},
+		{Time: start.Add(2 * time.Millisecond), Type: RecordTypeTaskStatus, TaskID: "task-1", Status: &task.TaskStatus{Status: task.StatusSuccess}},
+		{Time: start.Add(100 * time.Millisecond), Type: RecordTypeTasks, Tasks: task.Tasks{{Id: "task-2"}, {Id: "task-3"}}},
+		{Time: start.Add(101 * time.Millisecond), Type: RecordTypeTaskStatus, TaskID: "task-2", Status: &task.TaskStatus{Status: task.StatusSuccess}},
+		{Time: start.Add(102 * time.Millisecond), Type: RecordTypeTaskStatus, TaskID: "task-3", Status: &task.TaskStatus{Status: task.StatusError}},
+	}
+
+	adder := &taskAdder{}
+	replayStart := time.Now()
+	assert.Equal(t, 3, Replay(context.Background(), adder, records, 2))
+	assert.GreaterOrEqual(t, time.Since(replayStart), 50*time.Millisecond)
+	assert.Equal(t, task.Tasks{{Id: "task-1"}, {Id: "task-2"}, {Id: "task-3"}}, adder.tasks)
+
+	replayed := map[string][]task.TaskStatus{
+		"task-1": {{Status: task.StatusSuccess}},
+		"task-3": {{Status: task.StatusError}, {Status: task.StatusSuccess}},
+	}
+	assert.Equal(t, []Mismatch{
+		{TaskID: "task-2", Recorded: task.StatusSuccess},
+		{TaskID: "task-3", Recorded: task.StatusError, Replayed: task.StatusSuccess},
+	}, Compare(records, func(id string) []task.TaskStatus { return replayed[id] }))
+}
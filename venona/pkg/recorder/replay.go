@@ -0,0 +1,140 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/task"
+)
+
+type (
+	// TaskAdder receives the replayed task batches, e.g. a fakeplatform.Platform
+	TaskAdder interface {
+		AddTasks(tasks ...task.Task)
+	}
+
+	// Mismatch is a task whose last replayed status differs from its last recorded one
+	Mismatch struct {
+		TaskID   string
+		Recorded task.Status
+		Replayed task.Status
+	}
+)
+
+// maxRecordSize is the longest line of a recording, task batches can be large
+const maxRecordSize = 16 * 1024 * 1024
+
+// LoadRecording reads the records of a JSONL recording file
+func LoadRecording(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := ReadRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading recording \"%s\": %w", path, err)
+	}
+
+	return records, nil
+}
+
+// ReadRecords reads JSONL records, empty lines are skipped
+func ReadRecords(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// Replay adds the recorded task batches to the adder at their recorded time offsets, divided by speed.
+// A speed of 0 or less replays all the batches at once. Failed pulls and status records are skipped.
+// It returns the number of replayed tasks, when all batches are added or ctx is cancelled
+func Replay(ctx context.Context, adder TaskAdder, records []Record, speed float64) int {
+	var first time.Time
+	start := time.Now()
+	count := 0
+	for _, record := range records {
+		if record.Type != RecordTypeTasks || len(record.Tasks) == 0 {
+			continue
+		}
+
+		if first.IsZero() {
+			first = record.Time
+		}
+
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(record.Time.Sub(first)) / speed))
+			select {
+			case <-ctx.Done():
+				return count
+			case <-time.After(time.Until(due)):
+			}
+		}
+
+		adder.AddTasks(record.Tasks...)
+		count += len(record.Tasks)
+	}
+
+	return count
+}
+
+// Compare returns the tasks whose last replayed status is not the last recorded one, sorted by task id.
+// Tasks that were not reported in the recording are skipped
+func Compare(records []Record, replayed func(taskID string) []task.TaskStatus) []Mismatch {
+	recorded := map[string]task.Status{}
+	for _, record := range records {
+		if record.Type == RecordTypeTaskStatus && record.Status != nil && record.Error == "" {
+			recorded[record.TaskID] = record.Status.Status
+		}
+	}
+
+	res := []Mismatch{}
+	for id, status := range recorded {
+		var last task.Status
+		if statuses := replayed(id); len(statuses) > 0 {
+			last = statuses[len(statuses)-1].Status
+		}
+
+		if last != status {
+			res = append(res, Mismatch{TaskID: id, Recorded: status, Replayed: last})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].TaskID < res[j].TaskID })
+	return res
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted value
+const Mask = "[REDACTED]"
+
+// minSecretLength avoids masking short, common substrings when a secret is misconfigured
+const minSecretLength = 6
+
+type (
+	// Redactor masks known secret values and values matching regex patterns
+	Redactor struct {
+		mutex    sync.RWMutex
+		secrets  []string
+		patterns []*regexp.Regexp
+	}
+)
+
+var (
+	// defaultPatterns mask the value of common credential assignments, e.g. `"x-access-token": "..."` or `token=...`
+	defaultPatterns = []string{
+		`(?i)((?:authorization|x-access-token|access[_-]?token|api[_-]?key|token|license[_-]?key|password|secret)"?\s*[:=]\s*"?)(?:bearer\s+)?[^\s"'&,;}]+`,
+	}
+
+	global = New()
+)
+
+// New creates a new Redactor with the default patterns
+func New() *Redactor {
+	r := &Redactor{}
+	for _, p := range defaultPatterns {
+		r.patterns = append(r.patterns, regexp.MustCompile(p))
+	}
+
+	return r
+}
+
+// AddSecret registers a known secret value, every occurrence of it is masked.
+// Values shorter than 6 characters are ignored
+func (r *Redactor) AddSecret(secret string) {
+	secret = strings.TrimSpace(secret)
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, s := range r.secrets {
+		if s == secret {
+			return
+		}
+	}
+
+	r.secrets = append(r.secrets, secret)
+	// longer secrets first, in case one secret contains another
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// AddPattern registers a regex, matches are masked. If the regex has capture groups,
+// the first group is kept and only the rest of the match is masked
+func (r *Redactor) AddPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid redact pattern \"%s\": %w", pattern, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.patterns = append(r.patterns, re)
+	return nil
+}
+
+// String masks all the known secrets and pattern matches in s, and any of the extra secrets
+func (r *Redactor) String(s string, extra ...string) string {
+	if s == "" {
+		return s
+	}
+
+	for _, e := range extra {
+		if len(e) >= minSecretLength {
+			s = strings.ReplaceAll(s, e, Mask)
+		}
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+Mask)
+		} else {
+			s = re.ReplaceAllString(s, Mask)
+		}
+	}
+
+	return s
+}
+
+// Value redacts the string representation of strings, errors and fmt.Stringers.
+// Other values are returned as is
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case []byte:
+		return r.String(string(val))
+	case error:
+		if s := val.Error(); r.String(s) != s {
+			return r.String(s)
+		}
+	case fmt.Stringer:
+		if s := val.String(); r.String(s) != s {
+			return r.String(s)
+		}
+	}
+
+	return v
+}
+
+// AddSecret registers a known secret value with the global redactor
+func AddSecret(secret string) {
+	global.AddSecret(secret)
+}
+
+// AddPattern registers a regex with the global redactor
+func AddPattern(pattern string) error {
+	return global.AddPattern(pattern)
+}
+
+// String masks secrets in s with the global redactor
+func String(s string, extra ...string) string {
+	return global.String(s, extra...)
+}
+
+// Value masks secrets in v with the global redactor
+func Value(v interface{}) interface{} {
+	return global.Value(v)
+}
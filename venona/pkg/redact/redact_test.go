@@ -0,0 +1,97 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_String(t *testing.T) {
+	tests := map[string]struct {
+		secrets  []string
+		patterns []string
+		extra    []string
+		input    string
+		want     string
+	}{
+		"should mask a known secret": {
+			secrets: []string{"some-codefresh-token"},
+			input:   "failed with some-codefresh-token in the body",
+			want:    "failed with [REDACTED] in the body",
+		},
+		"should prefer the longest secret": {
+			secrets: []string{"abcdef", "abcdefghijkl"},
+			input:   "abcdefghijkl",
+			want:    "[REDACTED]",
+		},
+		"should ignore short secrets": {
+			secrets: []string{"abc"},
+			input:   "abc",
+			want:    "abc",
+		},
+		"should mask credential assignments": {
+			input: `{"x-access-token": "eyJhbGciOi", "url": "https://host/api?token=1234567&a=b"}`,
+			want:  `{"x-access-token": "[REDACTED]", "url": "https://host/api?token=[REDACTED]&a=b"}`,
+		},
+		"should mask bearer tokens": {
+			input: "Authorization: Bearer abcdefghij",
+			want:  "Authorization: [REDACTED]",
+		},
+		"should mask custom patterns": {
+			patterns: []string{`ghp_[A-Za-z0-9]+`},
+			input:    "cloning with ghp_abcdef123",
+			want:     "cloning with [REDACTED]",
+		},
+		"should mask extra secrets": {
+			extra: []string{"some-proxy-token"},
+			input: "response: some-proxy-token",
+			want:  "response: [REDACTED]",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := New()
+			for _, s := range tt.secrets {
+				r.AddSecret(s)
+			}
+
+			for _, p := range tt.patterns {
+				assert.NoError(t, r.AddPattern(p))
+			}
+
+			assert.Equal(t, tt.want, r.String(tt.input, tt.extra...))
+		})
+	}
+}
+
+func TestRedactor_AddPattern(t *testing.T) {
+	err := New().AddPattern("(")
+	assert.EqualError(t, err, "invalid redact pattern \"(\": error parsing regexp: missing closing ): `(`")
+}
+
+func TestRedactor_Value(t *testing.T) {
+	r := New()
+	r.AddSecret("some-runtime-token")
+	err := errors.New("unauthorized: some-runtime-token")
+	assert.Equal(t, "unauthorized: [REDACTED]", r.Value(err))
+	assert.Equal(t, "[REDACTED]", r.Value("some-runtime-token"))
+	assert.Equal(t, 3, r.Value(3))
+
+	clean := errors.New("not found")
+	assert.Equal(t, clean, r.Value(clean))
+}
@@ -0,0 +1,213 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultRegistry = "docker.io"
+
+type (
+	// Config of the images of a runtime, in the registry section of the runtime config
+	Config struct {
+		Rewrites []Rewrite `yaml:"rewrites,omitempty" json:"rewrites,omitempty"`
+		// Digests is the path of a YAML file that maps images, after they are rewritten, to the digests they are pinned to,
+		// e.g. registry.local/codefresh/engine:1.0: sha256:...
+		Digests     string   `yaml:"digests,omitempty" json:"digests,omitempty"`
+		PullSecrets []string `yaml:"pullSecrets,omitempty" json:"pullSecrets,omitempty"`
+	}
+
+	// Rewrite replaces the From prefix of images with To, e.g. docker.io/codefresh/ with registry.local/codefresh/.
+	// Images without a registry are matched as docker.io images
+	Rewrite struct {
+		From string `yaml:"from" json:"from"`
+		To   string `yaml:"to" json:"to"`
+	}
+
+	// Rewriter applies a Config to pods
+	Rewriter struct {
+		rewrites    []Rewrite
+		digests     map[string]string
+		pullSecrets []string
+	}
+
+	// Change of the image of a container
+	Change struct {
+		Container string
+		From      string
+		To        string
+	}
+)
+
+var (
+	errRewriteRequired = errors.New("rewrites require both from and to")
+	digestRegexp       = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// LoadConfig reads a YAML file of a registry config, the digests file is relative to it
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed parsing registry config \"%s\": %w", file, err)
+	}
+
+	if cfg.Digests != "" && !filepath.IsAbs(cfg.Digests) {
+		cfg.Digests = filepath.Join(filepath.Dir(file), cfg.Digests)
+	}
+
+	return cfg, nil
+}
+
+// New creates a Rewriter of the config, and loads its digests file
+func New(cfg *Config) (*Rewriter, error) {
+	r := &Rewriter{
+		rewrites:    append([]Rewrite{}, cfg.Rewrites...),
+		pullSecrets: cfg.PullSecrets,
+	}
+	for _, rw := range r.rewrites {
+		if rw.From == "" || rw.To == "" {
+			return nil, errRewriteRequired
+		}
+	}
+
+	// the longest prefix wins
+	sort.SliceStable(r.rewrites, func(i, j int) bool {
+		return len(r.rewrites[i].From) > len(r.rewrites[j].From)
+	})
+
+	if cfg.Digests != "" {
+		digests, err := loadDigests(cfg.Digests)
+		if err != nil {
+			return nil, err
+		}
+
+		r.digests = digests
+	}
+
+	return r, nil
+}
+
+// Apply rewrites and pins the images of all the containers and init containers of the pod, and adds the pull secrets
+// it does not have yet. It returns the images that were changed
+func (r *Rewriter) Apply(pod *v1.Pod) []Change {
+	if r == nil {
+		return nil
+	}
+
+	var changes []Change
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			if image := r.image(c.Image); image != c.Image {
+				changes = append(changes, Change{Container: c.Name, From: c.Image, To: image})
+				c.Image = image
+			}
+		}
+	}
+
+	for _, name := range r.pullSecrets {
+		if !hasPullSecret(pod, name) {
+			pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: name})
+		}
+	}
+
+	return changes
+}
+
+// image returns the rewritten image, pinned to its digest when it has one in the digests file
+func (r *Rewriter) image(image string) string {
+	normalized := Normalize(image)
+	for _, rw := range r.rewrites {
+		if strings.HasPrefix(normalized, rw.From) {
+			image = rw.To + strings.TrimPrefix(normalized, rw.From)
+			break
+		}
+	}
+
+	if strings.Contains(image, "@") {
+		// already pinned
+		return image
+	}
+
+	if digest, ok := r.digests[image]; ok {
+		return image + "@" + digest
+	}
+
+	return image
+}
+
+// Normalize adds the docker.io registry, and the library repository, to images that do not have them,
+// e.g. codefresh/engine:1.0 is docker.io/codefresh/engine:1.0
+func Normalize(image string) string {
+	first, rest, found := strings.Cut(image, "/")
+	if !found {
+		return defaultRegistry + "/library/" + image
+	}
+
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return image
+	}
+
+	return defaultRegistry + "/" + first + "/" + rest
+}
+
+func loadDigests(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := map[string]string{}
+	if err := yaml.UnmarshalStrict(data, &digests); err != nil {
+		return nil, fmt.Errorf("failed parsing digests file \"%s\": %w", file, err)
+	}
+
+	for image, digest := range digests {
+		if !digestRegexp.MatchString(digest) {
+			return nil, fmt.Errorf("invalid digest \"%s\" of image \"%s\" in \"%s\", expected sha256:<64 hex digits>", digest, image, file)
+		}
+	}
+
+	return digests, nil
+}
+
+func hasPullSecret(pod *v1.Pod, name string) bool {
+	for _, s := range pod.Spec.ImagePullSecrets {
+		if s.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Container, c.From, c.To)
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+var engineDigest = "sha256:" + strings.Repeat("a", 64)
+
+func writeFile(t *testing.T, dir string, name string, data string) string {
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestRewriter_Apply(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "digests.yaml", "registry.local/codefresh/engine:1.0: "+engineDigest+"\n")
+	cfg, err := LoadConfig(writeFile(t, dir, "registry.yaml", `
+rewrites:
+- from: docker.io/codefresh/
+  to: registry.local/codefresh/
+- from: docker.io/
+  to: registry.local/mirror/
+digests: digests.yaml
+pullSecrets: [registry-creds, existing]
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r, err := New(cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pod := &v1.Pod{Spec: v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "alpine:3"}},
+		Containers: []v1.Container{
+			{Name: "engine", Image: "codefresh/engine:1.0"},
+			{Name: "dind", Image: "docker.io/codefresh/dind:1.0@" + engineDigest},
+			{Name: "private", Image: "quay.io/codefresh/private:1.0"},
+		},
+		ImagePullSecrets: []v1.LocalObjectReference{{Name: "existing"}},
+	}}
+	changes := r.Apply(pod)
+
+	assert.Equal(t, []Change{
+		{Container: "init", From: "alpine:3", To: "registry.local/mirror/library/alpine:3"},
+		{Container: "engine", From: "codefresh/engine:1.0", To: "registry.local/codefresh/engine:1.0@" + engineDigest},
+		{Container: "dind", From: "docker.io/codefresh/dind:1.0@" + engineDigest, To: "registry.local/codefresh/dind:1.0@" + engineDigest},
+	}, changes)
+	assert.Equal(t, "quay.io/codefresh/private:1.0", pod.Spec.Containers[2].Image)
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "existing"}, {Name: "registry-creds"}}, pod.Spec.ImagePullSecrets)
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]struct {
+		cfg     *Config
+		wantErr string
+	}{
+		"should fail on a rewrite without a target": {
+			cfg:     &Config{Rewrites: []Rewrite{{From: "docker.io/"}}},
+			wantErr: errRewriteRequired.Error(),
+		},
+		"should fail on an invalid digest": {
+			cfg:     &Config{Digests: writeFile(t, dir, "invalid.yaml", "alpine:3: latest\n")},
+			wantErr: "invalid digest \"latest\" of image \"alpine:3\"",
+		},
+		"should fail on a missing digests file": {
+			cfg:     &Config{Digests: filepath.Join(dir, "missing.yaml")},
+			wantErr: "no such file or directory",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
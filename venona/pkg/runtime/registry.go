@@ -0,0 +1,122 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/task"
+)
+
+type (
+	// Registry holds the runtimes by name. It is safe for concurrent use,
+	// runtimes can be added and removed while tasks are handled
+	Registry struct {
+		mutex    sync.RWMutex
+		runtimes map[string]*trackedRuntime
+	}
+
+	// trackedRuntime records when it last handled a task
+	trackedRuntime struct {
+		Runtime
+		mutex      sync.Mutex
+		lastTaskAt time.Time
+	}
+)
+
+// NewRegistry creates a registry with the given runtimes
+func NewRegistry(runtimes map[string]Runtime) *Registry {
+	r := &Registry{
+		runtimes: map[string]*trackedRuntime{},
+	}
+	for name, rt := range runtimes {
+		r.Set(name, rt)
+	}
+
+	return r
+}
+
+// Get returns the runtime with the given name
+func (r *Registry) Get(name string) (Runtime, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	rt, ok := r.runtimes[name]
+	if !ok {
+		return nil, false
+	}
+
+	return rt, true
+}
+
+// Set adds the runtime, or replaces an existing one with the same name.
+// Tasks that are already handled by the replaced runtime are not affected
+func (r *Registry) Set(name string, rt Runtime) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	tracked := &trackedRuntime{Runtime: rt}
+	if prev, ok := r.runtimes[name]; ok {
+		tracked.lastTaskAt = prev.LastTaskAt()
+	}
+
+	r.runtimes[name] = tracked
+}
+
+// Delete removes the runtime, new tasks of this runtime fail with "runtime not found"
+func (r *Registry) Delete(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.runtimes, name)
+}
+
+// Names returns the sorted names of all the runtimes
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.runtimes))
+	for name := range r.runtimes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// LastTaskAt returns when the runtime last handled a task, zero if it did not yet
+func (r *Registry) LastTaskAt(name string) time.Time {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	rt, ok := r.runtimes[name]
+	if !ok {
+		return time.Time{}
+	}
+
+	return rt.LastTaskAt()
+}
+
+func (t *trackedRuntime) HandleTask(ctx context.Context, tsk *task.Task) error {
+	t.mutex.Lock()
+	t.lastTaskAt = time.Now()
+	t.mutex.Unlock()
+	return t.Runtime.HandleTask(ctx, tsk)
+}
+
+func (t *trackedRuntime) LastTaskAt() time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.lastTaskAt
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegistry(t *testing.T) {
+	k := kubernetes.NewMockKubernetes(t)
+	k.EXPECT().CreateResource(mock.Anything, task.TypeCreatePod, "some spec").Return(nil)
+	r := NewRegistry(map[string]Runtime{
+		"some-runtime": New(Options{Kubernetes: k}),
+	})
+
+	rt, ok := r.Get("some-runtime")
+	assert.True(t, ok)
+	assert.True(t, r.LastTaskAt("some-runtime").IsZero())
+	assert.NoError(t, rt.HandleTask(context.Background(), &task.Task{Type: task.TypeCreatePod, Spec: "some spec"}))
+	lastTaskAt := r.LastTaskAt("some-runtime")
+	assert.False(t, lastTaskAt.IsZero())
+
+	// replacing a runtime keeps its last task time
+	r.Set("some-runtime", New(Options{}))
+	r.Set("other-runtime", New(Options{}))
+	assert.Equal(t, lastTaskAt, r.LastTaskAt("some-runtime"))
+	assert.Equal(t, []string{"other-runtime", "some-runtime"}, r.Names())
+
+	r.Delete("some-runtime")
+	rt, ok = r.Get("some-runtime")
+	assert.False(t, ok)
+	assert.Nil(t, rt)
+	assert.True(t, r.LastTaskAt("some-runtime").IsZero())
+}
@@ -49,6 +49,10 @@ func (e HandleTaskError) IsRetriable() bool {
 	return e.isRetriable
 }
 
+func (e HandleTaskError) Unwrap() error {
+	return e.error
+}
+
 func NewHandleTaskError(err error, isRetriable bool) error {
 	return &HandleTaskError{
 		error:       err,
@@ -72,16 +76,23 @@ func (r runtime) HandleTask(ctx context.Context, t *task.Task) error {
 		}
 	case task.TypeDeletePVC, task.TypeDeletePod:
 		opts := kubernetes.DeleteOptions{}
-		opts.Kind = t.Type
-		b, err := json.Marshal(t.Spec)
-		if err != nil {
-			return NewHandleTaskError(fmt.Errorf("failed to marshal task spec: %w", err), false)
-		}
+		if spec, ok := t.Spec.(task.DeleteResourceSpec); ok {
+			opts.Name, opts.Namespace = spec.Name, spec.Namespace
+			opts.GracePeriodSeconds, opts.PropagationPolicy = spec.GracePeriodSeconds, spec.PropagationPolicy
+		} else {
+			// the spec was not decoded by task.UnmarshalTasks
+			b, err := json.Marshal(t.Spec)
+			if err != nil {
+				return NewHandleTaskError(fmt.Errorf("failed to marshal task spec: %w", err), false)
+			}
 
-		if err := json.Unmarshal(b, &opts); err != nil {
-			return NewHandleTaskError(fmt.Errorf("failed to unmarshal task spec: %w", err), false)
+			if err := json.Unmarshal(b, &opts); err != nil {
+				return NewHandleTaskError(fmt.Errorf("failed to unmarshal task spec: %w", err), false)
+			}
 		}
 
+		opts.Kind = t.Type
+
 		if err := r.client.DeleteResource(ctx, opts); err != nil {
 			return NewHandleTaskError(fmt.Errorf("failed deleting resource: %w", err), ierrors.IsRetriable(err))
 		}
@@ -82,6 +82,26 @@ func Test_runtime_HandleTask(t *testing.T) {
 				}).Return(nil)
 			},
 		},
+		"should delete a resource with a typed spec": {
+			task: &task.Task{
+				Type: task.TypeDeletePod,
+				Spec: task.DeleteResourceSpec{
+					Namespace:          "some-namespace",
+					Name:               "some-name",
+					GracePeriodSeconds: new(int64),
+					PropagationPolicy:  "Background",
+				},
+			},
+			beforeFn: func(k *kubernetes.MockKubernetes) {
+				k.EXPECT().DeleteResource(mock.Anything, kubernetes.DeleteOptions{
+					Kind:               task.TypeDeletePod,
+					Name:               "some-name",
+					Namespace:          "some-namespace",
+					GracePeriodSeconds: new(int64),
+					PropagationPolicy:  "Background",
+				}).Return(nil)
+			},
+		},
 		"should fail for unknown type": {
 			task: &task.Task{
 				Type: "some-type",
@@ -41,6 +41,8 @@ type (
 		Logger          logger.Logger
 		Monitor         monitoring.Monitor
 		MetricsRegistry *prometheus.Registry
+		// Handlers are served in addition to the health and metrics endpoints, by path
+		Handlers map[string]http.Handler
 	}
 
 	// Server is an HTTP server that expose API
@@ -69,6 +71,9 @@ func New(opts *Options) (*Server, error) {
 	})
 
 	r.Handle("/metrics", promhttp.HandlerFor(opts.MetricsRegistry, promhttp.HandlerOpts{Registry: opts.MetricsRegistry}))
+	for path, h := range opts.Handlers {
+		r.Handle(path, h)
+	}
 
 	srv := &http.Server{
 		Addr:              opts.Port,
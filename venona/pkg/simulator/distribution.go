@@ -0,0 +1,131 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// DistributionConstant always samples Mean
+	DistributionConstant DistributionKind = "constant"
+	// DistributionUniform samples between Min and Max
+	DistributionUniform DistributionKind = "uniform"
+	// DistributionNormal samples around Mean with StdDev, never below 0
+	DistributionNormal DistributionKind = "normal"
+	// DistributionExponential samples with Mean, which gives the long tail of a loaded API server
+	DistributionExponential DistributionKind = "exponential"
+)
+
+type (
+	// DistributionKind is the shape of a latency distribution
+	DistributionKind string
+
+	// Distribution of the latency of an operation
+	Distribution struct {
+		Kind   DistributionKind
+		Mean   time.Duration
+		StdDev time.Duration
+		Min    time.Duration
+		Max    time.Duration
+	}
+)
+
+// ParseDistribution parses a distribution from one of:
+// "<mean>", "constant:<mean>", "uniform:<min>-<max>", "normal:<mean>,<stddev>" or "exponential:<mean>"
+func ParseDistribution(s string) (Distribution, error) {
+	kind, value, ok := strings.Cut(s, ":")
+	if !ok {
+		kind, value = string(DistributionConstant), s
+	}
+
+	d := Distribution{Kind: DistributionKind(kind)}
+	var err error
+	switch d.Kind {
+	case DistributionConstant, DistributionExponential:
+		d.Mean, err = time.ParseDuration(value)
+	case DistributionUniform:
+		d.Min, d.Max, err = parsePair(value, "-")
+		if err == nil && d.Min > d.Max {
+			err = fmt.Errorf("min %s is greater than max %s", d.Min, d.Max)
+		}
+	case DistributionNormal:
+		d.Mean, d.StdDev, err = parsePair(value, ",")
+	default:
+		err = fmt.Errorf("unknown distribution \"%s\"", kind)
+	}
+
+	if err != nil {
+		return Distribution{}, fmt.Errorf("invalid distribution \"%s\": %w", s, err)
+	}
+
+	return d, nil
+}
+
+// Sample returns a random latency from the distribution
+func (d Distribution) Sample(r *rand.Rand) time.Duration {
+	var res time.Duration
+	switch d.Kind {
+	case DistributionUniform:
+		res = d.Min
+		if d.Max > d.Min {
+			res += time.Duration(r.Int63n(int64(d.Max - d.Min)))
+		}
+	case DistributionNormal:
+		res = d.Mean + time.Duration(r.NormFloat64()*float64(d.StdDev))
+	case DistributionExponential:
+		res = time.Duration(r.ExpFloat64() * float64(d.Mean))
+	default:
+		res = d.Mean
+	}
+
+	return max(res, 0)
+}
+
+// String returns the distribution in the format of ParseDistribution
+func (d Distribution) String() string {
+	switch d.Kind {
+	case DistributionUniform:
+		return fmt.Sprintf("%s:%s-%s", d.Kind, d.Min, d.Max)
+	case DistributionNormal:
+		return fmt.Sprintf("%s:%s,%s", d.Kind, d.Mean, d.StdDev)
+	case DistributionExponential:
+		return fmt.Sprintf("%s:%s", d.Kind, d.Mean)
+	default:
+		return d.Mean.String()
+	}
+}
+
+func parsePair(s string, sep string) (time.Duration, time.Duration, error) {
+	a, b, ok := strings.Cut(s, sep)
+	if !ok {
+		return 0, 0, fmt.Errorf("expected two durations separated by \"%s\"", sep)
+	}
+
+	first, err := time.ParseDuration(a)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	second, err := time.ParseDuration(b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return first, second, nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulator simulates the Kubernetes API server of a runtime, for load testing the agent without a cluster.
+// The runtimes talk to the simulated server with the same client as to a real cluster, including its rate limiter and retries.
+// The requests take a configurable time, fail at a configurable rate, and are throttled like an overloaded API server does
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/runtime"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// VerbDefault configures the operations of all the verbs that are not configured explicitly
+	VerbDefault = "*"
+
+	defaultRetryAfterSeconds = 1
+)
+
+type (
+	// Operation configures how the simulated API server handles the requests of a verb
+	Operation struct {
+		Latency Distribution
+		// ErrorRate is the fraction of requests, between 0 and 1, that fail with an internal error
+		ErrorRate float64
+	}
+
+	// Options for the simulator
+	Options struct {
+		// Operations by verb, one of "create", "delete", "patch" or "get". VerbDefault applies to all other verbs
+		Operations map[string]Operation
+		// ThrottleRate is the fraction of requests, between 0 and 1, that the API server rejects with 429 Too Many Requests,
+		// the client retries them after RetryAfterSeconds (default 1)
+		ThrottleRate      float64
+		RetryAfterSeconds int
+		// Seed makes the simulation repeatable, the current time is used when it is 0
+		Seed int64
+	}
+
+	// OperationStats counts the simulated requests of a verb
+	OperationStats struct {
+		Requests  int
+		Errors    int
+		Throttled int
+	}
+
+	// Simulator is a simulated API server, listening on a local port
+	Simulator struct {
+		operations map[string]Operation
+		throttle   float64
+		retryAfter int
+		mutex      sync.Mutex
+		rand       *rand.Rand
+		stats      map[string]*OperationStats
+		listener   net.Listener
+		server     *http.Server
+	}
+)
+
+var (
+	errSimulated = errors.New("simulated API server error")
+
+	// kinds of the resources the runtimes create and delete
+	kinds = map[string]string{
+		"pods":                   "Pod",
+		"persistentvolumeclaims": "PersistentVolumeClaim",
+	}
+
+	// sleep is overridden in tests
+	sleep = time.Sleep
+)
+
+// New starts a new Simulator, it must be closed when it is no longer used
+func New(opts Options) (*Simulator, error) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	retryAfter := opts.RetryAfterSeconds
+	if retryAfter <= 0 {
+		retryAfter = defaultRetryAfterSeconds
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Simulator{
+		operations: opts.Operations,
+		throttle:   opts.ThrottleRate,
+		retryAfter: retryAfter,
+		// #nosec G404 -- the simulation does not need a secure random source
+		rand:     rand.New(rand.NewSource(seed)),
+		stats:    map[string]*OperationStats{},
+		listener: listener,
+	}
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 60 * time.Second,
+	}
+	go func() { _ = s.server.Serve(listener) }()
+	return s, nil
+}
+
+// Host returns the URL of the simulated API server
+func (s *Simulator) Host() string {
+	return fmt.Sprintf("http://%s", s.listener.Addr())
+}
+
+// Close stops the simulated API server
+func (s *Simulator) Close() error {
+	return s.server.Close()
+}
+
+// Runtime returns a runtime that handles the tasks with the simulated API server,
+// the client options (Logger, QPS, Burst, Monitor) are used as given, the connection options are set to the simulator
+func (s *Simulator) Runtime(opts kubernetes.Options) (runtime.Runtime, error) {
+	opts.Type = "runtime"
+	opts.Host = s.Host()
+	opts.Token = "simulated"
+	opts.Insecure = true
+	k, err := kubernetes.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return runtime.New(runtime.Options{Kubernetes: k}), nil
+}
+
+// Stats returns the request counts by verb
+func (s *Simulator) Stats() map[string]OperationStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := make(map[string]OperationStats, len(s.stats))
+	for verb, stats := range s.stats {
+		res[verb] = *stats
+	}
+
+	return res
+}
+
+// ServeHTTP handles the requests of a runtime to /api/v1/namespaces/<namespace>/<resource>[/<name>].
+// The objects are not stored so the simulation can run for long: creating always succeeds,
+// deleting and patching never find the object missing, and getting never finds it
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace, resource, name := parsePath(r.URL.Path)
+	verb := requestVerb(r.Method)
+	latency, throttled, failed := s.next(verb)
+	sleep(latency)
+
+	gr := schema.GroupResource{Resource: resource}
+	switch {
+	case throttled:
+		w.Header().Set("Retry-After", strconv.Itoa(s.retryAfter))
+		writeStatus(w, k8serrors.NewTooManyRequests("simulated API server throttling", s.retryAfter))
+	case failed:
+		writeStatus(w, k8serrors.NewInternalError(errSimulated))
+	case kinds[resource] == "":
+		writeStatus(w, k8serrors.NewNotFound(gr, name))
+	case verb == "create":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeStatus(w, k8serrors.NewBadRequest(err.Error()))
+			return
+		}
+
+		// the created object is the requested one, in the encoding of the client (JSON or protobuf)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	case verb == "delete":
+		writeJSON(w, http.StatusOK, metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusSuccess,
+		})
+	case verb == "patch":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kinds[resource],
+			"metadata":   metav1.ObjectMeta{Name: name, Namespace: namespace},
+		})
+	default:
+		writeStatus(w, k8serrors.NewNotFound(gr, name))
+	}
+}
+
+// next draws the outcome of a request, and counts it
+func (s *Simulator) next(verb string) (time.Duration, bool, bool) {
+	op, ok := s.operations[verb]
+	if !ok {
+		op = s.operations[VerbDefault]
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats, ok := s.stats[verb]
+	if !ok {
+		stats = &OperationStats{}
+		s.stats[verb] = stats
+	}
+
+	stats.Requests++
+	latency := op.Latency.Sample(s.rand)
+	throttled := s.rand.Float64() < s.throttle
+	failed := !throttled && s.rand.Float64() < op.ErrorRate
+	if throttled {
+		stats.Throttled++
+	} else if failed {
+		stats.Errors++
+	}
+
+	return latency, throttled, failed
+}
+
+func parsePath(path string) (namespace, resource, name string) {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/v1/"), "/")
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace, resource = parts[1], parts[2]
+	}
+
+	if len(parts) >= 4 {
+		name = parts[3]
+	}
+
+	return namespace, resource, name
+}
+
+func requestVerb(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodDelete:
+		return "delete"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodGet:
+		return "get"
+	default:
+		return strings.ToLower(method)
+	}
+}
+
+func writeStatus(w http.ResponseWriter, err *k8serrors.StatusError) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	writeJSON(w, int(status.Code), status)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
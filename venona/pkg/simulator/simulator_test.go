@@ -0,0 +1,162 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	ierrors "github.com/codefresh-io/go/venona/pkg/errors"
+	"github.com/codefresh-io/go/venona/pkg/kubernetes"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDistribution(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    Distribution
+		wantErr string
+	}{
+		"should parse a constant without a kind": {
+			input: "20ms",
+			want:  Distribution{Kind: DistributionConstant, Mean: 20 * time.Millisecond},
+		},
+		"should parse uniform": {
+			input: "uniform:10ms-50ms",
+			want:  Distribution{Kind: DistributionUniform, Min: 10 * time.Millisecond, Max: 50 * time.Millisecond},
+		},
+		"should parse normal": {
+			input: "normal:30ms,5ms",
+			want:  Distribution{Kind: DistributionNormal, Mean: 30 * time.Millisecond, StdDev: 5 * time.Millisecond},
+		},
+		"should parse exponential": {
+			input: "exponential:1s",
+			want:  Distribution{Kind: DistributionExponential, Mean: time.Second},
+		},
+		"should fail when min is greater than max": {
+			input:   "uniform:50ms-10ms",
+			wantErr: "invalid distribution \"uniform:50ms-10ms\": min 50ms is greater than max 10ms",
+		},
+		"should fail on an unknown kind": {
+			input:   "poisson:1s",
+			wantErr: "invalid distribution \"poisson:1s\": unknown distribution \"poisson\"",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDistribution(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			// the string form parses back to the same distribution
+			again, err := ParseDistribution(got.String())
+			assert.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestDistribution_Sample(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	uniform := Distribution{Kind: DistributionUniform, Min: 10 * time.Millisecond, Max: 20 * time.Millisecond}
+	normal := Distribution{Kind: DistributionNormal, Mean: time.Millisecond, StdDev: 10 * time.Millisecond}
+	for range 100 {
+		d := uniform.Sample(r)
+		assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+		assert.Less(t, d, 20*time.Millisecond)
+		assert.GreaterOrEqual(t, normal.Sample(r), time.Duration(0))
+	}
+}
+
+func TestSimulator_Runtime(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		slept []time.Duration
+	)
+	sleep = func(d time.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		slept = append(slept, d)
+	}
+	defer func() { sleep = time.Sleep }()
+
+	sim, err := New(Options{
+		Operations: map[string]Operation{
+			"create":    {Latency: Distribution{Mean: 5 * time.Millisecond}},
+			VerbDefault: {Latency: Distribution{Mean: time.Millisecond}},
+		},
+		Seed: 1,
+	})
+	assert.NoError(t, err)
+	defer sim.Close()
+
+	re, err := sim.Runtime(kubernetes.Options{Logger: logger.New(logger.Options{}), QPS: 100, Burst: 100})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, re.HandleTask(ctx, &task.Task{Type: task.TypeCreatePod, Spec: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "some-pod", "namespace": "some-namespace"},
+	}}))
+	assert.NoError(t, re.HandleTask(ctx, &task.Task{Type: task.TypeCreatePVC, Spec: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   map[string]interface{}{"name": "some-pvc", "namespace": "some-namespace"},
+	}}))
+	assert.NoError(t, re.HandleTask(ctx, &task.Task{Type: task.TypeDeletePod, Spec: map[string]interface{}{
+		"name":      "some-pod",
+		"namespace": "some-namespace",
+	}}))
+
+	mutex.Lock()
+	assert.Equal(t, []time.Duration{5 * time.Millisecond, 5 * time.Millisecond, time.Millisecond}, slept)
+	mutex.Unlock()
+	assert.Equal(t, map[string]OperationStats{
+		"create": {Requests: 2},
+		"delete": {Requests: 1},
+	}, sim.Stats())
+}
+
+func TestSimulator_errors(t *testing.T) {
+	sim, err := New(Options{
+		Operations: map[string]Operation{
+			VerbDefault: {ErrorRate: 1},
+		},
+	})
+	assert.NoError(t, err)
+	defer sim.Close()
+
+	re, err := sim.Runtime(kubernetes.Options{Logger: logger.New(logger.Options{}), QPS: 100, Burst: 100})
+	assert.NoError(t, err)
+
+	err = re.HandleTask(context.Background(), &task.Task{Type: task.TypeDeletePVC, Spec: map[string]interface{}{
+		"name":      "some-pvc",
+		"namespace": "some-namespace",
+	}})
+	assert.ErrorContains(t, err, "simulated API server error")
+	assert.True(t, ierrors.IsRetriable(err))
+	assert.Equal(t, map[string]OperationStats{"delete": {Requests: 1, Errors: 1}}, sim.Stats())
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/pairs"
+)
+
+// ParseDurations parses a comma separated list of type=duration pairs of the given types, e.g. "CreatePod=1m,DeletePod=1m".
+// name describes a pair in the errors, e.g. "task timeout"
+func ParseDurations(s, name string, types ...Type) (map[Type]time.Duration, error) {
+	durations := map[Type]time.Duration{}
+	err := pairs.Parse(s, name, "type=duration", func(key, value string) error {
+		t := Type(key)
+		if !slices.Contains(types, t) {
+			return fmt.Errorf("unknown task type \"%s\"", t)
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("expected a non-negative duration")
+		}
+
+		durations[t] = d
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return durations, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	v1 "k8s.io/api/core/v1"
+)
+
+type (
+	// DeleteResourceSpec describes a task of type "DeletePod" or "DeletePvc"
+	DeleteResourceSpec struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		// GracePeriodSeconds overrides the grace period of the object, optional
+		GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+		// PropagationPolicy is either Orphan, Background or Foreground, optional
+		PropagationPolicy string `json:"propagationPolicy,omitempty"`
+	}
+
+	// CancelWorkflowSpec describes a task of type "CancelWorkflow"
+	CancelWorkflowSpec struct {
+		Reason string `json:"reason,omitempty"`
+	}
+
+	// SpecError is the non-retriable error of a task whose spec does not match the schema of its type
+	SpecError struct {
+		Type   Type
+		Fields []FieldError
+	}
+
+	// FieldError is a single violation of the schema
+	FieldError struct {
+		// Field is the JSON pointer of the field in the spec, empty for the spec itself
+		Field   string
+		Message string
+	}
+)
+
+var (
+	//go:embed schemas/*.json
+	schemaFiles embed.FS
+
+	// schemas of the task specs by type, compiled once
+	schemas = compileSchemas()
+	printer = message.NewPrinter(language.English)
+)
+
+// newSpec returns a pointer to the typed spec of a task type, and the spec value that pointer holds once it is decoded
+func newSpec(t Type) (interface{}, func() interface{}) {
+	switch t {
+	case TypeAgentTask:
+		spec := &AgentTask{}
+		return spec, func() interface{} { return *spec }
+	case TypeCreatePod:
+		spec := &v1.Pod{}
+		return spec, func() interface{} { return spec }
+	case TypeCreatePVC:
+		spec := &v1.PersistentVolumeClaim{}
+		return spec, func() interface{} { return spec }
+	case TypeDeletePod, TypeDeletePVC:
+		spec := &DeleteResourceSpec{}
+		return spec, func() interface{} { return *spec }
+	case TypeCancelWorkflow:
+		spec := &CancelWorkflowSpec{}
+		return spec, func() interface{} { return *spec }
+	default:
+		return nil, nil
+	}
+}
+
+// decodeSpec validates the raw spec of a task against the schema of its type and decodes it into the typed spec.
+// Specs of unknown types, and specs that are not valid, are decoded as plain JSON values
+func decodeSpec(t Type, raw json.RawMessage) (interface{}, *SpecError) {
+	var plain interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &plain); err != nil {
+			return nil, &SpecError{Type: t, Fields: []FieldError{{Message: err.Error()}}}
+		}
+	}
+
+	schema, ok := schemas[t]
+	if !ok {
+		return plain, nil
+	}
+
+	if specErr := validateSpec(t, schema, raw); specErr != nil {
+		return plain, specErr
+	}
+
+	target, value := newSpec(t)
+	if len(raw) == 0 {
+		return value(), nil
+	}
+
+	if err := json.Unmarshal(raw, target); err != nil {
+		return plain, &SpecError{Type: t, Fields: []FieldError{{Message: err.Error()}}}
+	}
+
+	return value(), nil
+}
+
+func validateSpec(t Type, schema *jsonschema.Schema, raw json.RawMessage) *SpecError {
+	if len(raw) == 0 {
+		raw = json.RawMessage("null")
+	}
+
+	// the validator works on its own representation of JSON numbers
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return &SpecError{Type: t, Fields: []FieldError{{Message: err.Error()}}}
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	specErr := &SpecError{Type: t}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		specErr.Fields = []FieldError{{Message: err.Error()}}
+		return specErr
+	}
+
+	specErr.Fields = fieldErrors(verr, nil)
+
+	sort.SliceStable(specErr.Fields, func(i, j int) bool {
+		return specErr.Fields[i].Field < specErr.Fields[j].Field
+	})
+	return specErr
+}
+
+// fieldErrors returns the leaves of the validation error tree, the inner nodes ($ref, allOf, ...) only say that validation failed
+func fieldErrors(e *jsonschema.ValidationError, res []FieldError) []FieldError {
+	if len(e.Causes) == 0 {
+		field := ""
+		if len(e.InstanceLocation) > 0 {
+			field = "/" + strings.Join(e.InstanceLocation, "/")
+		}
+
+		return append(res, FieldError{Field: field, Message: e.ErrorKind.LocalizedString(printer)})
+	}
+
+	for _, cause := range e.Causes {
+		res = fieldErrors(cause, res)
+	}
+
+	return res
+}
+
+func compileSchemas() map[Type]*jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	res := map[Type]*jsonschema.Schema{}
+	for _, t := range []Type{TypeAgentTask, TypeCreatePod, TypeCreatePVC, TypeDeletePod, TypeDeletePVC, TypeCancelWorkflow} {
+		name := fmt.Sprintf("schemas/%s.json", t)
+		data, err := schemaFiles.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			panic(fmt.Errorf("failed parsing %s: %w", name, err))
+		}
+
+		if err := c.AddResource(name, doc); err != nil {
+			panic(err)
+		}
+
+		res[t] = c.MustCompile(name)
+	}
+
+	return res
+}
+
+// Error lists the fields that do not match the schema
+func (e *SpecError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		field := f.Field
+		if field == "" {
+			field = "spec"
+		}
+
+		fields = append(fields, fmt.Sprintf("%s: %s", field, f.Message))
+	}
+
+	return fmt.Sprintf("invalid %s task spec: %s", e.Type, strings.Join(fields, "; "))
+}
+
+// IsRetriable is false, the spec would be just as invalid on a retry
+func (e *SpecError) IsRetriable() bool {
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUnmarshalTasks_spec(t *testing.T) {
+	tests := map[string]struct {
+		data       string
+		wantSpec   interface{}
+		wantErr    string
+		wantFields []string
+	}{
+		"should decode an agent task": {
+			data:     `[{"type":"AgentTask","spec":{"type":"proxy","params":{"data":3}}}]`,
+			wantSpec: AgentTask{Type: "proxy", Params: map[string]interface{}{"data": float64(3)}},
+		},
+		"should decode a pod": {
+			data: `[{"type":"CreatePod","spec":{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p","namespace":"ns"},"spec":{"containers":[{"name":"engine","image":"alpine"}]}}}]`,
+			wantSpec: &v1.Pod{
+				TypeMeta:   metav1TypeMeta("Pod"),
+				ObjectMeta: objectMeta("p", "ns"),
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "engine", Image: "alpine"}}},
+			},
+		},
+		"should decode a pvc": {
+			data: `[{"type":"CreatePvc","spec":{"apiVersion":"v1","kind":"PersistentVolumeClaim","metadata":{"name":"p","namespace":"ns"}}}]`,
+			wantSpec: &v1.PersistentVolumeClaim{
+				TypeMeta:   metav1TypeMeta("PersistentVolumeClaim"),
+				ObjectMeta: objectMeta("p", "ns"),
+			},
+		},
+		"should decode delete options": {
+			data:     `[{"type":"DeletePvc","spec":{"name":"p","namespace":"ns"}}]`,
+			wantSpec: DeleteResourceSpec{Name: "p", Namespace: "ns"},
+		},
+		"should decode delete options with a grace period and a propagation policy": {
+			data:     `[{"type":"DeletePod","spec":{"name":"p","namespace":"ns","gracePeriodSeconds":0,"propagationPolicy":"Foreground"}}]`,
+			wantSpec: DeleteResourceSpec{Name: "p", Namespace: "ns", GracePeriodSeconds: new(int64), PropagationPolicy: "Foreground"},
+		},
+		"should decode a workflow cancellation": {
+			data:     `[{"type":"CancelWorkflow","spec":{"reason":"build aborted"}}]`,
+			wantSpec: CancelWorkflowSpec{Reason: "build aborted"},
+		},
+		"should decode a workflow cancellation without a spec": {
+			data:     `[{"type":"CancelWorkflow"}]`,
+			wantSpec: CancelWorkflowSpec{},
+		},
+		"should keep the spec of an unknown type": {
+			data:     `[{"type":"Unknown","spec":{"a":"b"}}]`,
+			wantSpec: map[string]interface{}{"a": "b"},
+		},
+		"should list the fields that do not match the schema": {
+			data:       `[{"type":"CreatePod","spec":{"apiVersion":"v1","kind":"Job","metadata":{"name":""},"spec":{"containers":[{"name":"engine"}]}}}]`,
+			wantSpec:   map[string]interface{}{"apiVersion": "v1", "kind": "Job", "metadata": map[string]interface{}{"name": ""}, "spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "engine"}}}},
+			wantErr:    "invalid CreatePod task spec: ",
+			wantFields: []string{"/kind", "/metadata", "/metadata/name", "/spec/containers/0"},
+		},
+		"should reject a missing spec": {
+			data:       `[{"type":"DeletePod"}]`,
+			wantErr:    "invalid DeletePod task spec: spec: ",
+			wantFields: []string{""},
+		},
+		"should reject an unknown propagation policy": {
+			data:       `[{"type":"DeletePvc","spec":{"name":"p","namespace":"ns","propagationPolicy":"Later"}}]`,
+			wantSpec:   map[string]interface{}{"name": "p", "namespace": "ns", "propagationPolicy": "Later"},
+			wantErr:    "invalid DeletePvc task spec: /propagationPolicy: ",
+			wantFields: []string{"/propagationPolicy"},
+		},
+		"should reject a spec of the wrong type": {
+			data:       `[{"type":"DeletePod","spec":{"name":1,"namespace":"ns"}}]`,
+			wantSpec:   map[string]interface{}{"name": float64(1), "namespace": "ns"},
+			wantErr:    "invalid DeletePod task spec: /name: ",
+			wantFields: []string{"/name"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tasks, err := UnmarshalTasks([]byte(tt.data))
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 1)
+			assert.Equal(t, tt.wantSpec, tasks[0].Spec)
+			specErr := tasks[0].SpecError()
+			if tt.wantErr == "" {
+				assert.Nil(t, specErr)
+				return
+			}
+
+			assert.NotNil(t, specErr)
+			assert.Contains(t, specErr.Error(), tt.wantErr)
+			assert.False(t, errors.IsRetriable(specErr))
+			fields := []string{}
+			for _, f := range specErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestUnmarshalTasks_roundTrip(t *testing.T) {
+	data := `[{"_id":"1","type":"CreatePod","metadata":{"workflowId":"wf"},"spec":{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p","namespace":"ns"},"spec":{"containers":[{"name":"engine","image":"alpine"}]}}}]`
+	tasks, err := UnmarshalTasks([]byte(data))
+	assert.NoError(t, err)
+
+	// typed specs are marshalled back into specs that are just as valid
+	b, err := tasks.Marshal()
+	assert.NoError(t, err)
+	again, err := UnmarshalTasks(b)
+	assert.NoError(t, err)
+	assert.Nil(t, again[0].SpecError())
+	assert.Equal(t, tasks[0].Spec, again[0].Spec)
+	assert.Equal(t, "wf", again[0].Metadata.WorkflowId)
+}
+
+func metav1TypeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: kind}
+}
+
+func objectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: namespace}
+}
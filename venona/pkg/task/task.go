@@ -15,6 +15,7 @@
 package task
 
 import (
+	"context"
 	"encoding/json"
 	"sort"
 	"time"
@@ -29,11 +30,17 @@ const (
 	TypeDeletePod Type = "DeletePod"
 	TypeDeletePVC Type = "DeletePvc"
 	TypeAgentTask Type = "AgentTask"
+
+	// TypeCancelWorkflow cancels the workflow of the task, its spec is CancelWorkflowSpec
+	TypeCancelWorkflow Type = "CancelWorkflow"
 )
 
 const (
-	StatusSuccess Status = "Success"
-	StatusError   Status = "Error"
+	StatusSuccess   Status = "Success"
+	StatusError     Status = "Error"
+	StatusCancelled Status = "Cancelled"
+	// StatusWaiting is reported while a task waits for capacity in its namespace, before its final status
+	StatusWaiting Status = "Waiting"
 )
 
 type (
@@ -48,13 +55,17 @@ type (
 
 	// Task options
 	Task struct {
-		Id       string      `json:"_id"`
-		Type     Type        `json:"type"`
-		Metadata Metadata    `json:"metadata"`
-		Spec     interface{} `json:"spec"`
+		Id       string   `json:"_id"`
+		Type     Type     `json:"type"`
+		Metadata Metadata `json:"metadata"`
+		// Spec is the typed spec of the task type when the task is unmarshalled with UnmarshalTasks:
+		// AgentTask, *v1.Pod, *v1.PersistentVolumeClaim, DeleteResourceSpec or CancelWorkflowSpec
+		Spec interface{} `json:"spec"`
 
 		// only used in AgentTasks
 		Timeline Timeline
+
+		specErr *SpecError
 	}
 
 	// Metadata options
@@ -64,6 +75,9 @@ type (
 		WorkflowId            string `json:"workflowId"`
 		CurrentStatusRevision int    `json:"currentStatusRevision"`
 		ShouldReportStatus    bool   `json:"shouldReportStatus"`
+
+		// TraceContext is the W3C trace-context (traceparent, tracestate) of the platform span that created the task
+		TraceContext map[string]string `json:"traceContext,omitempty"`
 	}
 
 	// Timeline values
@@ -78,6 +92,8 @@ type (
 		Params map[string]interface{} `json:"params"`
 	}
 
+	metadataKey struct{}
+
 	TaskStatus struct {
 		Status         Status    `json:"status"`
 		OccurredAt     time.Time `json:"occurredAt"`
@@ -87,11 +103,33 @@ type (
 	}
 )
 
-// UnmarshalTasks with json
+// UnmarshalTasks with json, the spec of every task is validated and decoded into the typed spec of its type.
+// Tasks whose spec is not valid are returned with a SpecError
 func UnmarshalTasks(data []byte) (Tasks, error) {
-	var r Tasks
-	err := json.Unmarshal(data, &r)
-	return r, err
+	var raw []struct {
+		Task
+		Spec json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		return nil, nil
+	}
+
+	r := make(Tasks, len(raw))
+	for i := range raw {
+		r[i] = raw[i].Task
+		r[i].Spec, r[i].specErr = decodeSpec(r[i].Type, raw[i].Spec)
+	}
+
+	return r, nil
+}
+
+// SpecError returns the error of a spec that does not match the schema of the task type, or nil
+func (t *Task) SpecError() *SpecError {
+	return t.specErr
 }
 
 // Marshal tasks
@@ -108,14 +146,26 @@ func Less(task1 Task, task2 Task) bool {
 	return task1.Metadata.CreatedAt < task2.Metadata.CreatedAt
 }
 
-// NewTaskTransaction creates a new transaction with task-specific attributes
+// NewTaskTransaction creates a new transaction with task-specific attributes,
+// continuing the trace the task was created in, if there is one
 func NewTaskTransaction(monitor monitoring.Monitor, m Metadata) monitoring.Transaction {
-	txn := monitor.NewTransaction("runner-tasks-execution")
+	txn := monitor.NewTransactionWithTraceContext("runner-tasks-execution", m.TraceContext)
 	txn.AddAttribute("tid", m.WorkflowId)
 	txn.AddAttribute("runtime-environment", m.ReName)
 	return txn
 }
 
+// WithMetadata returns a copy of ctx that carries the metadata of the workflow being handled
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// MetadataFromContext returns the metadata of the workflow being handled, false when ctx has none
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	m, ok := ctx.Value(metadataKey{}).(Metadata)
+	return m, ok
+}
+
 // SortByType sorts the tasks in the specified order: TypeCreatePVC, TypeCreatePod, TypeDeletePod, TypeDeletePVC
 func SortByType(tasks []*Task) {
 	sort.SliceStable(tasks, func(i, j int) bool {
@@ -0,0 +1,217 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskfilter drops the pulled tasks that should not be handled: tasks that are older than the TTL of their type,
+// which nobody is waiting for anymore, and tasks that were already delivered to the agent
+package taskfilter
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/metrics"
+	"github.com/codefresh-io/go/venona/pkg/task"
+)
+
+const defaultCacheSize = 10000
+
+type (
+	// Options for the filter
+	Options struct {
+		// TTLs of the tasks by type, from their creation in the platform. Types without a TTL never expire
+		TTLs map[task.Type]time.Duration
+		// CacheSize is the number of task ids that are remembered for deduplication (default 10000)
+		CacheSize int
+		Logger    logger.Logger
+	}
+
+	// Filter of pulled tasks, safe for concurrent use
+	Filter struct {
+		ttls  map[task.Type]time.Duration
+		size  int
+		log   logger.Logger
+		mutex sync.Mutex
+		// seen holds the task ids, most recent first
+		seen  *list.List
+		index map[string]*list.Element
+	}
+
+	// ExpiredError is the non-retriable error of a task that is older than its TTL
+	ExpiredError struct {
+		Task task.Task
+		Age  time.Duration
+		TTL  time.Duration
+	}
+
+	seenTask struct {
+		id       string
+		revision int
+	}
+)
+
+var (
+	errOptionsRequired = errors.New("Options are required")
+	errLoggerRequired  = errors.New("Logger options is required")
+
+	taskTypes = map[task.Type]bool{
+		task.TypeCreatePod:      true,
+		task.TypeCreatePVC:      true,
+		task.TypeDeletePod:      true,
+		task.TypeDeletePVC:      true,
+		task.TypeAgentTask:      true,
+		task.TypeCancelWorkflow: true,
+	}
+
+	// now is overridden in tests
+	now = time.Now
+)
+
+// New creates a new Filter
+func New(opts *Options) (*Filter, error) {
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+
+	size := opts.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	return &Filter{
+		ttls:  opts.TTLs,
+		size:  size,
+		log:   opts.Logger,
+		seen:  list.New(),
+		index: map[string]*list.Element{},
+	}, nil
+}
+
+// Filter returns the tasks that should be handled, and the expired ones, whose status should be reported.
+// A task is a duplicate when its id was already seen, unless the platform delivers it again with a newer
+// status revision (a retry); duplicates are dropped
+func (f *Filter) Filter(tasks task.Tasks) (task.Tasks, []*ExpiredError) {
+	res := make(task.Tasks, 0, len(tasks))
+	expired := []*ExpiredError{}
+	t0 := now()
+	for _, t := range tasks {
+		if f.duplicate(t) {
+			f.log.Warn("Dropping duplicate task", "task", t.Id, "type", t.Type, "workflow", t.Metadata.WorkflowId, "revision", t.Metadata.CurrentStatusRevision)
+			metrics.IncDuplicateTasks(t.Type)
+			continue
+		}
+
+		if err := f.expired(t, t0); err != nil {
+			f.log.Warn("Dropping expired task", "task", t.Id, "type", t.Type, "workflow", t.Metadata.WorkflowId, "age", err.Age, "ttl", err.TTL)
+			metrics.IncExpiredTasks(t.Type)
+			expired = append(expired, err)
+			continue
+		}
+
+		res = append(res, t)
+	}
+
+	return res, expired
+}
+
+// Forget removes task ids from the cache, for tasks that are handed back to the platform unhandled
+func (f *Filter) Forget(ids []string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, id := range ids {
+		if e, ok := f.index[id]; ok {
+			f.seen.Remove(e)
+			delete(f.index, id)
+		}
+	}
+}
+
+// duplicate checks if the task was already seen, and remembers it otherwise
+func (f *Filter) duplicate(t task.Task) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if e, ok := f.index[t.Id]; ok {
+		s := e.Value.(*seenTask)
+		if t.Metadata.CurrentStatusRevision <= s.revision {
+			f.seen.MoveToFront(e)
+			return true
+		}
+
+		s.revision = t.Metadata.CurrentStatusRevision
+		f.seen.MoveToFront(e)
+		return false
+	}
+
+	f.index[t.Id] = f.seen.PushFront(&seenTask{id: t.Id, revision: t.Metadata.CurrentStatusRevision})
+	if f.seen.Len() > f.size {
+		oldest := f.seen.Back()
+		f.seen.Remove(oldest)
+		delete(f.index, oldest.Value.(*seenTask).id)
+	}
+
+	return false
+}
+
+func (f *Filter) expired(t task.Task, t0 time.Time) *ExpiredError {
+	ttl := f.ttls[t.Type]
+	if ttl <= 0 {
+		return nil
+	}
+
+	created, err := time.Parse(time.RFC3339, t.Metadata.CreatedAt)
+	if err != nil {
+		// tasks without a valid creation time are handled, rather than dropped by mistake
+		return nil
+	}
+
+	age := t0.Sub(created)
+	if age <= ttl {
+		return nil
+	}
+
+	return &ExpiredError{Task: t, Age: age, TTL: ttl}
+}
+
+// Error returns the reason that is reported for the task
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("task expired: created %s ago, more than the %s TTL of %s tasks", e.Age.Round(time.Second), e.TTL, e.Task.Type)
+}
+
+// IsRetriable is false, retrying an expired task would only expire it again
+func (e *ExpiredError) IsRetriable() bool {
+	return false
+}
+
+// ParseTTLs parses a comma separated list of type=duration pairs, e.g. "CreatePod=1h,CreatePvc=1h".
+// A duration of 0 disables the TTL of the type
+func ParseTTLs(s string) (map[task.Type]time.Duration, error) {
+	return task.ParseDurations(s, "task TTL", slices.Collect(maps.Keys(taskTypes))...)
+}
+
+func checkOptions(opts *Options) error {
+	if opts == nil {
+		return errOptionsRequired
+	}
+
+	if opts.Logger == nil {
+		return errLoggerRequired
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go/venona/pkg/errors"
+	"github.com/codefresh-io/go/venona/pkg/logger"
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTask(id string, t task.Type, age time.Duration, revision int) task.Task {
+	return task.Task{
+		Id:   id,
+		Type: t,
+		Metadata: task.Metadata{
+			CreatedAt:             now().Add(-age).Format(time.RFC3339),
+			CurrentStatusRevision: revision,
+		},
+	}
+}
+
+func ids(tasks task.Tasks) []string {
+	res := []string{}
+	for _, t := range tasks {
+		res = append(res, t.Id)
+	}
+
+	return res
+}
+
+func TestFilter_Filter(t *testing.T) {
+	ttls := map[task.Type]time.Duration{task.TypeCreatePod: time.Hour}
+	tests := map[string]struct {
+		cacheSize   int
+		polls       []task.Tasks
+		wantLast    []string
+		wantExpired []string
+	}{
+		"should pass fresh tasks": {
+			polls: []task.Tasks{{
+				newTask("1", task.TypeCreatePod, time.Minute, 0),
+				newTask("2", task.TypeDeletePod, 5*time.Hour, 0),
+			}},
+			wantLast:    []string{"1", "2"},
+			wantExpired: []string{},
+		},
+		"should expire tasks older than the ttl of their type": {
+			polls: []task.Tasks{{
+				newTask("1", task.TypeCreatePod, 2*time.Hour, 0),
+				newTask("2", task.TypeCreatePVC, 2*time.Hour, 0),
+			}},
+			wantLast:    []string{"2"},
+			wantExpired: []string{"1"},
+		},
+		"should pass tasks without a valid creation time": {
+			polls: []task.Tasks{{
+				{Id: "1", Type: task.TypeCreatePod, Metadata: task.Metadata{CreatedAt: "yesterday"}},
+			}},
+			wantLast:    []string{"1"},
+			wantExpired: []string{},
+		},
+		"should drop tasks that were already delivered": {
+			polls: []task.Tasks{
+				{newTask("1", task.TypeCreatePod, 0, 0), newTask("2", task.TypeCreatePod, 0, 0)},
+				{newTask("2", task.TypeCreatePod, 0, 0), newTask("3", task.TypeCreatePod, 0, 0), newTask("3", task.TypeCreatePod, 0, 0)},
+			},
+			wantLast:    []string{"3"},
+			wantExpired: []string{},
+		},
+		"should pass tasks that are delivered again with a newer revision": {
+			polls: []task.Tasks{
+				{newTask("1", task.TypeCreatePod, 0, 0)},
+				{newTask("1", task.TypeCreatePod, 0, 1)},
+			},
+			wantLast:    []string{"1"},
+			wantExpired: []string{},
+		},
+		"should forget the least recent tasks once the cache is full": {
+			cacheSize: 2,
+			polls: []task.Tasks{
+				{newTask("1", task.TypeCreatePod, 0, 0), newTask("2", task.TypeCreatePod, 0, 0), newTask("3", task.TypeCreatePod, 0, 0)},
+				{newTask("1", task.TypeCreatePod, 0, 0), newTask("3", task.TypeCreatePod, 0, 0)},
+			},
+			wantLast:    []string{"1"},
+			wantExpired: []string{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := New(&Options{TTLs: ttls, CacheSize: tt.cacheSize, Logger: logger.New(logger.Options{})})
+			assert.NoError(t, err)
+			var got task.Tasks
+			var expired []*ExpiredError
+			for _, p := range tt.polls {
+				got, expired = f.Filter(p)
+			}
+
+			assert.Equal(t, tt.wantLast, ids(got))
+			expiredIDs := []string{}
+			for _, e := range expired {
+				expiredIDs = append(expiredIDs, e.Task.Id)
+				assert.False(t, errors.IsRetriable(e))
+				assert.Contains(t, e.Error(), "task expired")
+			}
+			assert.Equal(t, tt.wantExpired, expiredIDs)
+			assert.LessOrEqual(t, f.seen.Len(), f.size)
+		})
+	}
+}
+
+func TestFilter_Forget(t *testing.T) {
+	f, err := New(&Options{Logger: logger.New(logger.Options{})})
+	assert.NoError(t, err)
+	tasks := task.Tasks{newTask("1", task.TypeCreatePod, 0, 0), newTask("2", task.TypeCreatePod, 0, 0)}
+	f.Filter(tasks)
+	f.Forget([]string{"1"})
+
+	got, _ := f.Filter(tasks)
+	assert.Equal(t, []string{"1"}, ids(got))
+}
+
+func TestParseTTLs(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    map[task.Type]time.Duration
+		wantErr string
+	}{
+		"should parse an empty string": {
+			input: "",
+			want:  map[task.Type]time.Duration{},
+		},
+		"should parse ttls by type": {
+			input: "CreatePod=1h, CreatePvc = 30m,DeletePod=0",
+			want: map[task.Type]time.Duration{
+				task.TypeCreatePod: time.Hour,
+				task.TypeCreatePVC: 30 * time.Minute,
+				task.TypeDeletePod: 0,
+			},
+		},
+		"should fail on a missing duration": {
+			input:   "CreatePod",
+			wantErr: "invalid task TTL \"CreatePod\", expected type=duration",
+		},
+		"should fail on an unknown type": {
+			input:   "CreateJob=1h",
+			wantErr: "invalid task TTL \"CreateJob=1h\", unknown task type \"CreateJob\"",
+		},
+		"should fail on a negative duration": {
+			input:   "CreatePod=-1h",
+			wantErr: "invalid task TTL \"CreatePod=-1h\", expected a non-negative duration",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTTLs(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
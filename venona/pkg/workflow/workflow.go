@@ -15,10 +15,13 @@
 package workflow
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/codefresh-io/go/venona/pkg/task"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type (
@@ -32,6 +35,25 @@ type (
 
 	// Type is the type of the workflow batch create/terminate/both
 	Type string
+
+	// resource is the namespace and name of the resource of a task, from the object of a create task
+	// or the delete options of a delete task
+	resource struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Metadata  *struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+)
+
+var (
+	// deleteOf is the delete task type that cancels out each create task type
+	deleteOf = map[task.Type]task.Type{
+		task.TypeCreatePod: task.TypeDeletePod,
+		task.TypeCreatePVC: task.TypeDeletePVC,
+	}
 )
 
 const (
@@ -71,6 +93,65 @@ func (wf *Workflow) AddTask(t *task.Task) error {
 	return nil
 }
 
+// Coalesce removes the tasks that create a resource together with the tasks that delete the same resource,
+// so a workflow that is created and terminated within the same poll does not create anything.
+// Only first deliveries of create tasks are coalesced, a retried one might have created the resource already.
+// It returns the removed tasks, creates followed by their deletes
+func (wf *Workflow) Coalesce() []*task.Task {
+	creates := map[string]*task.Task{}
+	for _, t := range wf.Tasks {
+		if _, ok := deleteOf[t.Type]; ok && t.Metadata.CurrentStatusRevision == 0 {
+			if key, ok := resourceKey(t, deleteOf[t.Type]); ok {
+				creates[key] = t
+			}
+		}
+	}
+
+	if len(creates) == 0 {
+		return nil
+	}
+
+	coalesced := map[*task.Task]bool{}
+	res := []*task.Task{}
+	for _, t := range wf.Tasks {
+		if workflowTypeFromTaskType(t.Type) != workflowTypeTerminate {
+			continue
+		}
+
+		key, ok := resourceKey(t, t.Type)
+		if !ok {
+			continue
+		}
+
+		if c, ok := creates[key]; ok && !coalesced[c] {
+			coalesced[c], coalesced[t] = true, true
+			res = append(res, c, t)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	tasks := make([]*task.Task, 0, len(wf.Tasks)-len(res))
+	wf.Type = workflowTypeNone
+	for _, t := range wf.Tasks {
+		if coalesced[t] {
+			continue
+		}
+
+		tasks = append(tasks, t)
+		if wfType := workflowTypeFromTaskType(t.Type); wf.Type == workflowTypeNone {
+			wf.Type = wfType
+		} else if wf.Type != wfType {
+			wf.Type = workflowTypeBoth
+		}
+	}
+
+	wf.Tasks = tasks
+	return res
+}
+
 func (wf *Workflow) GetLatency() (sinceCreation, inRunner, processed time.Duration) {
 	end := time.Now()
 	created, _ := time.Parse(time.RFC3339, wf.Metadata.CreatedAt)
@@ -94,3 +175,34 @@ func workflowTypeFromTaskType(t task.Type) Type {
 		return workflowTypeNone
 	}
 }
+
+// resourceKey identifies the resource of a task by the delete task type and the namespace/name of the resource
+func resourceKey(t *task.Task, deleteType task.Type) (string, bool) {
+	switch spec := t.Spec.(type) {
+	case metav1.Object:
+		return fmt.Sprintf("%s/%s/%s", deleteType, spec.GetNamespace(), spec.GetName()), spec.GetName() != ""
+	case task.DeleteResourceSpec:
+		return fmt.Sprintf("%s/%s/%s", deleteType, spec.Namespace, spec.Name), spec.Name != ""
+	}
+
+	// the spec was not decoded by task.UnmarshalTasks
+	b, err := json.Marshal(t.Spec)
+	if err != nil {
+		return "", false
+	}
+
+	r := resource{}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return "", false
+	}
+
+	if r.Metadata != nil {
+		r.Name, r.Namespace = r.Metadata.Name, r.Metadata.Namespace
+	}
+
+	if r.Name == "" {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s/%s/%s", deleteType, r.Namespace, r.Name), true
+}
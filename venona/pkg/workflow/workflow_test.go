@@ -0,0 +1,126 @@
+// Copyright 2026 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/codefresh-io/go/venona/pkg/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTask(id string, t task.Type, name string, revision int) *task.Task {
+	return &task.Task{
+		Id:   id,
+		Type: t,
+		Metadata: task.Metadata{
+			WorkflowId:            "wf",
+			CurrentStatusRevision: revision,
+		},
+		Spec: map[string]interface{}{
+			"apiVersion": "v1",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "ns"},
+		},
+	}
+}
+
+func deleteTask(id string, t task.Type, name string) *task.Task {
+	return &task.Task{
+		Id:       id,
+		Type:     t,
+		Metadata: task.Metadata{WorkflowId: "wf"},
+		Spec:     map[string]interface{}{"name": name, "namespace": "ns"},
+	}
+}
+
+func TestWorkflow_Coalesce(t *testing.T) {
+	tests := map[string]struct {
+		tasks         []*task.Task
+		wantCoalesced []string
+		wantTasks     []string
+		wantType      Type
+	}{
+		"should coalesce creating and deleting the same resources": {
+			tasks: []*task.Task{
+				createTask("1", task.TypeCreatePVC, "pvc", 0),
+				createTask("2", task.TypeCreatePod, "pod", 0),
+				deleteTask("3", task.TypeDeletePod, "pod"),
+				deleteTask("4", task.TypeDeletePVC, "pvc"),
+			},
+			wantCoalesced: []string{"2", "3", "1", "4"},
+			wantTasks:     []string{},
+			wantType:      workflowTypeNone,
+		},
+		"should keep deleting other resources": {
+			tasks: []*task.Task{
+				createTask("1", task.TypeCreatePod, "pod", 0),
+				deleteTask("2", task.TypeDeletePod, "pod"),
+				deleteTask("3", task.TypeDeletePVC, "pvc"),
+			},
+			wantCoalesced: []string{"1", "2"},
+			wantTasks:     []string{"3"},
+			wantType:      workflowTypeTerminate,
+		},
+		"should not coalesce resources of different types with the same name": {
+			tasks: []*task.Task{
+				createTask("1", task.TypeCreatePVC, "same", 0),
+				deleteTask("2", task.TypeDeletePod, "same"),
+			},
+			wantTasks: []string{"1", "2"},
+			wantType:  workflowTypeBoth,
+		},
+		"should not coalesce retried create tasks": {
+			tasks: []*task.Task{
+				createTask("1", task.TypeCreatePod, "pod", 1),
+				deleteTask("2", task.TypeDeletePod, "pod"),
+			},
+			wantTasks: []string{"1", "2"},
+			wantType:  workflowTypeBoth,
+		},
+		"should coalesce a create task only once": {
+			tasks: []*task.Task{
+				createTask("1", task.TypeCreatePod, "pod", 0),
+				deleteTask("2", task.TypeDeletePod, "pod"),
+				deleteTask("3", task.TypeDeletePod, "pod"),
+			},
+			wantCoalesced: []string{"1", "2"},
+			wantTasks:     []string{"3"},
+			wantType:      workflowTypeTerminate,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			wf := New(task.Metadata{WorkflowId: "wf"})
+			for _, task := range tt.tasks {
+				assert.NoError(t, wf.AddTask(task))
+			}
+
+			task.SortByType(wf.Tasks)
+			var coalesced []string
+			for _, c := range wf.Coalesce() {
+				coalesced = append(coalesced, c.Id)
+			}
+
+			assert.Equal(t, tt.wantCoalesced, coalesced)
+			ids := []string{}
+			for _, t := range wf.Tasks {
+				ids = append(ids, t.Id)
+			}
+			assert.Equal(t, tt.wantTasks, ids)
+			assert.Equal(t, tt.wantType, wf.Type)
+		})
+	}
+}